@@ -2,8 +2,10 @@ package edgecenterprotection_go
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 )
 
 const (
@@ -33,13 +35,39 @@ var _ AliasesService = &AliasesServiceOp{}
 
 // Alias represents an alias for Edgecenter DDoS protection resource
 type Alias struct {
-	ID        int64   `json:"id"`
-	Created   string  `json:"alias_created"`
-	Updated   string  `json:"alias_updated"`
-	Name      string  `json:"alias_data"`
-	SSLExpire int     `json:"alias_ssl_expire,omitempty"`
-	SSLStatus string  `json:"alias_ssl_status"`
-	SSLType   *string `json:"alias_ssl_type"`
+	ID        int64     `json:"id"`
+	Created   time.Time `json:"alias_created"`
+	Updated   time.Time `json:"alias_updated"`
+	Name      string    `json:"alias_data"`
+	SSLExpire int       `json:"alias_ssl_expire,omitempty"`
+	SSLStatus string    `json:"alias_ssl_status"`
+	SSLType   *string   `json:"alias_ssl_type"`
+}
+
+// UnmarshalJSON decodes the alias, parsing timestamps in any of the formats returned by the API
+func (a *Alias) UnmarshalJSON(data []byte) error {
+	type alias Alias
+
+	aux := struct {
+		*alias
+		Created json.RawMessage `json:"alias_created"`
+		Updated json.RawMessage `json:"alias_updated"`
+	}{alias: (*alias)(a)}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	var err error
+	if a.Created, err = unmarshalAPITime(aux.Created); err != nil {
+		return err
+	}
+
+	if a.Updated, err = unmarshalAPITime(aux.Updated); err != nil {
+		return err
+	}
+
+	return nil
 }
 
 // AliasCreateRequest represents a request to create an alias for DDoS protection resource
@@ -106,18 +134,38 @@ func (s *AliasesServiceOp) Get(ctx context.Context, resourceID int64, aliasID in
 
 // Add alias for DDoS resource
 func (s *AliasesServiceOp) Create(ctx context.Context, resourceID int64, reqBody *AliasCreateRequest) (*Alias, *Response, error) {
-	path := fmt.Sprintf("%s/%d/%s", resourcesBasePathV2, resourceID, aliasesPathV2)
-
 	if reqBody == nil {
 		return nil, nil, NewArgError("reqBody", "cannot be nil")
 	}
 
+	if s.client.parentValidation {
+		parent, resp, err := s.client.Resources.Get(ctx, resourceID)
+		if err != nil {
+			return nil, resp, err
+		}
+
+		if err := ValidateAliasForResource(parent, *reqBody); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return s.create(ctx, resourceID, reqBody)
+}
+
+func (s *AliasesServiceOp) create(ctx context.Context, resourceID int64, reqBody *AliasCreateRequest) (*Alias, *Response, error) {
+	path := fmt.Sprintf("%s/%d/%s", resourcesBasePathV2, resourceID, aliasesPathV2)
+
 	err := s.ValidateAliasCreateRequest(*reqBody)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	req, err := s.client.NewRequest(ctx, http.MethodPost, path, reqBody)
+	body := *reqBody
+	if body.Name, err = NormalizeDomainName(body.Name); err != nil {
+		return nil, nil, err
+	}
+
+	req, err := s.client.NewRequest(ctx, http.MethodPost, path, &body)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -146,6 +194,11 @@ func (s *AliasesServiceOp) Delete(ctx context.Context, resourceID int64, aliasID
 
 // Update alias for DDoS resource
 func (s *AliasesServiceOp) Update(ctx context.Context, resourceID int64, aliasID int64, reqBody *AliasUpdateRequest) (*Alias, *Response, error) {
+	return s.update(ctx, resourceID, aliasID, reqBody, "")
+}
+
+// update updates the alias, sending If-Match with ifMatch unless it is empty
+func (s *AliasesServiceOp) update(ctx context.Context, resourceID int64, aliasID int64, reqBody *AliasUpdateRequest, ifMatch string) (*Alias, *Response, error) {
 	if reqBody == nil {
 		return nil, nil, NewArgError("reqBody", "cannot be nil")
 	}
@@ -161,6 +214,7 @@ func (s *AliasesServiceOp) Update(ctx context.Context, resourceID int64, aliasID
 	if err != nil {
 		return nil, nil, err
 	}
+	setIfMatch(req, ifMatch)
 
 	alias := new(Alias)
 	resp, err := s.client.Do(ctx, req, alias)
@@ -173,6 +227,10 @@ func (s *AliasesServiceOp) Update(ctx context.Context, resourceID int64, aliasID
 
 // Check create request data matches restrictions
 func (s *AliasesServiceOp) ValidateAliasCreateRequest(r AliasCreateRequest) error {
+	if _, err := NormalizeDomainName(r.Name); err != nil {
+		return err
+	}
+
 	ssltype := r.SSLType
 	if ssltype != nil {
 		if len(*ssltype) > 0 && *ssltype != "custom" && *ssltype != "le" {
@@ -194,3 +252,11 @@ func (s *AliasesServiceOp) ValidateAliasUpdateRequest(r AliasUpdateRequest) erro
 
 	return nil
 }
+
+// listAllAliases returns the aliases of DDoS resource from every page
+func listAllAliases(ctx context.Context, s AliasesService, resourceID int64) ([]Alias, error) {
+	return listAll(ctx, func(ctx context.Context, limit, offset int) ([]Alias, error) {
+		aliases, _, err := s.List(ctx, resourceID, &AliasListOptions{Limit: limit, Offset: offset})
+		return aliases, err
+	})
+}
@@ -0,0 +1,470 @@
+package edgecenterprotection_go
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// BanRecord represents a time-limited blacklist entry created by BanManager
+type BanRecord struct {
+	ResourceID  int64     `json:"resource_id"`
+	BlacklistID int64     `json:"blacklist_id"`
+	IP          string    `json:"ip"`
+	Reason      string    `json:"reason,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// Expired reports whether the ban is expired at the given time
+func (r BanRecord) Expired(now time.Time) bool {
+	return !now.Before(r.ExpiresAt)
+}
+
+// BanStore persists ban records, so expiry survives restarts of the process managing bans.
+// Blacklist entries have no comment field in the API, so expiry cannot be stored with the entry itself.
+type BanStore interface {
+	List() ([]BanRecord, error)
+	Put(BanRecord) error
+	Delete(resourceID, blacklistID int64) error
+}
+
+// MemoryBanStore is a BanStore keeping records in memory only
+type MemoryBanStore struct {
+	mu      sync.Mutex
+	records []BanRecord
+}
+
+var _ BanStore = &MemoryBanStore{}
+
+// JSONFileBanStore is a BanStore keeping records in a JSON file. The file is rewritten atomically on every change,
+// under a lock on the file path with the ".lock" suffix, so several processes can share the store.
+type JSONFileBanStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+var _ BanStore = &JSONFileBanStore{}
+
+// atomicBanStore is implemented by stores that can look up and put a record without changes in between
+type atomicBanStore interface {
+	putWith(func([]BanRecord) (BanRecord, error)) error
+}
+
+// BanManager creates blacklist entries with an expiry time and removes them once they expire
+type BanManager struct {
+	blacklists BlacklistsService
+	store      BanStore
+	mu         sync.Mutex
+
+	// OnError is called with sweep errors in Run, errors are ignored if nil
+	OnError func(error)
+}
+
+// NewMemoryBanStore returns an empty MemoryBanStore
+func NewMemoryBanStore() *MemoryBanStore {
+	return &MemoryBanStore{}
+}
+
+// List returns all ban records
+func (s *MemoryBanStore) List() ([]BanRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]BanRecord(nil), s.records...), nil
+}
+
+// Put adds a ban record or replaces the record with the same resource and blacklist IDs
+func (s *MemoryBanStore) Put(r BanRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records = putBanRecord(s.records, r)
+
+	return nil
+}
+
+// putWith puts the record returned by fn for the current records while holding the store mutex
+func (s *MemoryBanStore) putWith(fn func([]BanRecord) (BanRecord, error)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, err := fn(append([]BanRecord(nil), s.records...))
+	if err != nil {
+		return err
+	}
+	s.records = putBanRecord(s.records, r)
+
+	return nil
+}
+
+// Delete removes the ban record with the given resource and blacklist IDs
+func (s *MemoryBanStore) Delete(resourceID, blacklistID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records = deleteBanRecord(s.records, resourceID, blacklistID)
+
+	return nil
+}
+
+// NewJSONFileBanStore returns a JSONFileBanStore using the file at path. The file is created on the first change.
+func NewJSONFileBanStore(path string) *JSONFileBanStore {
+	return &JSONFileBanStore{path: path}
+}
+
+// List returns all ban records
+func (s *JSONFileBanStore) List() ([]BanRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.load()
+}
+
+// Put adds a ban record or replaces the record with the same resource and blacklist IDs
+func (s *JSONFileBanStore) Put(r BanRecord) error {
+	return s.update(func(records []BanRecord) ([]BanRecord, error) {
+		return putBanRecord(records, r), nil
+	})
+}
+
+// Delete removes the ban record with the given resource and blacklist IDs
+func (s *JSONFileBanStore) Delete(resourceID, blacklistID int64) error {
+	return s.update(func(records []BanRecord) ([]BanRecord, error) {
+		return deleteBanRecord(records, resourceID, blacklistID), nil
+	})
+}
+
+// putWith puts the record returned by fn for the current records while holding the lock file
+func (s *JSONFileBanStore) putWith(fn func([]BanRecord) (BanRecord, error)) error {
+	return s.update(func(records []BanRecord) ([]BanRecord, error) {
+		r, err := fn(append([]BanRecord(nil), records...))
+		if err != nil {
+			return nil, err
+		}
+
+		return putBanRecord(records, r), nil
+	})
+}
+
+// update applies the change to the records while holding the lock file, so changes of other processes are not lost
+func (s *JSONFileBanStore) update(change func([]BanRecord) ([]BanRecord, error)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := lockFile(s.path+".lock", true)
+	if err != nil {
+		return fmt.Errorf("ban store %s: %w", s.path, err)
+	}
+	defer f.Close()
+
+	records, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	records, err = change(records)
+	if err != nil {
+		return err
+	}
+
+	return s.save(records)
+}
+
+func (s *JSONFileBanStore) load() ([]BanRecord, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var records []BanRecord
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &records); err != nil {
+			return nil, fmt.Errorf("ban store %s: %w", s.path, err)
+		}
+	}
+
+	return records, nil
+}
+
+func (s *JSONFileBanStore) save(records []BanRecord) error {
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// NewBanManager returns a BanManager creating blacklist entries with the given service and recording expiry in store
+func NewBanManager(blacklists BlacklistsService, store BanStore) *BanManager {
+	return &BanManager{
+		blacklists: blacklists,
+		store:      store,
+	}
+}
+
+// Ban adds ip to the blacklist of DDoS resource for ttl. Banning an address that is already banned by this
+// manager extends the existing ban instead of creating another blacklist entry. Addresses are compared in their
+// canonical form, so "192.0.2.1" and "192.0.2.1/32" are the same ban. Stores created by NewMemoryBanStore and
+// NewJSONFileBanStore stay locked from the lookup of the existing ban until the record is written.
+func (m *BanManager) Ban(ctx context.Context, resourceID int64, ip string, ttl time.Duration, reason string) (*BanRecord, error) {
+	if ttl <= 0 {
+		return nil, NewArgError("ttl", "must be positive")
+	}
+
+	ip, err := canonicalBanIP(ip)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var created *Blacklist
+	var record BanRecord
+	err = putBanWith(m.store, func(records []BanRecord) (BanRecord, error) {
+		now := time.Now()
+
+		if r, ok := findBan(records, resourceID, ip); ok {
+			if expires := now.Add(ttl); expires.After(r.ExpiresAt) {
+				r.ExpiresAt = expires
+			}
+			if reason != "" {
+				r.Reason = reason
+			}
+
+			record = r
+			return r, nil
+		}
+
+		blacklist, _, err := m.blacklists.Create(ctx, resourceID, &BlacklistCreateRequest{IP: ip})
+		if err != nil {
+			return BanRecord{}, err
+		}
+		created = blacklist
+
+		record = BanRecord{
+			ResourceID:  resourceID,
+			BlacklistID: blacklist.ID,
+			IP:          ip,
+			Reason:      reason,
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(ttl),
+		}
+		return record, nil
+	})
+	if err != nil {
+		if created != nil {
+			// without a record the entry would never expire, so do not leave it behind
+			_, _ = m.blacklists.Delete(ctx, resourceID, created.ID)
+		}
+		return nil, err
+	}
+
+	return &record, nil
+}
+
+// Unban removes a ban created by this manager before it expires
+func (m *BanManager) Unban(ctx context.Context, resourceID int64, ip string) error {
+	ip, err := canonicalBanIP(ip)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	records, err := m.store.List()
+	if err != nil {
+		return err
+	}
+
+	if r, ok := findBan(records, resourceID, ip); ok {
+		return m.remove(ctx, r)
+	}
+
+	return nil
+}
+
+// Bans returns all bans recorded by this manager
+func (m *BanManager) Bans() ([]BanRecord, error) {
+	return m.store.List()
+}
+
+// Sweep removes expired bans from the blacklists and returns the number of removed bans
+func (m *BanManager) Sweep(ctx context.Context) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	records, err := m.store.List()
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+
+	var errs []error
+	removed := 0
+	for _, r := range records {
+		if !r.Expired(now) {
+			continue
+		}
+
+		if err := m.remove(ctx, r); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		removed++
+	}
+
+	return removed, errors.Join(errs...)
+}
+
+// Run sweeps expired bans immediately and then every interval until ctx is done
+func (m *BanManager) Run(ctx context.Context, interval time.Duration) error {
+	return m.run(ctx, interval, nil)
+}
+
+// RunWithLock is like Run, but first acquires the lock file at lockPath, so that only one sweeper runs at a time.
+// The lock is released by the operating system if the process dies, and checked before every sweep.
+func (m *BanManager) RunWithLock(ctx context.Context, interval time.Duration, lockPath string) error {
+	lock, err := AcquireFileLock(lockPath)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = lock.Release() }()
+
+	return m.run(ctx, interval, lock)
+}
+
+func (m *BanManager) run(ctx context.Context, interval time.Duration, lock *FileLock) error {
+	if interval <= 0 {
+		return NewArgError("interval", "must be positive")
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if lock != nil {
+			if err := lock.Check(); err != nil {
+				return err
+			}
+		}
+
+		if _, err := m.Sweep(ctx); err != nil && m.OnError != nil {
+			m.OnError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// remove deletes the blacklist entry of the ban and its record. Entries already deleted by someone else are fine.
+func (m *BanManager) remove(ctx context.Context, r BanRecord) error {
+	_, err := m.blacklists.Delete(ctx, r.ResourceID, r.BlacklistID)
+	if err != nil && !isResponseStatus(err, http.StatusNotFound) {
+		return err
+	}
+
+	return m.store.Delete(r.ResourceID, r.BlacklistID)
+}
+
+// putBanWith puts the record returned by fn for the current records of store, atomically if the store supports it
+func putBanWith(store BanStore, fn func([]BanRecord) (BanRecord, error)) error {
+	if s, ok := store.(atomicBanStore); ok {
+		return s.putWith(fn)
+	}
+
+	records, err := store.List()
+	if err != nil {
+		return err
+	}
+
+	r, err := fn(records)
+	if err != nil {
+		return err
+	}
+
+	return store.Put(r)
+}
+
+// canonicalBanIP returns ip in the form used for blacklist entries and ban records
+func canonicalBanIP(ip string) (string, error) {
+	p, err := ParseIPPrefix(ip)
+	if err != nil {
+		return "", err
+	}
+
+	return FormatIPPrefix(p), nil
+}
+
+// findBan returns the ban of canonical ip on DDoS resource, comparing the canonical form of recorded addresses
+func findBan(records []BanRecord, resourceID int64, ip string) (BanRecord, bool) {
+	for _, r := range records {
+		if r.ResourceID != resourceID {
+			continue
+		}
+
+		if r.IP == ip {
+			return r, true
+		}
+		if c, err := canonicalBanIP(r.IP); err == nil && c == ip {
+			return r, true
+		}
+	}
+
+	return BanRecord{}, false
+}
+
+func putBanRecord(records []BanRecord, r BanRecord) []BanRecord {
+	for i := range records {
+		if records[i].ResourceID == r.ResourceID && records[i].BlacklistID == r.BlacklistID {
+			records[i] = r
+			return records
+		}
+	}
+
+	return append(records, r)
+}
+
+func deleteBanRecord(records []BanRecord, resourceID, blacklistID int64) []BanRecord {
+	out := records[:0]
+	for _, r := range records {
+		if r.ResourceID != resourceID || r.BlacklistID != blacklistID {
+			out = append(out, r)
+		}
+	}
+
+	return out
+}
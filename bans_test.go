@@ -0,0 +1,317 @@
+package edgecenterprotection_go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestJSONFileBanStoreSharedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bans.json")
+
+	// separate stores share no mutex, like separate processes
+	stores := []*JSONFileBanStore{NewJSONFileBanStore(path), NewJSONFileBanStore(path)}
+
+	const perStore = 50
+	var wg sync.WaitGroup
+	for i, s := range stores {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < perStore; j++ {
+				r := BanRecord{ResourceID: 1, BlacklistID: int64(i*perStore + j), ExpiresAt: time.Now()}
+				if err := s.Put(r); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	records, err := stores[0].List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2*perStore {
+		t.Fatalf("got %d records, want %d", len(records), 2*perStore)
+	}
+
+	if err := stores[1].Delete(1, 0); err != nil {
+		t.Fatal(err)
+	}
+	if records, _ := stores[0].List(); len(records) != 2*perStore-1 {
+		t.Errorf("got %d records after Delete, want %d", len(records), 2*perStore-1)
+	}
+}
+
+func TestFileLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sweeper.lock")
+
+	lock, err := AcquireFileLock(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := lock.Check(); err != nil {
+		t.Fatalf("Check() = %v", err)
+	}
+
+	if _, err := AcquireFileLock(path); !errors.Is(err, ErrLocked) {
+		t.Fatalf("second AcquireFileLock() = %v, want ErrLocked", err)
+	}
+
+	if err := lock.Release(); err != nil {
+		t.Fatalf("Release() = %v", err)
+	}
+
+	other, err := AcquireFileLock(path)
+	if err != nil {
+		t.Fatalf("AcquireFileLock() after Release = %v", err)
+	}
+	defer other.Release()
+}
+
+func TestFileLockLost(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sweeper.lock")
+
+	lock, err := AcquireFileLock(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// someone removes the lock file and another process locks a new one
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	other, err := AcquireFileLock(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Release()
+
+	if err := lock.Check(); !errors.Is(err, ErrLockLost) {
+		t.Errorf("Check() = %v, want ErrLockLost", err)
+	}
+	if err := lock.Release(); !errors.Is(err, ErrLockLost) {
+		t.Errorf("Release() = %v, want ErrLockLost", err)
+	}
+	if err := other.Check(); err != nil {
+		t.Errorf("Check() of the new owner = %v", err)
+	}
+}
+
+// Delete records the deleted blacklist entry and fails with deleteStatus if set
+func (f *fakeBlacklists) Delete(_ context.Context, _ int64, id int64) (*Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.deleted = append(f.deleted, id)
+	if f.deleteStatus != 0 {
+		return nil, &ResponseError{Response: &http.Response{StatusCode: f.deleteStatus}}
+	}
+
+	return nil, nil
+}
+
+// failingPutStore is a BanStore without atomic puts, failing every Put
+type failingPutStore struct {
+	BanStore
+}
+
+func (failingPutStore) Put(BanRecord) error {
+	return errors.New("disk full")
+}
+
+func TestBanManagerBan(t *testing.T) {
+	f := &fakeBlacklists{}
+	m := NewBanManager(f, NewMemoryBanStore())
+	ctx := context.Background()
+
+	first, err := m.Ban(ctx, 1, "192.0.2.1/32", time.Minute, "scanner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.IP != "192.0.2.1" || first.BlacklistID != 1 || first.Reason != "scanner" {
+		t.Errorf("first ban = %+v", first)
+	}
+
+	// the same address in another notation extends the ban
+	second, err := m.Ban(ctx, 1, " ::ffff:192.0.2.1 ", time.Hour, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.BlacklistID != first.BlacklistID || !second.ExpiresAt.After(first.ExpiresAt) || second.Reason != "scanner" {
+		t.Errorf("second ban = %+v, want the first ban extended", second)
+	}
+
+	// a shorter ttl never shortens the ban
+	third, err := m.Ban(ctx, 1, "192.0.2.1", time.Second, "again")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !third.ExpiresAt.Equal(second.ExpiresAt) || third.Reason != "again" {
+		t.Errorf("third ban = %+v, want expiry %v and reason again", third, second.ExpiresAt)
+	}
+
+	// another resource gets its own entry
+	if _, err := m.Ban(ctx, 2, "192.0.2.1", time.Minute, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(f.created, []string{"192.0.2.1", "192.0.2.1"}) {
+		t.Errorf("created = %v, want one entry per resource", f.created)
+	}
+	if bans, _ := m.Bans(); len(bans) != 2 {
+		t.Errorf("got %d bans, want 2", len(bans))
+	}
+
+	if _, err := m.Ban(ctx, 1, "192.0.2.300", time.Minute, ""); err == nil {
+		t.Error("expected error for invalid address")
+	}
+	if _, err := m.Ban(ctx, 1, "192.0.2.2", 0, ""); err == nil {
+		t.Error("expected error for zero ttl")
+	}
+}
+
+func TestBanManagerBanFailures(t *testing.T) {
+	ctx := context.Background()
+
+	f := &fakeBlacklists{failAt: 1}
+	m := NewBanManager(f, NewMemoryBanStore())
+	if _, err := m.Ban(ctx, 1, "192.0.2.1", time.Minute, ""); err == nil {
+		t.Fatal("expected create error")
+	}
+	if bans, _ := m.Bans(); len(bans) != 0 {
+		t.Errorf("got %d bans after failed create, want 0", len(bans))
+	}
+
+	// an entry without a record would never expire, so it is deleted again
+	f = &fakeBlacklists{}
+	m = NewBanManager(f, failingPutStore{NewMemoryBanStore()})
+	if _, err := m.Ban(ctx, 1, "192.0.2.1", time.Minute, ""); err == nil {
+		t.Fatal("expected store error")
+	}
+	if !slices.Equal(f.deleted, []int64{1}) {
+		t.Errorf("deleted = %v, want [1]", f.deleted)
+	}
+}
+
+func TestBanManagerBanSharedFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bans.json")
+	f := &fakeBlacklists{}
+
+	// managers sharing only the file, like separate processes, ban the same address at once
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m := NewBanManager(f, NewJSONFileBanStore(path))
+			if _, err := m.Ban(context.Background(), 1, "192.0.2.1", time.Minute, ""); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(f.created) != 1 {
+		t.Errorf("created %d blacklist entries, want 1", len(f.created))
+	}
+}
+
+func TestBanManagerUnban(t *testing.T) {
+	f := &fakeBlacklists{}
+	m := NewBanManager(f, NewMemoryBanStore())
+	ctx := context.Background()
+
+	if _, err := m.Ban(ctx, 1, "2001:db8::/64", time.Minute, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Unban(ctx, 1, "2001:db8:0:0::/64"); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(f.deleted, []int64{1}) {
+		t.Errorf("deleted = %v, want [1]", f.deleted)
+	}
+	if bans, _ := m.Bans(); len(bans) != 0 {
+		t.Errorf("got %d bans after Unban, want 0", len(bans))
+	}
+
+	// unknown bans are ignored
+	if err := m.Unban(ctx, 1, "192.0.2.1"); err != nil {
+		t.Errorf("Unban of unknown address = %v", err)
+	}
+	if len(f.deleted) != 1 {
+		t.Errorf("deleted = %v, want no more deletes", f.deleted)
+	}
+}
+
+func TestBanManagerSweep(t *testing.T) {
+	f := &fakeBlacklists{deleteStatus: http.StatusNotFound}
+	store := NewMemoryBanStore()
+	m := NewBanManager(f, store)
+
+	now := time.Now()
+	for i, expires := range []time.Time{now.Add(-time.Minute), now.Add(time.Hour), now.Add(-time.Second)} {
+		_ = store.Put(BanRecord{ResourceID: 1, BlacklistID: int64(i + 1), IP: fmt.Sprintf("192.0.2.%d", i+1), ExpiresAt: expires})
+	}
+
+	// entries already deleted by someone else count as removed
+	removed, err := m.Sweep(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 2 || !slices.Equal(f.deleted, []int64{1, 3}) {
+		t.Errorf("removed %d, deleted %v, want 2 and [1 3]", removed, f.deleted)
+	}
+	if bans, _ := m.Bans(); len(bans) != 1 || bans[0].BlacklistID != 2 {
+		t.Errorf("bans = %+v, want only the unexpired ban", bans)
+	}
+
+	// other errors keep the record for the next sweep
+	f.deleteStatus = http.StatusInternalServerError
+	_ = store.Put(BanRecord{ResourceID: 1, BlacklistID: 4, IP: "192.0.2.4", ExpiresAt: now.Add(-time.Minute)})
+	if removed, err := m.Sweep(context.Background()); err == nil || removed != 0 {
+		t.Errorf("Sweep() = %d, %v, want 0 and an error", removed, err)
+	}
+	if bans, _ := m.Bans(); len(bans) != 2 {
+		t.Errorf("got %d bans, want the failed ban kept", len(bans))
+	}
+}
+
+func TestBanManagerRun(t *testing.T) {
+	f := &fakeBlacklists{deleteStatus: http.StatusInternalServerError}
+	store := NewMemoryBanStore()
+	_ = store.Put(BanRecord{ResourceID: 1, BlacklistID: 1, IP: "192.0.2.1", ExpiresAt: time.Now().Add(-time.Minute)})
+
+	m := NewBanManager(f, store)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 10)
+	m.OnError = func(err error) {
+		errs <- err
+		// two sweeps show that Run sweeps again after the interval
+		if len(errs) == 2 {
+			cancel()
+		}
+	}
+
+	if err := m.Run(ctx, time.Millisecond); !errors.Is(err, context.Canceled) {
+		t.Errorf("Run() = %v, want context.Canceled", err)
+	}
+	if len(errs) < 2 {
+		t.Errorf("got %d sweep errors, want at least 2", len(errs))
+	}
+
+	if err := m.Run(context.Background(), 0); err == nil {
+		t.Error("expected error for zero interval")
+	}
+}
@@ -95,6 +95,12 @@ func (s *BlacklistsServiceOp) Create(ctx context.Context, resourceID int64, reqB
 		return nil, nil, NewArgError("reqBody", "cannot be nil")
 	}
 
+	if s.client.listConflictCheck {
+		if err := checkBlacklistConflict(ctx, s.client, resourceID, reqBody.IP); err != nil {
+			return nil, nil, err
+		}
+	}
+
 	req, err := s.client.NewRequest(ctx, http.MethodPost, path, reqBody)
 	if err != nil {
 		return nil, nil, err
@@ -128,6 +134,12 @@ func (s *BlacklistsServiceOp) Update(ctx context.Context, resourceID int64, blac
 		return nil, nil, NewArgError("reqBody", "cannot be nil")
 	}
 
+	if s.client.listConflictCheck {
+		if err := checkBlacklistConflict(ctx, s.client, resourceID, reqBody.IP); err != nil {
+			return nil, nil, err
+		}
+	}
+
 	path := fmt.Sprintf("%s/%d/%s/%d", resourcesBasePathV2, resourceID, blacklistsPathV2, blacklistID)
 
 	req, err := s.client.NewRequest(ctx, http.MethodPatch, path, reqBody)
@@ -143,3 +155,30 @@ func (s *BlacklistsServiceOp) Update(ctx context.Context, resourceID int64, blac
 
 	return blacklist, resp, err
 }
+
+// CreateAggregatedBlacklists adds blacklists for DDoS resource through s, merging adjacent and overlapping addresses into the minimal set of prefixes.
+// The aggregation result reports the extra address space covered when opts.MaxEntries is set.
+func CreateAggregatedBlacklists(ctx context.Context, s BlacklistsService, resourceID int64, ips []string, opts *AggregateOptions) ([]Blacklist, *AggregateResult, *Response, error) {
+	var blacklists []Blacklist
+
+	result, resp, err := createAggregated(ips, opts, func(ip string) (*Response, error) {
+		blacklist, resp, err := s.Create(ctx, resourceID, &BlacklistCreateRequest{IP: ip})
+		if err != nil {
+			return resp, err
+		}
+
+		blacklists = append(blacklists, *blacklist)
+
+		return resp, nil
+	})
+
+	return blacklists, result, resp, err
+}
+
+// listAllBlacklists returns the blacklists of DDoS resource from every page
+func listAllBlacklists(ctx context.Context, s BlacklistsService, resourceID int64) ([]Blacklist, error) {
+	return listAll(ctx, func(ctx context.Context, limit, offset int) ([]Blacklist, error) {
+		blacklists, _, err := s.List(ctx, resourceID, &BlacklistListOptions{Limit: limit, Offset: offset})
+		return blacklists, err
+	})
+}
@@ -0,0 +1,413 @@
+package edgecenterprotection_go
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"strings"
+)
+
+// BlocklistFormat identifies the format of a threat-intelligence blocklist
+type BlocklistFormat int
+
+const (
+	// BlocklistPlainText is one address, prefix or range per line. Text after '#' or ';' is a comment.
+	BlocklistPlainText BlocklistFormat = iota
+
+	// BlocklistCSV is comma-separated values with the address in a configurable column.
+	BlocklistCSV
+
+	// BlocklistSpamhausDROP is the Spamhaus DROP/EDROP format: "prefix ; SBL id" lines.
+	BlocklistSpamhausDROP
+
+	// BlocklistIPSet is the output of "ipset save".
+	BlocklistIPSet
+)
+
+// String returns the name of the blocklist format
+func (f BlocklistFormat) String() string {
+	switch f {
+	case BlocklistPlainText:
+		return "text"
+	case BlocklistCSV:
+		return "csv"
+	case BlocklistSpamhausDROP:
+		return "drop"
+	case BlocklistIPSet:
+		return "ipset"
+	default:
+		return fmt.Sprintf("BlocklistFormat(%d)", int(f))
+	}
+}
+
+// BlocklistEntry represents a single prefix read from a blocklist
+type BlocklistEntry struct {
+	Prefix  netip.Prefix
+	Comment string
+	Source  string
+	Line    int
+}
+
+// BlocklistParseOptions specifies the parameters of ParseBlocklist
+type BlocklistParseOptions struct {
+	Format BlocklistFormat
+
+	// Source is recorded in every entry and in error messages, e.g. a file name or feed URL
+	Source string
+
+	// CSVColumn is the one-based column holding the address, the first column by default
+	CSVColumn int
+
+	// CSVCommentColumn is the one-based column holding the comment, zero for none
+	CSVCommentColumn int
+
+	// CSVComma is the field delimiter, ',' by default
+	CSVComma rune
+
+	// CSVSkipHeader skips the first record
+	CSVSkipHeader bool
+
+	// IPSetName limits ipset entries to the named set, all sets are read by default
+	IPSetName string
+
+	// SkipInvalid collects invalid lines in BlocklistParseResult.Invalid instead of failing
+	SkipInvalid bool
+}
+
+// BlocklistParseResult represents the entries parsed from a blocklist
+type BlocklistParseResult struct {
+	Entries []BlocklistEntry
+
+	// Invalid holds errors for lines skipped with BlocklistParseOptions.SkipInvalid
+	Invalid []error
+}
+
+// BlocklistImportOptions specifies the optional parameters to ImportBlocklist
+type BlocklistImportOptions struct {
+	// MaxEntries limits the number of created blacklist entries, see AggregateOptions
+	MaxEntries int
+
+	// DryRun computes the result without creating blacklist entries
+	DryRun bool
+}
+
+// BlocklistImportResult represents the outcome of ImportBlocklist
+type BlocklistImportResult struct {
+	// Created holds the blacklist entries added to the resource
+	Created []Blacklist
+
+	// Covered holds entries already covered by existing blacklist entries of the resource
+	Covered []BlocklistEntry
+
+	// Aggregate describes the prefixes that were created for the remaining entries
+	Aggregate *AggregateResult
+}
+
+// ParseBlocklist reads a blocklist in the given format and returns validated prefixes with their comments
+func ParseBlocklist(r io.Reader, opts BlocklistParseOptions) (*BlocklistParseResult, error) {
+	result := &BlocklistParseResult{}
+
+	invalid := func(err error) error {
+		if opts.SkipInvalid {
+			result.Invalid = append(result.Invalid, err)
+			return nil
+		}
+
+		return err
+	}
+
+	add := func(line int, addr, comment string) error {
+		ipr, err := parseIPRange(addr)
+		if err != nil {
+			var argErr *ArgError
+			if errors.As(err, &argErr) {
+				err = NewArgError(fmt.Sprintf("%s:%d: %q", blocklistSource(opts.Source), line, addr), argErr.reason)
+			}
+
+			return invalid(err)
+		}
+
+		for _, p := range rangesToPrefixes([]ipRange{ipr}) {
+			result.Entries = append(result.Entries, BlocklistEntry{
+				Prefix:  p,
+				Comment: comment,
+				Source:  opts.Source,
+				Line:    line,
+			})
+		}
+
+		return nil
+	}
+
+	var err error
+	switch opts.Format {
+	case BlocklistPlainText:
+		err = parseBlocklistLines(r, parsePlainTextLine, add)
+	case BlocklistSpamhausDROP:
+		err = parseBlocklistLines(r, parseDROPLine, add)
+	case BlocklistIPSet:
+		err = parseBlocklistLines(r, func(line string) (string, string, bool) {
+			return parseIPSetLine(line, opts.IPSetName)
+		}, add)
+	case BlocklistCSV:
+		err = parseBlocklistCSV(r, opts, add, invalid)
+	default:
+		return nil, NewArgError("Format", fmt.Sprintf("unsupported blocklist format %s", opts.Format))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ImportBlocklist adds blocklist entries to the blacklist of DDoS resource. Entries already covered by the
+// existing blacklist are skipped, the rest is aggregated into the minimal set of prefixes.
+func ImportBlocklist(ctx context.Context, c *Client, resourceID int64, entries []BlocklistEntry, opts *BlocklistImportOptions) (*BlocklistImportResult, error) {
+	if opts == nil {
+		opts = &BlocklistImportOptions{}
+	}
+
+	existing, err := listAllBlacklists(ctx, c.Blacklists, resourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	existingRanges := make([]ipRange, 0, len(existing))
+	for _, b := range existing {
+		ipr, err := parseIPRange(b.IP)
+		if err != nil {
+			continue
+		}
+		existingRanges = append(existingRanges, ipr)
+	}
+	existingRanges = mergeRanges(existingRanges)
+
+	result := &BlocklistImportResult{}
+
+	var pending []netip.Prefix
+	for _, e := range entries {
+		if len(subtractRanges(prefixesToRanges([]netip.Prefix{e.Prefix}), existingRanges)) == 0 {
+			result.Covered = append(result.Covered, e)
+			continue
+		}
+		pending = append(pending, e.Prefix)
+	}
+
+	result.Aggregate, err = AggregatePrefixesMax(pending, opts.MaxEntries)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.DryRun {
+		return result, nil
+	}
+
+	for _, p := range result.Aggregate.Prefixes {
+		blacklist, _, err := c.Blacklists.Create(ctx, resourceID, &BlacklistCreateRequest{IP: FormatIPPrefix(p)})
+		if err != nil {
+			return result, err
+		}
+		result.Created = append(result.Created, *blacklist)
+	}
+
+	return result, nil
+}
+
+// blocklistSource returns the source name used in error messages
+func blocklistSource(source string) string {
+	if source == "" {
+		return "blocklist"
+	}
+
+	return source
+}
+
+// parseBlocklistLines reads r line by line and passes the address and comment of every entry to add
+func parseBlocklistLines(r io.Reader, parse func(string) (string, string, bool), add func(int, string, string) error) error {
+	scanner := bufio.NewScanner(r)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+
+		addr, comment, ok := parse(scanner.Text())
+		if !ok {
+			continue
+		}
+
+		if err := add(line, addr, comment); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+// parsePlainTextLine parses "address [comment]" lines with optional '#' or ';' comments
+func parsePlainTextLine(line string) (string, string, bool) {
+	var comment string
+	if i := strings.IndexAny(line, "#;"); i >= 0 {
+		line, comment = line[:i], strings.TrimSpace(line[i+1:])
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return "", "", false
+	}
+
+	if len(fields) > 1 && comment == "" {
+		comment = strings.Join(fields[1:], " ")
+	}
+
+	return fields[0], comment, true
+}
+
+// parseDROPLine parses "prefix ; SBL id" lines, lines starting with ';' are comments
+func parseDROPLine(line string) (string, string, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, ";") {
+		return "", "", false
+	}
+
+	addr, comment, _ := strings.Cut(line, ";")
+
+	return strings.TrimSpace(addr), strings.TrimSpace(comment), true
+}
+
+// parseIPSetLine parses "add <set> <entry> [options]" lines of ipset save output
+func parseIPSetLine(line, setName string) (string, string, bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 || fields[0] != "add" {
+		return "", "", false
+	}
+
+	if setName != "" && fields[1] != setName {
+		return "", "", false
+	}
+
+	// options follow the entry, the comment is the only quoted value
+	var comment string
+	options := ipsetFields(strings.Join(fields[3:], " "))
+	for i := 0; i+1 < len(options); i++ {
+		if options[i] == "comment" {
+			comment = options[i+1]
+			i++
+		}
+	}
+
+	// entries of hash:net,port and similar types carry extra dimensions after a comma
+	addr, _, _ := strings.Cut(fields[2], ",")
+
+	return addr, comment, true
+}
+
+// ipsetFields splits ipset options at whitespace, keeping double quoted values with backslash escapes together
+func ipsetFields(s string) []string {
+	var fields []string
+	var field strings.Builder
+	inField, quoted := false, false
+
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case quoted && ch == '\\' && i+1 < len(s):
+			i++
+			field.WriteByte(s[i])
+		case ch == '"':
+			quoted, inField = !quoted, true
+		case !quoted && (ch == ' ' || ch == '\t'):
+			if inField {
+				fields = append(fields, field.String())
+				field.Reset()
+				inField = false
+			}
+		default:
+			field.WriteByte(ch)
+			inField = true
+		}
+	}
+
+	if inField {
+		fields = append(fields, field.String())
+	}
+
+	return fields
+}
+
+// parseBlocklistCSV reads CSV records and passes the configured columns to add and malformed records to invalid
+func parseBlocklistCSV(r io.Reader, opts BlocklistParseOptions, add func(int, string, string) error, invalid func(error) error) error {
+	column := opts.CSVColumn
+	if column == 0 {
+		column = 1
+	}
+
+	if column < 0 {
+		return NewArgError("CSVColumn", "cannot be negative")
+	}
+
+	if opts.CSVCommentColumn < 0 {
+		return NewArgError("CSVCommentColumn", "cannot be negative")
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.Comment = '#'
+	reader.TrimLeadingSpace = true
+
+	// LazyQuotes is left off: a stray quote would swallow the following records, while a parse error
+	// affects only its record and can be skipped with SkipInvalid
+	if opts.CSVComma != 0 {
+		reader.Comma = opts.CSVComma
+	}
+
+	first := true
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+
+		// the reader continues with the next record after a parse error
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			err = NewArgError(fmt.Sprintf("%s:%d", blocklistSource(opts.Source), parseErr.StartLine), parseErr.Err.Error())
+			if err := invalid(err); err != nil {
+				return err
+			}
+			first = false
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if first && opts.CSVSkipHeader {
+			first = false
+			continue
+		}
+		first = false
+
+		line, _ := reader.FieldPos(0)
+
+		if len(record) < column {
+			if err := add(line, "", ""); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var comment string
+		if opts.CSVCommentColumn > 0 && len(record) >= opts.CSVCommentColumn {
+			comment = strings.TrimSpace(record[opts.CSVCommentColumn-1])
+		}
+
+		if err := add(line, record[column-1], comment); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package edgecenterprotection_go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseBlocklistIPSetComments(t *testing.T) {
+	input := `create drop hash:net family inet hashsize 1024 maxelem 65536 comment
+add drop 192.0.2.0/24 comment "scanner"
+add drop 198.51.100.7 timeout 300 comment "seen with comment spam"
+add drop 203.0.113.0/25 comment "quoted \"comment\" value" skbmark 0x1
+add other 10.0.0.1 comment "other set"
+add drop 2001:db8::/32
+`
+
+	res, err := ParseBlocklist(strings.NewReader(input), BlocklistParseOptions{Format: BlocklistIPSet, IPSetName: "drop"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct{ prefix, comment string }{
+		{"192.0.2.0/24", "scanner"},
+		{"198.51.100.7/32", "seen with comment spam"},
+		{"203.0.113.0/25", `quoted "comment" value`},
+		{"2001:db8::/32", ""},
+	}
+	if len(res.Entries) != len(want) {
+		t.Fatalf("got %d entries, want %d: %+v", len(res.Entries), len(want), res.Entries)
+	}
+	for i, w := range want {
+		if e := res.Entries[i]; e.Prefix.String() != w.prefix || e.Comment != w.comment {
+			t.Errorf("entry %d = %s %q, want %s %q", i, e.Prefix, e.Comment, w.prefix, w.comment)
+		}
+	}
+}
+
+func TestParseBlocklistCSVSkipInvalid(t *testing.T) {
+	input := `ip,reason
+192.0.2.1,botnet
+"198.51.100.1"x,broken quote
+not-an-ip,typo
+203.0.113.5,spam
+`
+	opts := BlocklistParseOptions{Format: BlocklistCSV, CSVSkipHeader: true, CSVCommentColumn: 2, Source: "feed.csv"}
+
+	if _, err := ParseBlocklist(strings.NewReader(input), opts); err == nil {
+		t.Fatal("want error without SkipInvalid")
+	}
+
+	opts.SkipInvalid = true
+	res, err := ParseBlocklist(strings.NewReader(input), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res.Entries) != 2 || res.Entries[0].Comment != "botnet" || res.Entries[1].Prefix.String() != "203.0.113.5/32" {
+		t.Errorf("entries = %+v", res.Entries)
+	}
+	if len(res.Invalid) != 2 {
+		t.Fatalf("invalid = %v, want 2 errors", res.Invalid)
+	}
+	if msg := res.Invalid[0].Error(); !strings.HasPrefix(msg, "feed.csv:3") {
+		t.Errorf("invalid[0] = %q, want it to start with feed.csv:3", msg)
+	}
+}
+
+func TestParseBlocklistPlainText(t *testing.T) {
+	input := `# feed header
+192.0.2.1
+198.51.100.0/24 # scanners
+203.0.113.0-203.0.113.5 ; range entry
+2001:db8::/48 trailing words
+
+   ; indented comment
+`
+
+	res, err := ParseBlocklist(strings.NewReader(input), BlocklistParseOptions{Source: "feed.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		prefix, comment string
+		line            int
+	}{
+		{"192.0.2.1/32", "", 2},
+		{"198.51.100.0/24", "scanners", 3},
+		{"203.0.113.0/30", "range entry", 4},
+		{"203.0.113.4/31", "range entry", 4},
+		{"2001:db8::/48", "trailing words", 5},
+	}
+	if len(res.Entries) != len(want) {
+		t.Fatalf("got %d entries, want %d: %+v", len(res.Entries), len(want), res.Entries)
+	}
+	for i, w := range want {
+		e := res.Entries[i]
+		if e.Prefix.String() != w.prefix || e.Comment != w.comment || e.Line != w.line || e.Source != "feed.txt" {
+			t.Errorf("entry %d = %s %q line %d source %q, want %s %q line %d", i, e.Prefix, e.Comment, e.Line, e.Source, w.prefix, w.comment, w.line)
+		}
+	}
+
+	_, err = ParseBlocklist(strings.NewReader("192.0.2.1\n192.0.2.300\n"), BlocklistParseOptions{Source: "feed.txt"})
+	var argErr *ArgError
+	if !errors.As(err, &argErr) || !strings.HasPrefix(argErr.arg, "feed.txt:2") {
+		t.Errorf("err = %v, want ArgError for feed.txt:2", err)
+	}
+}
+
+func TestParseBlocklistDROP(t *testing.T) {
+	input := `; Spamhaus DROP List 2024/01/01 - (c) 2024 The Spamhaus Project
+; Last-Modified: Mon, 01 Jan 2024 00:00:00 GMT
+1.10.16.0/20 ; SBL256894
+2.56.192.0/22;SBL459831
+
+  103.2.44.0/22 ; SBL586413
+`
+
+	res, err := ParseBlocklist(strings.NewReader(input), BlocklistParseOptions{Format: BlocklistSpamhausDROP})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct{ prefix, comment string }{
+		{"1.10.16.0/20", "SBL256894"},
+		{"2.56.192.0/22", "SBL459831"},
+		{"103.2.44.0/22", "SBL586413"},
+	}
+	if len(res.Entries) != len(want) {
+		t.Fatalf("got %d entries, want %d: %+v", len(res.Entries), len(want), res.Entries)
+	}
+	for i, w := range want {
+		if e := res.Entries[i]; e.Prefix.String() != w.prefix || e.Comment != w.comment {
+			t.Errorf("entry %d = %s %q, want %s %q", i, e.Prefix, e.Comment, w.prefix, w.comment)
+		}
+	}
+}
+
+// pageOf returns the page of items selected by limit and offset
+func pageOf[T any](items []T, limit, offset int) []T {
+	lo, hi := min(offset, len(items)), min(offset+limit, len(items))
+	return items[lo:hi]
+}
+
+// List returns the existing blacklist entries in pages
+func (f *fakeBlacklists) List(_ context.Context, _ int64, opts *BlacklistListOptions) ([]Blacklist, *Response, error) {
+	f.listCalls++
+	return pageOf(f.existing, opts.Limit, opts.Offset), nil, nil
+}
+
+func TestImportBlocklistAllPages(t *testing.T) {
+	f := &fakeBlacklists{}
+	for i := range listPageSize + 1 {
+		f.existing = append(f.existing, Blacklist{ID: int64(i + 1), IP: fmt.Sprintf("10.0.%d.%d", i/256, i%256)})
+	}
+	// the last page holds the only entry covering 192.0.2.0/24
+	f.existing[listPageSize].IP = "192.0.2.0/24"
+
+	entries := []BlocklistEntry{
+		{Prefix: netip.MustParsePrefix("192.0.2.10/32")},
+		{Prefix: netip.MustParsePrefix("198.51.100.0/24")},
+	}
+
+	res, err := ImportBlocklist(context.Background(), &Client{Blacklists: f}, 1, entries, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if f.listCalls != 2 {
+		t.Errorf("List called %d times, want 2", f.listCalls)
+	}
+	if len(res.Covered) != 1 || res.Covered[0].Prefix.String() != "192.0.2.10/32" {
+		t.Errorf("covered = %+v, want 192.0.2.10/32", res.Covered)
+	}
+	if !slices.Equal(f.created, []string{"198.51.100.0/24"}) {
+		t.Errorf("created = %v, want [198.51.100.0/24]", f.created)
+	}
+}
+
+func TestListAll(t *testing.T) {
+	for _, n := range []int{0, 1, listPageSize - 1, listPageSize, 2*listPageSize + 3} {
+		var calls int
+		items, err := listAll(context.Background(), func(_ context.Context, limit, offset int) ([]int, error) {
+			calls++
+			if limit != listPageSize {
+				t.Fatalf("limit = %d, want %d", limit, listPageSize)
+			}
+
+			var page []int
+			for i := offset; i < n && i < offset+limit; i++ {
+				page = append(page, i)
+			}
+			return page, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(items) != n {
+			t.Errorf("n=%d: got %d items", n, len(items))
+		}
+		if want := n/listPageSize + 1; calls != want {
+			t.Errorf("n=%d: %d calls, want %d", n, calls, want)
+		}
+	}
+
+	// an API ignoring the limit returns everything at once
+	var calls int
+	items, _ := listAll(context.Background(), func(context.Context, int, int) ([]int, error) {
+		calls++
+		return make([]int, listPageSize+5), nil
+	})
+	if calls != 1 || len(items) != listPageSize+5 {
+		t.Errorf("got %d items in %d calls, want %d in 1", len(items), calls, listPageSize+5)
+	}
+
+	if _, err := listAll(context.Background(), func(context.Context, int, int) ([]int, error) {
+		return nil, errors.New("boom")
+	}); err == nil {
+		t.Error("expected list error")
+	}
+}
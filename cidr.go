@@ -0,0 +1,490 @@
+package edgecenterprotection_go
+
+import (
+	"container/heap"
+	"math/big"
+	"net/netip"
+	"sort"
+	"strings"
+)
+
+// AggregateOptions specifies the optional parameters to CreateAggregatedBlacklists and CreateAggregatedWhitelists
+type AggregateOptions struct {
+	// MaxEntries limits the number of resulting prefixes. When the exact aggregation does not fit,
+	// neighbouring prefixes are replaced by their common supernet, covering extra address space.
+	// Zero means exact aggregation without limit.
+	MaxEntries int
+}
+
+// AggregateResult represents the outcome of a prefix aggregation
+type AggregateResult struct {
+	// Prefixes is the covering set of prefixes, IPv4 first, sorted by address
+	Prefixes []netip.Prefix
+
+	// Extra lists the address space covered by Prefixes that was not present in the input.
+	// It is always empty for exact aggregation.
+	Extra []netip.Prefix
+}
+
+// ExtraAddresses returns the number of addresses covered by Prefixes that were not present in the input.
+func (r *AggregateResult) ExtraAddresses() *big.Int {
+	total := new(big.Int)
+	for _, p := range r.Extra {
+		total.Add(total, prefixSize(p))
+	}
+
+	return total
+}
+
+// ipRange represents an inclusive range of addresses of the same family
+type ipRange struct {
+	from netip.Addr
+	to   netip.Addr
+}
+
+// ParseIPPrefix parses a single address or a CIDR prefix as used by blacklist and whitelist entries.
+// A single address is returned as a host prefix, host bits of a CIDR prefix are cleared.
+func ParseIPPrefix(s string) (netip.Prefix, error) {
+	s = strings.TrimSpace(s)
+
+	if strings.Contains(s, "/") {
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, NewArgError("IP", err.Error())
+		}
+
+		addr := p.Addr()
+		if addr.Zone() != "" {
+			return netip.Prefix{}, NewArgError("IP", "zoned addresses are not supported")
+		}
+
+		bits := p.Bits()
+		if addr.Is4In6() && bits >= 96 {
+			addr, bits = addr.Unmap(), bits-96
+		}
+
+		return netip.PrefixFrom(addr, bits).Masked(), nil
+	}
+
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, NewArgError("IP", err.Error())
+	}
+
+	if addr.Zone() != "" {
+		return netip.Prefix{}, NewArgError("IP", "zoned addresses are not supported")
+	}
+
+	addr = addr.Unmap()
+
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// FormatIPPrefix formats a prefix for blacklist and whitelist entries. Host prefixes are formatted as a bare address.
+func FormatIPPrefix(p netip.Prefix) string {
+	if p.IsSingleIP() {
+		return p.Addr().String()
+	}
+
+	return p.String()
+}
+
+// AggregatePrefixes merges adjacent and overlapping prefixes into the minimal set of prefixes
+// covering exactly the same address space. IPv4 and IPv6 prefixes are aggregated separately.
+func AggregatePrefixes(prefixes []netip.Prefix) []netip.Prefix {
+	return rangesToPrefixes(prefixesToRanges(prefixes))
+}
+
+// AggregatePrefixesMax aggregates prefixes like AggregatePrefixes and, while the result has more than
+// maxEntries prefixes, replaces the pair of neighbouring prefixes with the smallest common supernet by
+// that supernet. The address space covered in addition to the input is reported in AggregateResult.Extra.
+func AggregatePrefixesMax(prefixes []netip.Prefix, maxEntries int) (*AggregateResult, error) {
+	if maxEntries < 0 {
+		return nil, NewArgError("maxEntries", "cannot be negative")
+	}
+
+	input := prefixesToRanges(prefixes)
+	result := rangesToPrefixes(input)
+
+	if maxEntries > 0 && len(result) > maxEntries {
+		var err error
+		if result, err = mergePrefixes(result, maxEntries); err != nil {
+			return nil, err
+		}
+	}
+
+	return &AggregateResult{
+		Prefixes: result,
+		Extra:    rangesToPrefixes(subtractRanges(prefixesToRanges(result), input)),
+	}, nil
+}
+
+// prefixNode is an entry of the list of prefixes merged by mergePrefixes
+type prefixNode struct {
+	prefix     netip.Prefix
+	prev, next *prefixNode
+
+	// version changes whenever the prefix changes, invalidating queued merges
+	version int
+	removed bool
+}
+
+// prefixMerge is a queued merge of a node with its next neighbour into their common supernet of bits length
+type prefixMerge struct {
+	left, right  *prefixNode
+	leftVersion  int
+	rightVersion int
+	bits         int
+	leftAddr     netip.Addr
+}
+
+// prefixMergeHeap orders merges by the smallest supernet first, then by address
+type prefixMergeHeap []prefixMerge
+
+func (h prefixMergeHeap) Len() int {
+	return len(h)
+}
+
+func (h prefixMergeHeap) Less(i, j int) bool {
+	if h[i].bits != h[j].bits {
+		return h[i].bits > h[j].bits
+	}
+
+	return h[i].leftAddr.Less(h[j].leftAddr)
+}
+
+func (h prefixMergeHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+}
+
+func (h *prefixMergeHeap) Push(x any) {
+	*h = append(*h, x.(prefixMerge))
+}
+
+func (h *prefixMergeHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+
+	return x
+}
+
+// pushMerge queues the merge of the node with its next neighbour if both are of the same family
+func (h *prefixMergeHeap) pushMerge(n *prefixNode) {
+	if n == nil || n.next == nil {
+		return
+	}
+
+	a, b := n.prefix, n.next.prefix
+	if a.Addr().Is4() != b.Addr().Is4() {
+		return
+	}
+
+	heap.Push(h, prefixMerge{
+		left:         n,
+		right:        n.next,
+		leftVersion:  n.version,
+		rightVersion: n.next.version,
+		bits:         commonPrefixBits(a.Addr(), b.Addr(), min(a.Bits(), b.Bits())),
+		leftAddr:     a.Addr(),
+	})
+}
+
+// valid reports whether both nodes are unchanged and still neighbours since the merge was queued
+func (m prefixMerge) valid() bool {
+	return !m.left.removed && !m.right.removed && m.left.next == m.right &&
+		m.left.version == m.leftVersion && m.right.version == m.rightVersion
+}
+
+// mergePrefixes replaces pairs of neighbouring prefixes by their common supernet, smallest supernet first,
+// until at most maxEntries prefixes remain. The prefixes must be aggregated and sorted.
+func mergePrefixes(prefixes []netip.Prefix, maxEntries int) ([]netip.Prefix, error) {
+	nodes := make([]prefixNode, len(prefixes))
+	for i := range nodes {
+		nodes[i].prefix = prefixes[i]
+		if i > 0 {
+			nodes[i].prev = &nodes[i-1]
+		}
+		if i+1 < len(nodes) {
+			nodes[i].next = &nodes[i+1]
+		}
+	}
+
+	h := make(prefixMergeHeap, 0, len(nodes))
+	for i := range nodes {
+		h.pushMerge(&nodes[i])
+	}
+
+	count := len(nodes)
+	for count > maxEntries {
+		if h.Len() == 0 {
+			return nil, NewArgError("maxEntries", "cannot be less than the number of address families in the input")
+		}
+
+		m := heap.Pop(&h).(prefixMerge)
+		if !m.valid() {
+			continue
+		}
+
+		n := m.left
+		n.prefix = netip.PrefixFrom(n.prefix.Addr(), m.bits).Masked()
+		count -= n.absorbNeighbours()
+		n.version++
+
+		h.pushMerge(n.prev)
+		h.pushMerge(n)
+	}
+
+	result := make([]netip.Prefix, 0, count)
+	for i := range nodes {
+		if !nodes[i].removed {
+			result = append(result, nodes[i].prefix)
+		}
+	}
+
+	return result, nil
+}
+
+// absorbNeighbours removes the neighbours covered by the prefix of the node and merges it with sibling
+// neighbours, keeping the list aggregated. It returns the number of removed nodes.
+func (n *prefixNode) absorbNeighbours() int {
+	removed := 0
+	for {
+		switch {
+		case n.next != nil && n.prefix.Contains(n.next.prefix.Addr()):
+			n.next.remove()
+		case n.prev != nil && n.prefix.Contains(n.prev.prefix.Addr()):
+			n.prev.remove()
+		case n.next != nil && isSiblingPrefix(n.prefix, n.next.prefix):
+			n.next.remove()
+			n.prefix = netip.PrefixFrom(n.prefix.Addr(), n.prefix.Bits()-1).Masked()
+		case n.prev != nil && isSiblingPrefix(n.prev.prefix, n.prefix):
+			n.prev.remove()
+			n.prefix = netip.PrefixFrom(n.prefix.Addr(), n.prefix.Bits()-1).Masked()
+		default:
+			return removed
+		}
+		removed++
+	}
+}
+
+// remove unlinks the node from the list
+func (n *prefixNode) remove() {
+	if n.prev != nil {
+		n.prev.next = n.next
+	}
+	if n.next != nil {
+		n.next.prev = n.prev
+	}
+
+	n.removed = true
+}
+
+// isSiblingPrefix reports whether a and b are the two halves of the same prefix
+func isSiblingPrefix(a, b netip.Prefix) bool {
+	if a == b || a.Bits() != b.Bits() || a.Bits() == 0 || a.Addr().Is4() != b.Addr().Is4() {
+		return false
+	}
+
+	return netip.PrefixFrom(a.Addr(), a.Bits()-1).Masked() == netip.PrefixFrom(b.Addr(), b.Bits()-1).Masked()
+}
+
+// createAggregated parses and aggregates ips and passes every resulting entry to create
+func createAggregated(ips []string, opts *AggregateOptions, create func(ip string) (*Response, error)) (*AggregateResult, *Response, error) {
+	prefixes := make([]netip.Prefix, 0, len(ips))
+	for _, ip := range ips {
+		p, err := ParseIPPrefix(ip)
+		if err != nil {
+			return nil, nil, err
+		}
+		prefixes = append(prefixes, p)
+	}
+
+	maxEntries := 0
+	if opts != nil {
+		maxEntries = opts.MaxEntries
+	}
+
+	result, err := AggregatePrefixesMax(prefixes, maxEntries)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var resp *Response
+	for _, p := range result.Prefixes {
+		resp, err = create(FormatIPPrefix(p))
+		if err != nil {
+			return result, resp, err
+		}
+	}
+
+	return result, resp, nil
+}
+
+// prefixesToRanges converts prefixes into sorted, merged address ranges
+func prefixesToRanges(prefixes []netip.Prefix) []ipRange {
+	ranges := make([]ipRange, 0, len(prefixes))
+	for _, p := range prefixes {
+		if !p.IsValid() {
+			continue
+		}
+
+		p = p.Masked()
+		ranges = append(ranges, ipRange{from: p.Addr(), to: lastAddr(p)})
+	}
+
+	return mergeRanges(ranges)
+}
+
+// mergeRanges sorts ranges and merges overlapping and adjacent ones
+func mergeRanges(ranges []ipRange) []ipRange {
+	sort.Slice(ranges, func(i, j int) bool {
+		if c := ranges[i].from.Compare(ranges[j].from); c != 0 {
+			return c < 0
+		}
+		return ranges[i].to.Compare(ranges[j].to) < 0
+	})
+
+	merged := make([]ipRange, 0, len(ranges))
+	for _, r := range ranges {
+		if n := len(merged); n > 0 {
+			last := &merged[n-1]
+			if last.from.Is4() == r.from.Is4() {
+				next := last.to.Next()
+				if !next.IsValid() || r.from.Compare(next) <= 0 {
+					if r.to.Compare(last.to) > 0 {
+						last.to = r.to
+					}
+					continue
+				}
+			}
+		}
+		merged = append(merged, r)
+	}
+
+	return merged
+}
+
+// subtractRanges returns the parts of a not covered by b. Both inputs must be merged.
+func subtractRanges(a, b []ipRange) []ipRange {
+	var out []ipRange
+	for _, r := range a {
+		cur := r
+		covered := false
+		for _, s := range b {
+			if s.from.Is4() != cur.from.Is4() || s.to.Less(cur.from) || cur.to.Less(s.from) {
+				continue
+			}
+
+			if cur.from.Less(s.from) {
+				out = append(out, ipRange{from: cur.from, to: s.from.Prev()})
+			}
+
+			if s.to.Compare(cur.to) >= 0 {
+				covered = true
+				break
+			}
+			cur.from = s.to.Next()
+		}
+
+		if !covered {
+			out = append(out, cur)
+		}
+	}
+
+	return out
+}
+
+// rangesToPrefixes converts merged ranges into the minimal list of prefixes covering them
+func rangesToPrefixes(ranges []ipRange) []netip.Prefix {
+	var prefixes []netip.Prefix
+	for _, r := range ranges {
+		start := r.from
+		for {
+			bits := start.BitLen()
+			for bits > 0 {
+				p := netip.PrefixFrom(start, bits-1).Masked()
+				if p.Addr() != start || lastAddr(p).Compare(r.to) > 0 {
+					break
+				}
+				bits--
+			}
+
+			p := netip.PrefixFrom(start, bits)
+			prefixes = append(prefixes, p)
+
+			last := lastAddr(p)
+			if last.Compare(r.to) >= 0 {
+				break
+			}
+			start = last.Next()
+		}
+	}
+
+	return prefixes
+}
+
+// lastAddr returns the last address covered by the prefix
+func lastAddr(p netip.Prefix) netip.Addr {
+	b := p.Masked().Addr().AsSlice()
+	for i := p.Bits(); i < len(b)*8; i++ {
+		b[i/8] |= 0x80 >> (i % 8)
+	}
+
+	addr, _ := netip.AddrFromSlice(b)
+
+	return addr
+}
+
+// prefixSize returns the number of addresses covered by the prefix
+func prefixSize(p netip.Prefix) *big.Int {
+	return new(big.Int).Lsh(big.NewInt(1), uint(p.Addr().BitLen()-p.Bits()))
+}
+
+// commonPrefixBits returns the length of the common leading bits of a and b, capped by limit
+func commonPrefixBits(a, b netip.Addr, limit int) int {
+	ab, bb := a.AsSlice(), b.AsSlice()
+	for i := 0; i < limit; i++ {
+		mask := byte(0x80 >> (i % 8))
+		if ab[i/8]&mask != bb[i/8]&mask {
+			return i
+		}
+	}
+
+	return limit
+}
+
+// parseIPRange parses a single address, a CIDR prefix or an inclusive "first-last" address range
+func parseIPRange(s string) (ipRange, error) {
+	s = strings.TrimSpace(s)
+
+	if from, to, found := strings.Cut(s, "-"); found {
+		first, err := netip.ParseAddr(strings.TrimSpace(from))
+		if err != nil {
+			return ipRange{}, NewArgError("IP", err.Error())
+		}
+
+		last, err := netip.ParseAddr(strings.TrimSpace(to))
+		if err != nil {
+			return ipRange{}, NewArgError("IP", err.Error())
+		}
+
+		first, last = first.Unmap(), last.Unmap()
+		if first.Is4() != last.Is4() || first.Zone() != "" || last.Zone() != "" {
+			return ipRange{}, NewArgError("IP", "range bounds must be unzoned addresses of the same family")
+		}
+
+		if last.Less(first) {
+			return ipRange{}, NewArgError("IP", "range end is before range start")
+		}
+
+		return ipRange{from: first, to: last}, nil
+	}
+
+	p, err := ParseIPPrefix(s)
+	if err != nil {
+		return ipRange{}, err
+	}
+
+	return ipRange{from: p.Addr(), to: lastAddr(p)}, nil
+}
@@ -0,0 +1,327 @@
+package edgecenterprotection_go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/netip"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func mustPrefixes(t *testing.T, ss ...string) []netip.Prefix {
+	t.Helper()
+
+	prefixes := make([]netip.Prefix, 0, len(ss))
+	for _, s := range ss {
+		p, err := ParseIPPrefix(s)
+		if err != nil {
+			t.Fatalf("ParseIPPrefix(%q): %v", s, err)
+		}
+		prefixes = append(prefixes, p)
+	}
+
+	return prefixes
+}
+
+func formatPrefixes(prefixes []netip.Prefix) []string {
+	out := make([]string, len(prefixes))
+	for i, p := range prefixes {
+		out[i] = FormatIPPrefix(p)
+	}
+
+	return out
+}
+
+func TestParseIPPrefix(t *testing.T) {
+	tests := []struct {
+		in, want string
+		wantErr  bool
+	}{
+		{in: "10.0.0.1", want: "10.0.0.1/32"},
+		{in: " 10.0.0.1/24 ", want: "10.0.0.0/24"},
+		{in: "::ffff:10.0.0.1", want: "10.0.0.1/32"},
+		{in: "::ffff:10.0.0.0/120", want: "10.0.0.0/24"},
+		{in: "2001:db8::1/32", want: "2001:db8::/32"},
+		{in: "fe80::1%eth0", wantErr: true},
+		{in: "10.0.0.256", wantErr: true},
+		{in: "10.0.0.0/33", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseIPPrefix(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseIPPrefix(%q) = %v, want error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil || got.String() != tt.want {
+			t.Errorf("ParseIPPrefix(%q) = %v, %v, want %s", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestAggregatePrefixes(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []string
+		want []string
+	}{
+		{
+			name: "adjacent IPv4 hosts",
+			in:   []string{"10.0.0.0", "10.0.0.1", "10.0.0.2", "10.0.0.3"},
+			want: []string{"10.0.0.0/30"},
+		},
+		{
+			name: "unaligned IPv4 range",
+			in:   []string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4"},
+			want: []string{"10.0.0.1", "10.0.0.2/31", "10.0.0.4"},
+		},
+		{
+			name: "overlapping and duplicate",
+			in:   []string{"192.168.0.0/24", "192.168.0.128/25", "192.168.0.7", "192.168.1.0/24", "192.168.1.0/24"},
+			want: []string{"192.168.0.0/23"},
+		},
+		{
+			name: "IPv6 halves",
+			in:   []string{"2001:db8::/33", "2001:db8:8000::/33"},
+			want: []string{"2001:db8::/32"},
+		},
+		{
+			name: "families kept apart and IPv4 first",
+			in:   []string{"2001:db8::1", "10.0.0.1", "2001:db8::", "10.0.0.0"},
+			want: []string{"10.0.0.0/31", "2001:db8::/127"},
+		},
+		{
+			name: "mapped addresses merge with IPv4",
+			in:   []string{"::ffff:10.0.0.0", "10.0.0.1"},
+			want: []string{"10.0.0.0/31"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := formatPrefixes(AggregatePrefixes(mustPrefixes(t, tt.in...)))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("AggregatePrefixes(%v) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAggregatePrefixesMax(t *testing.T) {
+	tests := []struct {
+		name       string
+		in         []string
+		maxEntries int
+		want       []string
+		wantExtra  []string
+		wantErr    bool
+	}{
+		{
+			name:       "exact fits",
+			in:         []string{"10.0.0.1", "10.0.0.3"},
+			maxEntries: 2,
+			want:       []string{"10.0.0.1", "10.0.0.3"},
+		},
+		{
+			name:       "closest pair merged first",
+			in:         []string{"10.0.0.1", "10.0.0.3", "10.0.1.0"},
+			maxEntries: 2,
+			want:       []string{"10.0.0.0/30", "10.0.1.0"},
+			wantExtra:  []string{"10.0.0.0", "10.0.0.2"},
+		},
+		{
+			name:       "supernet absorbs neighbours",
+			in:         []string{"10.0.0.0", "10.0.0.5", "10.0.0.9", "10.0.0.14", "10.0.0.16"},
+			maxEntries: 2,
+			want:       []string{"10.0.0.0/28", "10.0.0.16"},
+			wantExtra:  []string{"10.0.0.1", "10.0.0.2/31", "10.0.0.4", "10.0.0.6/31", "10.0.0.8", "10.0.0.10/31", "10.0.0.12/31", "10.0.0.15"},
+		},
+		{
+			name:       "merge rebuilds sibling",
+			in:         []string{"10.0.0.0/31", "10.0.0.2", "10.0.0.4/30"},
+			maxEntries: 1,
+			want:       []string{"10.0.0.0/29"},
+			wantExtra:  []string{"10.0.0.3"},
+		},
+		{
+			name:       "IPv6",
+			in:         []string{"2001:db8::1", "2001:db8::2", "2001:db8:1::"},
+			maxEntries: 2,
+			want:       []string{"2001:db8::/126", "2001:db8:1::"},
+			wantExtra:  []string{"2001:db8::", "2001:db8::3"},
+		},
+		{
+			name:       "families are never merged",
+			in:         []string{"10.0.0.1", "2001:db8::1"},
+			maxEntries: 1,
+			wantErr:    true,
+		},
+		{
+			name:       "negative",
+			in:         []string{"10.0.0.1"},
+			maxEntries: -1,
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := AggregatePrefixesMax(mustPrefixes(t, tt.in...), tt.maxEntries)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("AggregatePrefixesMax() = %v, want error", res.Prefixes)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := formatPrefixes(res.Prefixes); !slices.Equal(got, tt.want) {
+				t.Errorf("Prefixes = %v, want %v", got, tt.want)
+			}
+			if got := formatPrefixes(res.Extra); !slices.Equal(got, tt.wantExtra) {
+				t.Errorf("Extra = %v, want %v", got, tt.wantExtra)
+			}
+		})
+	}
+}
+
+// naiveAggregateMax is the quadratic reference of AggregatePrefixesMax, re-aggregating after every merge
+func naiveAggregateMax(prefixes []netip.Prefix, maxEntries int) []netip.Prefix {
+	result := AggregatePrefixes(prefixes)
+	for len(result) > maxEntries {
+		best, bestBits := -1, -1
+		for i := 0; i+1 < len(result); i++ {
+			a, b := result[i], result[i+1]
+			if a.Addr().Is4() != b.Addr().Is4() {
+				continue
+			}
+			if bits := commonPrefixBits(a.Addr(), b.Addr(), min(a.Bits(), b.Bits())); bits > bestBits {
+				best, bestBits = i, bits
+			}
+		}
+
+		merged := append([]netip.Prefix{}, result[:best]...)
+		merged = append(merged, netip.PrefixFrom(result[best].Addr(), bestBits).Masked())
+		result = AggregatePrefixes(append(merged, result[best+2:]...))
+	}
+
+	return result
+}
+
+func TestAggregatePrefixesMaxMatchesReference(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for round := 0; round < 200; round++ {
+		var prefixes []netip.Prefix
+		for i := rnd.Intn(60) + 2; i > 0; i-- {
+			addr := netip.AddrFrom4([4]byte{10, 0, byte(rnd.Intn(4)), byte(rnd.Intn(256))})
+			prefixes = append(prefixes, netip.PrefixFrom(addr, 24+rnd.Intn(9)).Masked())
+		}
+		maxEntries := rnd.Intn(10) + 1
+
+		res, err := AggregatePrefixesMax(prefixes, maxEntries)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := naiveAggregateMax(prefixes, maxEntries)
+		if !slices.Equal(res.Prefixes, want) {
+			t.Fatalf("round %d: AggregatePrefixesMax(%v, %d) = %v, want %v", round, prefixes, maxEntries, res.Prefixes, want)
+		}
+	}
+}
+
+func TestAggregatePrefixesMaxLargeFeed(t *testing.T) {
+	var prefixes []netip.Prefix
+	for i := 0; i < 50000; i++ {
+		addr := netip.AddrFrom4([4]byte{byte(i >> 16), byte(i >> 8), byte(i), 1})
+		prefixes = append(prefixes, netip.PrefixFrom(addr, 32))
+	}
+
+	res, err := AggregatePrefixesMax(prefixes, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Prefixes) > 100 {
+		t.Errorf("got %d prefixes, want at most 100", len(res.Prefixes))
+	}
+	for _, p := range prefixes {
+		if !slices.ContainsFunc(res.Prefixes, func(q netip.Prefix) bool { return q.Contains(p.Addr()) }) {
+			t.Fatalf("%v is not covered", p)
+		}
+	}
+}
+
+func ExampleAggregatePrefixesMax() {
+	prefixes := []netip.Prefix{
+		netip.MustParsePrefix("198.51.100.1/32"),
+		netip.MustParsePrefix("198.51.100.2/32"),
+		netip.MustParsePrefix("198.51.100.3/32"),
+	}
+
+	res, _ := AggregatePrefixesMax(prefixes, 1)
+	fmt.Println(res.Prefixes, res.Extra, res.ExtraAddresses())
+	// Output: [198.51.100.0/30] [198.51.100.0/32] 1
+}
+
+type fakeBlacklists struct {
+	BlacklistsService
+	mu      sync.Mutex
+	created []string
+	failAt  int
+
+	existing  []Blacklist
+	listCalls int
+
+	deleted      []int64
+	deleteStatus int
+}
+
+func (f *fakeBlacklists) Create(_ context.Context, _ int64, r *BlacklistCreateRequest) (*Blacklist, *Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.failAt > 0 && len(f.created)+1 == f.failAt {
+		return nil, nil, errors.New("create failed")
+	}
+	f.created = append(f.created, r.IP)
+
+	return &Blacklist{ID: int64(len(f.created)), IP: r.IP}, nil, nil
+}
+
+func TestCreateAggregatedBlacklists(t *testing.T) {
+	ips := []string{"10.0.0.0/25", "10.0.0.128/25", "10.0.1.7", "2001:db8::1"}
+
+	f := &fakeBlacklists{}
+	blacklists, result, _, err := CreateAggregatedBlacklists(context.Background(), f, 1, ips, nil)
+	if err != nil {
+		t.Fatalf("CreateAggregatedBlacklists: %v", err)
+	}
+
+	want := []string{"10.0.0.0/24", "10.0.1.7", "2001:db8::1"}
+	if !slices.Equal(f.created, want) {
+		t.Errorf("created %v, want %v", f.created, want)
+	}
+	if len(blacklists) != len(want) || len(result.Prefixes) != len(want) {
+		t.Errorf("got %d blacklists and %d prefixes, want %d", len(blacklists), len(result.Prefixes), len(want))
+	}
+
+	f = &fakeBlacklists{failAt: 2}
+	blacklists, _, _, err = CreateAggregatedBlacklists(context.Background(), f, 1, ips, nil)
+	if err == nil {
+		t.Fatal("expected create error")
+	}
+	if len(blacklists) != 1 {
+		t.Errorf("got %d blacklists before the failure, want 1", len(blacklists))
+	}
+
+	if _, _, _, err = CreateAggregatedBlacklists(context.Background(), f, 1, []string{"not-an-ip"}, nil); err == nil {
+		t.Error("expected parse error")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"path"
@@ -57,6 +58,34 @@ type Client struct {
 	// Optional extra HTTP headers to set on every request to the API.
 	headers map[string]string
 
+	// Optional check of blacklist and whitelist entries against the opposite list before they are sent.
+	listConflictCheck bool
+
+	// Optional check of GeoIP lists against the ISO 3166-1 alpha-2 country codes before they are sent.
+	geoIPListCheck bool
+
+	// Optional check of headers against the other headers of their resource before they are sent.
+	duplicateHeaderCheck bool
+
+	// Optional upper bounds of origin settings.
+	originLimits OriginLimits
+
+	// Optional check of origins and aliases against the feature flags of their resource before they are sent.
+	parentValidation bool
+
+	// Optional logger of API requests and the levels they are logged at.
+	logger    *slog.Logger
+	logLevels LogLevels
+
+	// Optional recorder of API request metrics.
+	metrics MetricsRecorder
+
+	// Optional tracer of API requests.
+	tracer Tracer
+
+	// Optional writer of request and response dumps.
+	debugDump *debugDumper
+
 	// Optional retry values. Setting the RetryConfig.RetryMax value enables automatically retrying requests
 	// that fail with 429 or 500-level response codes
 	RetryConfig RetryConfig
@@ -89,6 +118,15 @@ type RequestCompletionCallback func(*http.Request, *http.Response)
 // Response is a EdgecenterCloud response. This wraps the standard http.Response returned from EdgecenterCloud.
 type Response struct {
 	*http.Response
+
+	// RequestID is the ID the API or its proxies assigned to the request, to reference it in support tickets
+	RequestID string
+
+	// Rate is the rate limit state after the request
+	Rate Rate
+
+	// ServerTiming are the metrics of the Server-Timing header
+	ServerTiming []ServerTiming
 }
 
 // An ResponseError reports the error caused by an API request.
@@ -101,6 +139,15 @@ type ResponseError struct {
 
 	// Attempts is the number of times the request was attempted when retries are enabled.
 	Attempts int
+
+	// RequestID is the ID the API or its proxies assigned to the request, to reference it in support tickets
+	RequestID string `json:"request_id"`
+
+	// Rate is the rate limit state after the request
+	Rate Rate `json:"-"`
+
+	// ServerTiming are the metrics of the Server-Timing header
+	ServerTiming []ServerTiming `json:"-"`
 }
 
 func addOptions(s string, opt interface{}) (string, error) {
@@ -194,6 +241,17 @@ func New(httpClient *http.Client, opts ...ClientOpt) (*Client, error) {
 
 		// By default, this is nil and does not log.
 		retryableClient.Logger = c.RetryConfig.Logger
+		if retryableClient.Logger == nil && c.logger != nil {
+			retryableClient.Logger = NewRetryableLogger(c.logger)
+		}
+
+		// Records the attempt number in the request context for logging
+		retryableClient.RequestLogHook = countAttempt
+
+		// Dumps every attempt rather than the request as a whole
+		if c.debugDump != nil {
+			retryableClient.HTTPClient.Transport = c.debugDump.wrap(retryableClient.HTTPClient.Transport)
+		}
 
 		// if timeout is set, it is maintained before overwriting client with StandardClient()
 		retryableClient.HTTPClient.Timeout = c.HTTPClient.Timeout
@@ -212,6 +270,11 @@ func New(httpClient *http.Client, opts ...ClientOpt) (*Client, error) {
 		}
 
 		c.HTTPClient = retryableClient.StandardClient()
+	} else if c.debugDump != nil {
+		// the given client is copied to leave it unchanged
+		httpClient := *c.HTTPClient
+		httpClient.Transport = c.debugDump.wrap(httpClient.Transport)
+		c.HTTPClient = &httpClient
 	}
 
 	return c, nil
@@ -280,7 +343,7 @@ func WithRetryAndBackoffs(retryConfig RetryConfig) ClientOpt {
 // NewRequest creates an API request. A relative URL can be provided in urlStr, which will be resolved to the
 // BaseURL of the Client. Relative URLS should always be specified without a preceding slash. If specified, the
 // value pointed to by body is JSON encoded and included in as the request body.
-func (c *Client) NewRequest(_ context.Context, method, urlStr string, body interface{}) (*http.Request, error) {
+func (c *Client) NewRequest(ctx context.Context, method, urlStr string, body interface{}) (*http.Request, error) {
 	// check urlStr is valid path
 	if _, err := url.Parse(urlStr); err != nil {
 		return nil, err
@@ -327,7 +390,12 @@ func (c *Client) NewRequest(_ context.Context, method, urlStr string, body inter
 
 // newResponse creates a new Response for the provided http.Response.
 func newResponse(r *http.Response) *Response {
-	response := Response{Response: r}
+	response := Response{
+		Response:     r,
+		RequestID:    headerRequestID(r.Header),
+		Rate:         headerRate(r.Header, time.Now()),
+		ServerTiming: headerServerTiming(r.Header),
+	}
 
 	return &response
 }
@@ -336,6 +404,36 @@ func newResponse(r *http.Response) *Response {
 // pointed to by v, or returned as an error if an API error has occurred. If v implements the io.Writer interface,
 // the raw response will be written to v, without attempting to decode it.
 func (c *Client) Do(ctx context.Context, req *http.Request, v interface{}) (*Response, error) {
+	if c.logger == nil && c.metrics == nil && c.tracer == nil {
+		return c.do(ctx, req, v)
+	}
+
+	var span Span
+	if c.tracer != nil {
+		ctx, span = c.startSpan(ctx, req)
+	}
+
+	ctx, counter := withAttemptCounter(ctx)
+	start := time.Now()
+
+	resp, err := c.do(ctx, req, v)
+
+	attempts, duration := requestAttempts(counter, resp), time.Since(start)
+	if span != nil {
+		endSpan(span, resp, err, attempts)
+	}
+	if c.logger != nil {
+		c.logRequest(ctx, req, resp, err, attempts, duration)
+	}
+	if c.metrics != nil {
+		c.recordMetrics(req, resp, err, attempts, duration)
+	}
+
+	return resp, err
+}
+
+// do sends the API request and decodes the response
+func (c *Client) do(ctx context.Context, req *http.Request, v interface{}) (*Response, error) {
 	resp, err := DoRequestWithClient(ctx, c.HTTPClient, req)
 	if err != nil {
 		return &Response{
@@ -367,6 +465,11 @@ func (c *Client) Do(ctx context.Context, req *http.Request, v interface{}) (*Res
 
 	err = CheckResponse(resp)
 	if err != nil {
+		// the request ID in the error body takes precedence over the header
+		if respErr, ok := err.(*ResponseError); ok && respErr.RequestID != "" {
+			response.RequestID = respErr.RequestID
+		}
+
 		return response, err
 	}
 
@@ -377,12 +480,8 @@ func (c *Client) Do(ctx context.Context, req *http.Request, v interface{}) (*Res
 			err = json.NewDecoder(resp.Body).Decode(v)
 		}
 		if err != nil {
-			return &Response{
-				Response: &http.Response{
-					Status:     http.StatusText(http.StatusInternalServerError),
-					StatusCode: http.StatusInternalServerError,
-				},
-			}, err
+			// the response keeps its status and metadata, so a body that cannot be decoded can still be traced
+			return response, err
 		}
 	}
 
@@ -397,13 +496,18 @@ func DoRequestWithClient(ctx context.Context, client *http.Client, req *http.Req
 }
 
 func (r *ResponseError) Error() string {
+	var requestID string
+	if r.RequestID != "" {
+		requestID = fmt.Sprintf(" (request ID %s)", r.RequestID)
+	}
+
 	var attempted string
 	if r.Attempts > 0 {
 		attempted = fmt.Sprintf("; giving up after %d attempt(s)", r.Attempts)
 	}
 
-	return fmt.Sprintf("%v %v: %d %v%s",
-		r.Response.Request.Method, r.Response.Request.URL, r.Response.StatusCode, r.Message, attempted)
+	return fmt.Sprintf("%v %v: %d %v%s%s",
+		r.Response.Request.Method, r.Response.Request.URL, r.Response.StatusCode, r.Message, requestID, attempted)
 }
 
 // CheckResponse checks the API response for errors, and returns them if present. A response is considered an
@@ -429,6 +533,12 @@ func CheckResponse(r *http.Response) error {
 		errorResponse.Attempts = attempts
 	}
 
+	if errorResponse.RequestID == "" {
+		errorResponse.RequestID = headerRequestID(r.Header)
+	}
+	errorResponse.Rate = headerRate(r.Header, time.Now())
+	errorResponse.ServerTiming = headerServerTiming(r.Header)
+
 	return errorResponse
 }
 
@@ -436,3 +546,33 @@ func CheckResponse(r *http.Response) error {
 func PtrTo[T any](v T) *T {
 	return &v
 }
+
+// valueOf returns the value pointed to by p, or the zero value if p is nil.
+func valueOf[T any](p *T) T {
+	if p == nil {
+		var zero T
+		return zero
+	}
+
+	return *p
+}
+
+// listPageSize is the page size used by listAll
+const listPageSize = 100
+
+// listAll calls list with increasing offsets and collects the items of every page. Listing stops at the first page
+// shorter than the requested limit, or at a page longer than it, which means the API ignored the limit.
+func listAll[T any](ctx context.Context, list func(ctx context.Context, limit, offset int) ([]T, error)) ([]T, error) {
+	var all []T
+	for offset := 0; ; offset += listPageSize {
+		page, err := list(ctx, listPageSize, offset)
+		if err != nil {
+			return nil, err
+		}
+
+		all = append(all, page...)
+		if len(page) != listPageSize {
+			return all, nil
+		}
+	}
+}
@@ -0,0 +1,369 @@
+package edgecenterprotection_go
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"slices"
+	"time"
+)
+
+const (
+	// number of times a read-modify-write update is retried after a conflicting change
+	defaultUpdateConflictRetries = 5
+
+	updateConflictBackoffMin = 100 * time.Millisecond
+	updateConflictBackoffMax = 2 * time.Second
+)
+
+// setIfMatch makes the request conditional on the ETag, unless it is empty
+func setIfMatch(req *http.Request, etag string) {
+	if etag != "" {
+		req.Header.Set("If-Match", etag)
+	}
+}
+
+// conditionalResourceUpdater is implemented by resource services able to send If-Match with updates
+type conditionalResourceUpdater interface {
+	update(ctx context.Context, resourceID int64, reqBody *ResourceUpdateRequest, ifMatch string) (*Resource, *Response, error)
+}
+
+// conditionalOriginUpdater is implemented by origin services able to send If-Match with updates
+type conditionalOriginUpdater interface {
+	update(ctx context.Context, resourceID int64, originID int64, reqBody *OriginCreateRequest, ifMatch string) (*Origin, *Response, error)
+}
+
+// conditionalAliasUpdater is implemented by alias services able to send If-Match with updates
+type conditionalAliasUpdater interface {
+	update(ctx context.Context, resourceID int64, aliasID int64, reqBody *AliasUpdateRequest, ifMatch string) (*Alias, *Response, error)
+}
+
+// conditionalHeaderUpdater is implemented by header services able to send If-Match with updates
+type conditionalHeaderUpdater interface {
+	checkedUpdate(ctx context.Context, resourceID int64, headerID int64, reqBody *HeaderCreateRequest, ifMatch string) (*Header, *Response, error)
+}
+
+// updateWithRetry implements read-modify-write updates with optimistic concurrency. The object is read and
+// mutated, then written. If conditional is set and the API supplied an ETag with the read, write gets it as ifMatch.
+// Otherwise the object is read again right before writing and compared with the first read using same; a change
+// made between this second read and the write is not detected and gets overwritten. On a conflict, either detected
+// locally or reported by the API with 409 or 412, the whole cycle is retried.
+func updateWithRetry[T any](
+	ctx context.Context,
+	conditional bool,
+	get func(context.Context) (*T, *Response, error),
+	clone func(*T) *T,
+	mutate func(*T) error,
+	same func(a, b *T) bool,
+	write func(ctx context.Context, ifMatch string, current, mutated *T) (*T, *Response, error),
+) (*T, *Response, error) {
+	var resp *Response
+
+	for attempt := 0; attempt <= defaultUpdateConflictRetries; attempt++ {
+		if attempt > 0 {
+			if err := sleepCtx(ctx, updateConflictBackoff(attempt)); err != nil {
+				return nil, resp, err
+			}
+		}
+
+		current, getResp, err := get(ctx)
+		resp = getResp
+		if err != nil {
+			return nil, resp, err
+		}
+
+		mutated := clone(current)
+		if err := mutate(mutated); err != nil {
+			return nil, resp, err
+		}
+
+		if reflect.DeepEqual(current, mutated) {
+			return current, resp, nil
+		}
+
+		var etag string
+		if conditional {
+			etag = responseETag(resp)
+		}
+		if etag == "" {
+			latest, latestResp, err := get(ctx)
+			resp = latestResp
+			if err != nil {
+				return nil, resp, err
+			}
+
+			if !same(current, latest) {
+				continue
+			}
+		}
+
+		updated, writeResp, err := write(ctx, etag, current, mutated)
+		resp = writeResp
+		if isResponseStatus(err, http.StatusConflict, http.StatusPreconditionFailed) {
+			continue
+		}
+
+		return updated, resp, err
+	}
+
+	return nil, resp, ErrUpdateConflict
+}
+
+// UpdateResourceWithRetry reads DDoS resource, applies mutate and writes back only the changed fields.
+// If the resource is modified by someone else in the meantime, the update is retried with fresh data.
+// The write is conditional on the ETag of the read if the API supplies one and s is the service of a Client.
+// Otherwise the resource is read again before writing, comparing UpdatedAt, and a change made between
+// that read and the write is overwritten.
+func UpdateResourceWithRetry(ctx context.Context, s ResourcesService, resourceID int64, mutate func(*Resource) error) (*Resource, *Response, error) {
+	if mutate == nil {
+		return nil, nil, NewArgError("mutate", "cannot be nil")
+	}
+
+	write := func(ctx context.Context, _ string, current, mutated *Resource) (*Resource, *Response, error) {
+		return s.Update(ctx, resourceID, resourceUpdateDiff(current, mutated))
+	}
+	u, conditional := s.(conditionalResourceUpdater)
+	if conditional {
+		write = func(ctx context.Context, ifMatch string, current, mutated *Resource) (*Resource, *Response, error) {
+			return u.update(ctx, resourceID, resourceUpdateDiff(current, mutated), ifMatch)
+		}
+	}
+
+	return updateWithRetry(ctx, conditional,
+		func(ctx context.Context) (*Resource, *Response, error) {
+			return s.Get(ctx, resourceID)
+		},
+		cloneResource,
+		mutate,
+		func(a, b *Resource) bool {
+			if !a.UpdatedAt.IsZero() || !b.UpdatedAt.IsZero() {
+				return a.UpdatedAt.Equal(b.UpdatedAt)
+			}
+			return reflect.DeepEqual(a, b)
+		},
+		write,
+	)
+}
+
+// UpdateOriginWithRetry reads the origin of DDoS resource, applies mutate and writes it back.
+// If the origin is modified by someone else in the meantime, the update is retried with fresh data.
+// Conflicts are detected as described for UpdateResourceWithRetry, comparing all fields without an ETag.
+func UpdateOriginWithRetry(ctx context.Context, s OriginsService, resourceID int64, originID int64, mutate func(*Origin) error) (*Origin, *Response, error) {
+	if mutate == nil {
+		return nil, nil, NewArgError("mutate", "cannot be nil")
+	}
+
+	write := func(ctx context.Context, _ string, _, mutated *Origin) (*Origin, *Response, error) {
+		return s.Update(ctx, resourceID, originID, OriginCreateRequestFromOrigin(mutated))
+	}
+	u, conditional := s.(conditionalOriginUpdater)
+	if conditional {
+		write = func(ctx context.Context, ifMatch string, _, mutated *Origin) (*Origin, *Response, error) {
+			return u.update(ctx, resourceID, originID, OriginCreateRequestFromOrigin(mutated), ifMatch)
+		}
+	}
+
+	return updateWithRetry(ctx, conditional,
+		func(ctx context.Context) (*Origin, *Response, error) {
+			return s.Get(ctx, resourceID, originID)
+		},
+		func(o *Origin) *Origin {
+			c := *o
+			return &c
+		},
+		mutate,
+		func(a, b *Origin) bool {
+			return *a == *b
+		},
+		write,
+	)
+}
+
+// UpdateAliasWithRetry reads the alias of DDoS resource, applies mutate and writes back its SSL settings.
+// If the alias is modified by someone else in the meantime, the update is retried with fresh data.
+// Conflicts are detected as described for UpdateResourceWithRetry, comparing Updated without an ETag.
+// Only SSLType can be updated, an ArgError is returned if mutate changes any other field.
+func UpdateAliasWithRetry(ctx context.Context, s AliasesService, resourceID int64, aliasID int64, mutate func(*Alias) error) (*Alias, *Response, error) {
+	if mutate == nil {
+		return nil, nil, NewArgError("mutate", "cannot be nil")
+	}
+
+	write := func(ctx context.Context, _ string, _, mutated *Alias) (*Alias, *Response, error) {
+		return s.Update(ctx, resourceID, aliasID, &AliasUpdateRequest{SSLType: mutated.SSLType})
+	}
+	u, conditional := s.(conditionalAliasUpdater)
+	if conditional {
+		write = func(ctx context.Context, ifMatch string, _, mutated *Alias) (*Alias, *Response, error) {
+			return u.update(ctx, resourceID, aliasID, &AliasUpdateRequest{SSLType: mutated.SSLType}, ifMatch)
+		}
+	}
+
+	return updateWithRetry(ctx, conditional,
+		func(ctx context.Context) (*Alias, *Response, error) {
+			return s.Get(ctx, resourceID, aliasID)
+		},
+		func(a *Alias) *Alias {
+			c := *a
+			if a.SSLType != nil {
+				c.SSLType = PtrTo(*a.SSLType)
+			}
+			return &c
+		},
+		func(a *Alias) error {
+			fetched := *a
+			if err := mutate(a); err != nil {
+				return err
+			}
+			return checkAliasReadOnly(&fetched, a)
+		},
+		func(a, b *Alias) bool {
+			if !a.Updated.IsZero() || !b.Updated.IsZero() {
+				return a.Updated.Equal(b.Updated)
+			}
+			return reflect.DeepEqual(a, b)
+		},
+		write,
+	)
+}
+
+// UpdateHeaderWithRetry reads the header of DDoS resource, applies mutate and writes it back.
+// If the header is modified by someone else in the meantime, the update is retried with fresh data.
+// Conflicts are detected as described for UpdateResourceWithRetry, comparing all fields without an ETag.
+func UpdateHeaderWithRetry(ctx context.Context, s HeadersService, resourceID int64, headerID int64, mutate func(*Header) error) (*Header, *Response, error) {
+	if mutate == nil {
+		return nil, nil, NewArgError("mutate", "cannot be nil")
+	}
+
+	write := func(ctx context.Context, _ string, _, mutated *Header) (*Header, *Response, error) {
+		return s.Update(ctx, resourceID, headerID, &HeaderCreateRequest{Key: mutated.Key, Value: mutated.Value})
+	}
+	u, conditional := s.(conditionalHeaderUpdater)
+	if conditional {
+		write = func(ctx context.Context, ifMatch string, _, mutated *Header) (*Header, *Response, error) {
+			return u.checkedUpdate(ctx, resourceID, headerID, &HeaderCreateRequest{Key: mutated.Key, Value: mutated.Value}, ifMatch)
+		}
+	}
+
+	return updateWithRetry(ctx, conditional,
+		func(ctx context.Context) (*Header, *Response, error) {
+			return s.Get(ctx, resourceID, headerID)
+		},
+		func(h *Header) *Header {
+			c := *h
+			return &c
+		},
+		mutate,
+		func(a, b *Header) bool {
+			return *a == *b
+		},
+		write,
+	)
+}
+
+// checkAliasReadOnly returns an ArgError naming the first field other than SSLType that differs between
+// the fetched and the mutated alias, as the API cannot update them
+func checkAliasReadOnly(fetched, mutated *Alias) error {
+	switch {
+	case mutated.ID != fetched.ID:
+		return NewArgError("ID", "cannot be updated")
+	case mutated.Name != fetched.Name:
+		return NewArgError("Name", "cannot be updated")
+	case !mutated.Created.Equal(fetched.Created):
+		return NewArgError("Created", "cannot be updated")
+	case !mutated.Updated.Equal(fetched.Updated):
+		return NewArgError("Updated", "cannot be updated")
+	case mutated.SSLExpire != fetched.SSLExpire:
+		return NewArgError("SSLExpire", "cannot be updated")
+	case mutated.SSLStatus != fetched.SSLStatus:
+		return NewArgError("SSLStatus", "cannot be updated")
+	}
+
+	return nil
+}
+
+// cloneResource returns a deep copy of the resource
+func cloneResource(r *Resource) *Resource {
+	c := *r
+	c.TLSEnabled = slices.Clone(r.TLSEnabled)
+	if r.SSLType != nil {
+		c.SSLType = PtrTo(*r.SSLType)
+	}
+
+	return &c
+}
+
+// resourceUpdateDiff returns an update request with the updatable fields that differ between a and b set from b
+func resourceUpdateDiff(a, b *Resource) *ResourceUpdateRequest {
+	req := &ResourceUpdateRequest{}
+
+	if a.Active != b.Active {
+		req.Active = PtrTo(b.Active)
+	}
+	if a.MultipleOrigins != b.MultipleOrigins {
+		req.MultipleOrigins = PtrTo(b.MultipleOrigins)
+	}
+	if a.WidlcardAliases != b.WidlcardAliases {
+		req.WidlcardAliases = PtrTo(b.WidlcardAliases)
+	}
+	if a.RedirectToHTTPS != b.RedirectToHTTPS {
+		req.RedirectToHTTPS = PtrTo(b.RedirectToHTTPS)
+	}
+	if a.HTTPS2HTTP != b.HTTPS2HTTP {
+		req.HTTPS2HTTP = PtrTo(b.HTTPS2HTTP)
+	}
+	if a.IPHash != b.IPHash {
+		req.IPHash = PtrTo(b.IPHash)
+	}
+	if a.GeoIPMode != b.GeoIPMode {
+		req.GeoIPMode = PtrTo(b.GeoIPMode)
+	}
+	if a.GeoIPList != b.GeoIPList {
+		req.GeoIPList = PtrTo(b.GeoIPList)
+	}
+	if a.WWWRedir != b.WWWRedir {
+		req.WWWRedir = PtrTo(b.WWWRedir)
+	}
+	if !slices.Equal(a.TLSEnabled, b.TLSEnabled) {
+		req.TLSEnabled = NewOptional(append([]string{}, b.TLSEnabled...))
+	}
+	if (a.SSLType == nil) != (b.SSLType == nil) || valueOf(a.SSLType) != valueOf(b.SSLType) {
+		req.SSLType = OptionalFromPtr(b.SSLType)
+	}
+	if a.WAF != b.WAF {
+		req.WAF = PtrTo(b.WAF)
+	}
+
+	return req
+}
+
+// responseETag returns the ETag header of the response, if any
+func responseETag(resp *Response) string {
+	if resp == nil || resp.Response == nil {
+		return ""
+	}
+
+	return resp.Header.Get("ETag")
+}
+
+// updateConflictBackoff returns the delay before the given retry of a conflicting update
+func updateConflictBackoff(attempt int) time.Duration {
+	d := updateConflictBackoffMin << (attempt - 1)
+	if d <= 0 || d > updateConflictBackoffMax {
+		d = updateConflictBackoffMax
+	}
+
+	return d
+}
+
+// sleepCtx waits for d or until ctx is done
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,217 @@
+package edgecenterprotection_go
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func newTestClient(t *testing.T, handler http.Handler) *Client {
+	t.Helper()
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	c, err := New(srv.Client(), SetBaseURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return c
+}
+
+func TestUpdateAliasWithRetry(t *testing.T) {
+	var ifMatch, body string
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		if r.Method == http.MethodPatch {
+			ifMatch = r.Header.Get("If-Match")
+			data, _ := io.ReadAll(r.Body)
+			body = string(data)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"id": 2, "alias_data": "www.example.com", "alias_ssl_type": "le"})
+	}))
+
+	_, _, err := UpdateAliasWithRetry(context.Background(), c.Aliases, 1, 2, func(a *Alias) error {
+		a.SSLType = PtrTo("custom")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ifMatch != `"v1"` || body != `{"alias_ssl_type":"custom"}`+"\n" {
+		t.Errorf("PATCH If-Match %q body %q", ifMatch, body)
+	}
+
+	ifMatch = ""
+	_, _, err = UpdateAliasWithRetry(context.Background(), c.Aliases, 1, 2, func(a *Alias) error {
+		a.Name = "api.example.com"
+		return nil
+	})
+	var argErr *ArgError
+	if !errors.As(err, &argErr) || argErr.arg != "Name" {
+		t.Errorf("UpdateAliasWithRetry() renaming alias = %v, want Name ArgError", err)
+	}
+	if ifMatch != "" {
+		t.Error("alias with a changed name was written")
+	}
+}
+
+// resourceServer serves a resource whose updated timestamps are taken from updates in turn, answering the first
+// PATCH requests with the statuses in patchStatuses
+type resourceServer struct {
+	mu            sync.Mutex
+	etag          string
+	updates       []string
+	gets          int
+	patchStatuses []int
+	patches       []string
+	ifMatch       []string
+}
+
+func (rs *resourceServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	if rs.etag != "" {
+		w.Header().Set("ETag", rs.etag)
+	}
+
+	if r.Method == http.MethodPatch {
+		data, _ := io.ReadAll(r.Body)
+		rs.patches = append(rs.patches, string(data))
+		rs.ifMatch = append(rs.ifMatch, r.Header.Get("If-Match"))
+
+		if len(rs.patchStatuses) > 0 {
+			status := rs.patchStatuses[0]
+			rs.patchStatuses = rs.patchStatuses[1:]
+			w.WriteHeader(status)
+			return
+		}
+	} else {
+		rs.gets++
+	}
+
+	updated := rs.updates[min(rs.gets, len(rs.updates))-1]
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"id":             1,
+		"name":           "example.com",
+		"updated":        updated,
+		"is_waf_enabled": false,
+		"tls_enabled":    []string{"1.2"},
+	})
+}
+
+func enableWAF(r *Resource) error {
+	r.WAF = true
+	return nil
+}
+
+func TestUpdateResourceWithRetryETag(t *testing.T) {
+	rs := &resourceServer{etag: `"v1"`, updates: []string{"2024-01-01T00:00:00Z"}, patchStatuses: []int{http.StatusPreconditionFailed, http.StatusConflict}}
+	c := newTestClient(t, rs)
+
+	if _, _, err := UpdateResourceWithRetry(context.Background(), c.Resources, 1, enableWAF); err != nil {
+		t.Fatal(err)
+	}
+
+	// with an ETag the resource is read once per attempt
+	if rs.gets != 3 || len(rs.patches) != 3 {
+		t.Errorf("got %d reads and %d writes, want 3 and 3", rs.gets, len(rs.patches))
+	}
+	for i, p := range rs.patches {
+		if p != `{"is_waf_enabled":true}`+"\n" || rs.ifMatch[i] != `"v1"` {
+			t.Errorf("PATCH %d If-Match %q body %q", i, rs.ifMatch[i], p)
+		}
+	}
+}
+
+func TestUpdateResourceWithRetryUpdatedAt(t *testing.T) {
+	// the resource changes between the first two reads, then stays the same
+	rs := &resourceServer{updates: []string{"2024-01-01T00:00:00Z", "2024-01-01T00:00:01Z", "2024-01-01T00:00:01Z", "2024-01-01T00:00:01Z"}}
+	c := newTestClient(t, rs)
+
+	if _, _, err := UpdateResourceWithRetry(context.Background(), c.Resources, 1, enableWAF); err != nil {
+		t.Fatal(err)
+	}
+
+	if rs.gets != 4 || len(rs.patches) != 1 {
+		t.Errorf("got %d reads and %d writes, want 4 and 1", rs.gets, len(rs.patches))
+	}
+	if rs.ifMatch[0] != "" {
+		t.Errorf("If-Match %q sent without an ETag", rs.ifMatch[0])
+	}
+}
+
+func TestUpdateResourceWithRetryOtherService(t *testing.T) {
+	rs := &resourceServer{etag: `"v1"`, updates: []string{"2024-01-01T00:00:00Z"}}
+	c := newTestClient(t, rs)
+
+	// services outside this package cannot send If-Match, so the resource is read again before writing
+	s := struct{ ResourcesService }{c.Resources}
+	if _, _, err := UpdateResourceWithRetry(context.Background(), s, 1, enableWAF); err != nil {
+		t.Fatal(err)
+	}
+
+	if rs.gets != 2 || len(rs.patches) != 1 || rs.ifMatch[0] != "" {
+		t.Errorf("got %d reads and %d writes with If-Match %q, want 2 and 1 without", rs.gets, len(rs.patches), rs.ifMatch)
+	}
+}
+
+func TestUpdateResourceWithRetryUnchanged(t *testing.T) {
+	rs := &resourceServer{updates: []string{"2024-01-01T00:00:00Z"}}
+	c := newTestClient(t, rs)
+
+	_, _, err := UpdateResourceWithRetry(context.Background(), c.Resources, 1, func(r *Resource) error {
+		r.WAF = false
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rs.patches) != 0 {
+		t.Errorf("unchanged resource was written: %q", rs.patches)
+	}
+
+	mutateErr := errors.New("mutate failed")
+	_, _, err = UpdateResourceWithRetry(context.Background(), c.Resources, 1, func(*Resource) error {
+		return mutateErr
+	})
+	if !errors.Is(err, mutateErr) {
+		t.Errorf("UpdateResourceWithRetry() = %v, want the mutate error", err)
+	}
+}
+
+func TestResourceUpdateDiff(t *testing.T) {
+	a := &Resource{Name: "example.com", TLSEnabled: []string{"1.2"}, SSLType: PtrTo("le"), GeoIPList: "RU"}
+
+	b := cloneResource(a)
+	b.Name = "other.example.com"
+	b.Active = true
+	b.TLSEnabled = append(b.TLSEnabled, "1.3")
+	b.SSLType = nil
+	b.GeoIPMode = GeoIPModeDeny
+
+	data, err := json.Marshal(resourceUpdateDiff(a, b))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"active":true,"service_geoip_mode":2,"tls_enabled":["1.2","1.3"],"ssl_type":null}`
+	if string(data) != want {
+		t.Errorf("diff = %s, want %s", data, want)
+	}
+
+	if a.TLSEnabled[0] != "1.2" || len(a.TLSEnabled) != 1 || *a.SSLType != "le" {
+		t.Errorf("cloneResource shares data with the original: %+v", a)
+	}
+
+	if data, _ := json.Marshal(resourceUpdateDiff(a, cloneResource(a))); string(data) != "{}" {
+		t.Errorf("diff of equal resources = %s, want {}", data)
+	}
+}
@@ -0,0 +1,199 @@
+package edgecenterprotection_go
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"sync"
+	"time"
+)
+
+// defaultDumpBodySize limits dumped bodies unless DebugDumpOptions.MaxBodySize is set
+const defaultDumpBodySize = 64 << 10
+
+// DebugDumpOptions configures WithDebugDump
+type DebugDumpOptions struct {
+	// MaxBodySize limits the dumped bytes of each request and response body, 64 KiB by default.
+	// A negative value omits bodies.
+	MaxBodySize int
+}
+
+// WithDebugDump is a client option writing every HTTP request sent to the API and its response to w, including
+// each retry attempt. Authorization and cookie headers and the SSL private keys in bodies are redacted.
+// Dumps of concurrent requests do not interleave, but w must not be written to by anything else.
+func WithDebugDump(w io.Writer, opts *DebugDumpOptions) ClientOpt {
+	return func(c *Client) error {
+		if w == nil {
+			return NewArgError("w", "cannot be nil")
+		}
+
+		d := &debugDumper{w: w, maxBodySize: defaultDumpBodySize}
+		if opts != nil && opts.MaxBodySize != 0 {
+			d.maxBodySize = opts.MaxBodySize
+		}
+
+		c.debugDump = d
+
+		return nil
+	}
+}
+
+// debugDumper writes redacted dumps of requests and responses
+type debugDumper struct {
+	mu          sync.Mutex
+	w           io.Writer
+	maxBodySize int
+}
+
+// wrap returns a transport dumping the requests sent through rt, http.DefaultTransport if nil
+func (d *debugDumper) wrap(rt http.RoundTripper) http.RoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+
+	return &dumpTransport{next: rt, dumper: d}
+}
+
+// dumpTransport is a http.RoundTripper dumping requests and responses
+type dumpTransport struct {
+	next   http.RoundTripper
+	dumper *debugDumper
+}
+
+func (t *dumpTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	d := t.dumper
+
+	// out is sent in place of req, a RoundTripper must not modify the request it was given
+	out := req
+
+	var reqBody []byte
+	var reqTruncated bool
+	if d.maxBodySize > 0 && req.Body != nil && req.Body != http.NoBody {
+		// without GetBody the peeked bytes are put back in front of the body of a copy
+		if req.GetBody == nil {
+			out = req.Clone(req.Context())
+		}
+
+		var err error
+		reqBody, reqTruncated, err = peekBody(&out.Body, req.GetBody, d.maxBodySize)
+		if err != nil {
+			req.Body.Close()
+			return nil, err
+		}
+	}
+
+	reqDump, err := httputil.DumpRequestOut(redactedRequest(req), false)
+	if err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(out)
+	duration := time.Since(start)
+
+	if resp != nil && resp.Request == out {
+		resp.Request = req
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "---> %s %s\n", req.Method, req.URL.Redacted())
+	buf.Write(reqDump)
+	d.writeBody(&buf, reqBody, reqTruncated)
+
+	if err != nil {
+		fmt.Fprintf(&buf, "<--- error after %s: %v\n\n", duration, err)
+		d.write(buf.Bytes())
+		return resp, err
+	}
+
+	var respBody []byte
+	var respTruncated bool
+	if d.maxBodySize > 0 && resp.Body != nil && resp.Body != http.NoBody {
+		respBody, respTruncated, err = peekBody(&resp.Body, nil, d.maxBodySize)
+		if err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+	}
+
+	respHeader := *resp
+	respHeader.Header = redactHeader(resp.Header)
+	respDump, err := httputil.DumpResponse(&respHeader, false)
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+
+	fmt.Fprintf(&buf, "<--- %s in %s\n", resp.Status, duration)
+	buf.Write(respDump)
+	d.writeBody(&buf, respBody, respTruncated)
+	buf.WriteString("\n")
+	d.write(buf.Bytes())
+
+	return resp, nil
+}
+
+// writeBody appends the redacted body to the dump
+func (d *debugDumper) writeBody(buf *bytes.Buffer, body []byte, truncated bool) {
+	if len(body) == 0 {
+		return
+	}
+
+	buf.Write(redactJSONSecrets(body))
+	if truncated {
+		fmt.Fprintf(buf, "\n[body truncated to %d bytes]", d.maxBodySize)
+	}
+	buf.WriteString("\n")
+}
+
+// write writes the dump of a request as a whole
+func (d *debugDumper) write(dump []byte) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	_, _ = d.w.Write(dump)
+}
+
+// redactedRequest returns a copy of the request for dumping without body, with secret headers redacted
+func redactedRequest(req *http.Request) *http.Request {
+	r := req.Clone(req.Context())
+	r.Header = redactHeader(req.Header)
+
+	return r
+}
+
+// peekBody returns up to limit bytes of the body and whether it is longer. The body is read from getBody if set,
+// otherwise the read bytes are put back in front of it.
+func peekBody(body *io.ReadCloser, getBody func() (io.ReadCloser, error), limit int) ([]byte, bool, error) {
+	r := *body
+	if getBody != nil {
+		var err error
+		if r, err = getBody(); err != nil {
+			return nil, false, err
+		}
+		defer r.Close()
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, int64(limit)+1))
+	if err != nil {
+		return nil, false, err
+	}
+
+	if getBody == nil {
+		*body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(data), *body), *body}
+	}
+
+	if len(data) > limit {
+		return data[:limit], true, nil
+	}
+
+	return data, false, nil
+}
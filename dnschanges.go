@@ -0,0 +1,215 @@
+package edgecenterprotection_go
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+)
+
+// defaultDNSChangeTTL is the TTL of generated records, short enough to switch back quickly during onboarding
+const defaultDNSChangeTTL = 300
+
+// wildcardCheckLabel is resolved in place of the "*" label to check wildcard aliases
+const wildcardCheckLabel = "wildcard-check"
+
+// DNSResolver resolves host names to addresses, *net.Resolver implements it
+type DNSResolver interface {
+	LookupNetIP(ctx context.Context, network, host string) ([]netip.Addr, error)
+}
+
+// DNSRecord is a DNS record required for DDoS resource
+type DNSRecord struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+	TTL   uint32 `json:"ttl"`
+}
+
+// DNSNameStatus is the state of a name of DDoS resource in DNS
+type DNSNameStatus struct {
+	Name string `json:"name"`
+
+	// Current are the addresses the name resolves to
+	Current []string `json:"current"`
+
+	// Done is set if the name resolves only to the service IP
+	Done bool `json:"done"`
+
+	Error string `json:"error,omitempty"`
+}
+
+// DNSChangeSet lists the records pointing the resource name and every alias at the service IP of DDoS resource
+type DNSChangeSet struct {
+	ServiceIP string      `json:"service_ip"`
+	Records   []DNSRecord `json:"records"`
+
+	// Status is filled in by Check
+	Status []DNSNameStatus `json:"status,omitempty"`
+}
+
+// NewDNSChangeSet returns the records required for DDoS resource and its aliases. A zero ttl selects 300 seconds.
+func NewDNSChangeSet(resource *Resource, aliases []Alias, ttl uint32) (*DNSChangeSet, error) {
+	if resource == nil {
+		return nil, NewArgError("resource", "cannot be nil")
+	}
+
+	serviceIP, err := netip.ParseAddr(resource.ServiceIP)
+	if err != nil {
+		return nil, NewArgError("resource", fmt.Sprintf("resource %s has no valid service IP yet", resource.Name))
+	}
+
+	if ttl == 0 {
+		ttl = defaultDNSChangeTTL
+	}
+
+	recordType := "A"
+	if !serviceIP.Unmap().Is4() {
+		recordType = "AAAA"
+	}
+
+	cs := &DNSChangeSet{ServiceIP: serviceIP.Unmap().String()}
+
+	names := []string{resource.Name}
+	for _, a := range aliases {
+		names = append(names, a.Name)
+	}
+
+	seen := make(map[string]bool, len(names))
+	for _, n := range names {
+		name, err := NormalizeDomainName(n)
+		if err != nil {
+			return nil, err
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		cs.Records = append(cs.Records, DNSRecord{Name: name, Type: recordType, Value: cs.ServiceIP, TTL: ttl})
+	}
+
+	return cs, nil
+}
+
+// GenerateDNSChanges builds the change set of DDoS resource and every page of its aliases and checks it against DNS
+// with GetDomainName and the resolver, net.DefaultResolver if nil, see Check
+func GenerateDNSChanges(ctx context.Context, c *Client, resourceID int64, resolver DNSResolver) (*DNSChangeSet, error) {
+	resource, _, err := c.Resources.Get(ctx, resourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	aliases, err := listAllAliases(ctx, c.Aliases, resourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	cs, err := NewDNSChangeSet(resource, aliases, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	dnsCheck, _, err := c.Resources.GetDomainName(ctx, resourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	cs.Check(ctx, dnsCheck, resolver)
+
+	return cs, nil
+}
+
+// Check fills in the status of every name. The first record is the resource name, its addresses are taken from
+// dnsCheck if it is not nil and the service IP is an IPv4 address, as dnsCheck only reports A records. Other names
+// are resolved, wildcard names by replacing "*" with a fixed label.
+func (cs *DNSChangeSet) Check(ctx context.Context, dnsCheck *DnsCheck, resolver DNSResolver) {
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+
+	if serviceIP, err := netip.ParseAddr(cs.ServiceIP); err != nil || !serviceIP.Unmap().Is4() {
+		dnsCheck = nil
+	}
+
+	cs.Status = make([]DNSNameStatus, len(cs.Records))
+	for i, rec := range cs.Records {
+		status := DNSNameStatus{Name: rec.Name}
+
+		if i == 0 && dnsCheck != nil {
+			for _, a := range dnsCheck.A {
+				if addr, err := netip.ParseAddr(a); err == nil {
+					a = addr.Unmap().String()
+				}
+				status.Current = append(status.Current, a)
+			}
+		} else {
+			host := rec.Name
+			if isWildcardDomain(host) {
+				host = wildcardCheckLabel + host[1:]
+			}
+
+			addrs, err := resolver.LookupNetIP(ctx, "ip", host)
+			if err != nil {
+				status.Error = err.Error()
+			}
+			for _, addr := range addrs {
+				status.Current = append(status.Current, addr.Unmap().String())
+			}
+		}
+
+		status.Done = len(status.Current) > 0
+		for _, addr := range status.Current {
+			if addr != cs.ServiceIP {
+				status.Done = false
+			}
+		}
+
+		cs.Status[i] = status
+	}
+}
+
+// Pending returns the status of names that do not resolve only to the service IP yet
+func (cs *DNSChangeSet) Pending() []DNSNameStatus {
+	var pending []DNSNameStatus
+	for _, s := range cs.Status {
+		if !s.Done {
+			pending = append(pending, s)
+		}
+	}
+
+	return pending
+}
+
+// BIND returns the records as a zone file snippet. Names within origin are written relative to it,
+// other names are absolute. An empty origin writes every name absolute.
+func (cs *DNSChangeSet) BIND(origin string) string {
+	origin = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(origin), "."))
+
+	var b strings.Builder
+	if origin != "" {
+		fmt.Fprintf(&b, "$ORIGIN %s.\n", origin)
+	}
+
+	for _, rec := range cs.Records {
+		name := rec.Name + "."
+		switch {
+		case origin == "":
+		case rec.Name == origin:
+			name = "@"
+		case strings.HasSuffix(rec.Name, "."+origin):
+			name = strings.TrimSuffix(rec.Name, "."+origin)
+		}
+
+		fmt.Fprintf(&b, "%s\t%d\tIN\t%s\t%s\n", name, rec.TTL, rec.Type, rec.Value)
+	}
+
+	return b.String()
+}
+
+// JSON returns the change set with the check status as indented JSON
+func (cs *DNSChangeSet) JSON() ([]byte, error) {
+	return json.MarshalIndent(cs, "", "  ")
+}
@@ -0,0 +1,208 @@
+package edgecenterprotection_go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"slices"
+	"testing"
+)
+
+// fakeResolver answers from a table of hosts and records the queried ones
+type fakeResolver struct {
+	addrs   map[string][]string
+	queried []string
+}
+
+func (f *fakeResolver) LookupNetIP(_ context.Context, _, host string) ([]netip.Addr, error) {
+	f.queried = append(f.queried, host)
+
+	addrs, ok := f.addrs[host]
+	if !ok {
+		return nil, fmt.Errorf("lookup %s: no such host", host)
+	}
+
+	var out []netip.Addr
+	for _, a := range addrs {
+		out = append(out, netip.MustParseAddr(a))
+	}
+
+	return out, nil
+}
+
+type dnsResources struct {
+	*fakeResources
+	dnsCheck DnsCheck
+}
+
+func (f *dnsResources) GetDomainName(context.Context, int64) (*DnsCheck, *Response, error) {
+	check := f.dnsCheck
+	return &check, nil, nil
+}
+
+func TestNewDNSChangeSet(t *testing.T) {
+	resource := &Resource{Name: "Example.com", ServiceIP: "203.0.113.1"}
+	aliases := []Alias{{Name: "www.example.com"}, {Name: "WWW.Example.com."}, {Name: "*.example.com"}, {Name: "пример.рф"}}
+
+	cs, err := NewDNSChangeSet(resource, aliases, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []DNSRecord{
+		{Name: "example.com", Type: "A", Value: "203.0.113.1", TTL: 300},
+		{Name: "www.example.com", Type: "A", Value: "203.0.113.1", TTL: 300},
+		{Name: "*.example.com", Type: "A", Value: "203.0.113.1", TTL: 300},
+		{Name: "xn--e1afmkfd.xn--p1ai", Type: "A", Value: "203.0.113.1", TTL: 300},
+	}
+	if cs.ServiceIP != "203.0.113.1" || !slices.Equal(cs.Records, want) {
+		t.Errorf("NewDNSChangeSet() = %+v, want %+v", cs.Records, want)
+	}
+
+	cs, err = NewDNSChangeSet(&Resource{Name: "example.com", ServiceIP: "2001:DB8::1"}, nil, 60)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (DNSRecord{Name: "example.com", Type: "AAAA", Value: "2001:db8::1", TTL: 60}); len(cs.Records) != 1 || cs.Records[0] != want {
+		t.Errorf("NewDNSChangeSet() with IPv6 = %+v, want %+v", cs.Records, want)
+	}
+
+	cs, err = NewDNSChangeSet(&Resource{Name: "example.com", ServiceIP: "::ffff:203.0.113.1"}, nil, 0)
+	if err != nil || cs.Records[0].Type != "A" || cs.ServiceIP != "203.0.113.1" {
+		t.Errorf("NewDNSChangeSet() with a mapped address = %+v, %v", cs, err)
+	}
+
+	var argErr *ArgError
+	if _, err := NewDNSChangeSet(&Resource{Name: "example.com"}, nil, 0); !errors.As(err, &argErr) {
+		t.Errorf("NewDNSChangeSet() without service IP = %v, want ArgError", err)
+	}
+	if _, err := NewDNSChangeSet(nil, nil, 0); !errors.As(err, &argErr) {
+		t.Errorf("NewDNSChangeSet(nil) = %v, want ArgError", err)
+	}
+	if _, err := NewDNSChangeSet(resource, []Alias{{Name: "bad..example.com"}}, 0); err == nil {
+		t.Error("NewDNSChangeSet() with an invalid alias = nil, want error")
+	}
+}
+
+func TestDNSChangeSetCheck(t *testing.T) {
+	resource := &Resource{Name: "example.com", ServiceIP: "203.0.113.1"}
+	aliases := []Alias{{Name: "www.example.com"}, {Name: "shop.example.com"}, {Name: "*.example.com"}, {Name: "old.example.com"}}
+	cs, err := NewDNSChangeSet(resource, aliases, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resolver := &fakeResolver{addrs: map[string][]string{
+		"www.example.com":            {"::ffff:203.0.113.1"},
+		"shop.example.com":           {"203.0.113.1", "2001:db8::10"},
+		"wildcard-check.example.com": {"203.0.113.1"},
+	}}
+	cs.Check(context.Background(), &DnsCheck{A: []string{"203.0.113.1"}}, resolver)
+
+	want := []DNSNameStatus{
+		{Name: "example.com", Current: []string{"203.0.113.1"}, Done: true},
+		{Name: "www.example.com", Current: []string{"203.0.113.1"}, Done: true},
+		{Name: "shop.example.com", Current: []string{"203.0.113.1", "2001:db8::10"}},
+		{Name: "*.example.com", Current: []string{"203.0.113.1"}, Done: true},
+		{Name: "old.example.com", Error: "lookup old.example.com: no such host"},
+	}
+	for i, s := range cs.Status {
+		if s.Name != want[i].Name || s.Done != want[i].Done || s.Error != want[i].Error || !slices.Equal(s.Current, want[i].Current) {
+			t.Errorf("status %d = %+v, want %+v", i, s, want[i])
+		}
+	}
+
+	// the resource name comes from the API check
+	if slices.Contains(resolver.queried, "example.com") {
+		t.Errorf("resolver queried %q", resolver.queried)
+	}
+
+	pending := cs.Pending()
+	if len(pending) != 2 || pending[0].Name != "shop.example.com" || pending[1].Name != "old.example.com" {
+		t.Errorf("Pending() = %+v", pending)
+	}
+}
+
+func TestDNSChangeSetCheckIPv6(t *testing.T) {
+	cs, err := NewDNSChangeSet(&Resource{Name: "example.com", ServiceIP: "2001:db8::1"}, []Alias{{Name: "www.example.com"}}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resolver := &fakeResolver{addrs: map[string][]string{
+		"example.com":     {"2001:db8:0:0::1"},
+		"www.example.com": {"2001:db8::1", "198.51.100.1"},
+	}}
+
+	// the API check only reports A records, so it cannot tell whether the AAAA record is in place
+	cs.Check(context.Background(), &DnsCheck{A: []string{"198.51.100.1"}}, resolver)
+
+	if s := cs.Status[0]; !s.Done || !slices.Equal(s.Current, []string{"2001:db8::1"}) {
+		t.Errorf("status of the resource name = %+v, want done", s)
+	}
+	if s := cs.Status[1]; s.Done {
+		t.Errorf("status of a name with a stale A record = %+v, want pending", s)
+	}
+	if !slices.Equal(resolver.queried, []string{"example.com", "www.example.com"}) {
+		t.Errorf("resolver queried %q", resolver.queried)
+	}
+}
+
+func TestDNSChangeSetBIND(t *testing.T) {
+	cs, err := NewDNSChangeSet(&Resource{Name: "example.com", ServiceIP: "203.0.113.1"},
+		[]Alias{{Name: "www.example.com"}, {Name: "*.example.com"}, {Name: "example.org"}, {Name: "notexample.com"}}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `$ORIGIN example.com.
+@	300	IN	A	203.0.113.1
+www	300	IN	A	203.0.113.1
+*	300	IN	A	203.0.113.1
+example.org.	300	IN	A	203.0.113.1
+notexample.com.	300	IN	A	203.0.113.1
+`
+	if got := cs.BIND(" Example.COM. "); got != want {
+		t.Errorf("BIND() =\n%s\nwant\n%s", got, want)
+	}
+
+	want = `example.com.	300	IN	A	203.0.113.1
+www.example.com.	300	IN	A	203.0.113.1
+*.example.com.	300	IN	A	203.0.113.1
+example.org.	300	IN	A	203.0.113.1
+notexample.com.	300	IN	A	203.0.113.1
+`
+	if got := cs.BIND(""); got != want {
+		t.Errorf("BIND() without origin =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestGenerateDNSChangesAllPages(t *testing.T) {
+	aliases := &fakeAliases{}
+	for i := range listPageSize {
+		aliases.existing = append(aliases.existing, Alias{ID: int64(i + 1), Name: fmt.Sprintf("a%d.example.com", i)})
+	}
+	aliases.existing = append(aliases.existing, Alias{Name: "last.example.com"})
+
+	resources := &dnsResources{
+		fakeResources: &fakeResources{resource: Resource{Name: "example.com", ServiceIP: "203.0.113.1"}},
+		dnsCheck:      DnsCheck{A: []string{"203.0.113.1"}},
+	}
+	c := &Client{Resources: resources, Aliases: aliases}
+
+	cs, err := GenerateDNSChanges(context.Background(), c, 1, &fakeResolver{addrs: map[string][]string{"last.example.com": {"198.51.100.1"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cs.Records) != listPageSize+2 || aliases.listCalls != 2 {
+		t.Fatalf("GenerateDNSChanges() = %d records in %d list calls, want %d in 2", len(cs.Records), aliases.listCalls, listPageSize+2)
+	}
+	if !cs.Status[0].Done {
+		t.Errorf("status of the resource name = %+v, want done", cs.Status[0])
+	}
+	if last := cs.Status[len(cs.Status)-1]; last.Name != "last.example.com" || last.Done {
+		t.Errorf("status of the last alias = %+v, want pending", last)
+	}
+}
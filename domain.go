@@ -0,0 +1,171 @@
+package edgecenterprotection_go
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+const (
+	maxDomainNameLength  = 253
+	maxDomainLabelLength = 63
+
+	// wildcardPrefix is the leading wildcard label of alias names
+	wildcardPrefix = "*."
+
+	// acePrefix marks labels encoded with punycode
+	acePrefix = "xn--"
+)
+
+// domainDots replaces the Unicode full stops mapped to "." by IDNA
+var domainDots = strings.NewReplacer("。", ".", "．", ".", "｡", ".")
+
+// NormalizeDomainName converts a domain name to its canonical ASCII form: lowercase, without a trailing dot,
+// with internationalized labels encoded in punycode. Names are mapped and validated with the UTS #46 lookup
+// profile of IDNA, so full-width characters are folded and Unicode is normalized to NFC first.
+// A leading "*" label is accepted as a wildcard.
+func NormalizeDomainName(name string) (string, error) {
+	return normalizeDomainName("Name", name, true)
+}
+
+// DomainToUnicode converts punycode labels of a domain name to Unicode, e.g. "xn--e1afmkfd.xn--p1ai" to "пример.рф"
+func DomainToUnicode(name string) (string, error) {
+	ascii, err := NormalizeDomainName(name)
+	if err != nil {
+		return "", err
+	}
+
+	host, wildcard := strings.CutPrefix(ascii, wildcardPrefix)
+
+	unicodeName, err := idna.Lookup.ToUnicode(host)
+	if err != nil {
+		return "", NewArgError("Name", fmt.Sprintf("%q is not a valid domain name: %v", name, err))
+	}
+
+	if wildcard {
+		unicodeName = wildcardPrefix + unicodeName
+	}
+
+	return unicodeName, nil
+}
+
+// SameDomainName reports whether two domain names are equal after normalization, e.g. in Unicode and punycode form
+func SameDomainName(a, b string) bool {
+	na, err := NormalizeDomainName(a)
+	if err != nil {
+		return false
+	}
+
+	nb, err := NormalizeDomainName(b)
+	if err != nil {
+		return false
+	}
+
+	return na == nb
+}
+
+// GetResourceByName returns DDoS resource by its domain name given in either Unicode or punycode form
+func GetResourceByName(ctx context.Context, s ResourcesService, name string) (*Resource, *Response, error) {
+	if s == nil {
+		return nil, nil, NewArgError("s", "cannot be nil")
+	}
+
+	ascii, err := normalizeDomainName("name", name, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// the API may store the name in either form
+	candidates := []string{ascii}
+	if unicodeName, err := DomainToUnicode(ascii); err == nil && unicodeName != ascii {
+		candidates = append(candidates, unicodeName)
+	}
+
+	var resp *Response
+	for _, candidate := range candidates {
+		resources, err := listAll(ctx, func(ctx context.Context, limit, offset int) ([]Resource, error) {
+			var resources []Resource
+			resources, resp, err = s.List(ctx, &ResourceListOptions{Name: candidate, Limit: limit, Offset: offset})
+			return resources, err
+		})
+		if err != nil {
+			return nil, resp, err
+		}
+
+		var found []Resource
+		for _, r := range resources {
+			if SameDomainName(r.Name, ascii) {
+				found = append(found, r)
+			}
+		}
+
+		switch len(found) {
+		case 0:
+			continue
+		case 1:
+			return &found[0], resp, nil
+		default:
+			return nil, resp, ErrMultipleResourcesWithTheSameName
+		}
+	}
+
+	return nil, resp, ErrResourceDoesntExist
+}
+
+// normalizeDomainName normalizes and validates a domain name, reporting errors for the named argument
+func normalizeDomainName(arg, name string, allowWildcard bool) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", NewArgError(arg, "cannot be empty")
+	}
+
+	host, wildcard := strings.CutPrefix(name, wildcardPrefix)
+	if (wildcard && !allowWildcard) || strings.Contains(host, "*") {
+		return "", NewArgError(arg, fmt.Sprintf("%q may only contain a wildcard as the first label of an alias", name))
+	}
+
+	ascii, err := idna.Lookup.ToASCII(host)
+	if err != nil {
+		return "", NewArgError(arg, fmt.Sprintf("%q is not a valid domain name: %v", name, err))
+	}
+	ascii = strings.TrimSuffix(ascii, ".")
+
+	labels := strings.Split(ascii, ".")
+	if len(labels) < 2 {
+		return "", NewArgError(arg, fmt.Sprintf("%q must have at least two labels", name))
+	}
+
+	// idna decodes punycode labels to their ASCII content, e.g. "xn--abc-" to "abc", instead of rejecting them
+	inputLabels := strings.Split(strings.TrimSuffix(domainDots.Replace(host), "."), ".")
+	for i, label := range inputLabels {
+		label = strings.ToLower(label)
+		if strings.HasPrefix(label, acePrefix) && (i >= len(labels) || labels[i] != label) {
+			return "", NewArgError(arg, fmt.Sprintf("label %q of %q is not valid punycode", label, name))
+		}
+	}
+
+	for _, label := range labels {
+		if label == "" {
+			return "", NewArgError(arg, fmt.Sprintf("%q cannot have empty labels", name))
+		}
+		if len(label) > maxDomainLabelLength {
+			return "", NewArgError(arg, fmt.Sprintf("label %q of %q is longer than %d characters", label, name, maxDomainLabelLength))
+		}
+	}
+
+	if tld := labels[len(labels)-1]; strings.Trim(tld, "0123456789") == "" {
+		return "", NewArgError(arg, fmt.Sprintf("%q cannot have a numeric top-level label", name))
+	}
+
+	if wildcard {
+		ascii = wildcardPrefix + ascii
+	}
+
+	if len(ascii) > maxDomainNameLength {
+		return "", NewArgError(arg, fmt.Sprintf("%q is longer than %d characters in punycode form", name, maxDomainNameLength))
+	}
+
+	return ascii, nil
+}
@@ -0,0 +1,177 @@
+package edgecenterprotection_go
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestNormalizeDomainName(t *testing.T) {
+	tests := []struct {
+		in, want string
+		wantErr  bool
+	}{
+		{in: "пример.рф", want: "xn--e1afmkfd.xn--p1ai"},
+		{in: "ПРИМЕР.РФ.", want: "xn--e1afmkfd.xn--p1ai"},
+		{in: "xn--e1afmkfd.xn--p1ai", want: "xn--e1afmkfd.xn--p1ai"},
+		{in: "XN--E1AFMKFD.рф", want: "xn--e1afmkfd.xn--p1ai"},
+		{in: "*.пример.рф", want: "*.xn--e1afmkfd.xn--p1ai"},
+		{in: "президент.рф", want: "xn--d1abbgf6aiiy.xn--p1ai"},
+		{in: "пример。рф", want: "xn--e1afmkfd.xn--p1ai"},
+		{in: "почемужеонинеговорятпорусски.рф", want: "xn--b1abfaaepdrnnbgefbadotcwatmq2g4l.xn--p1ai"},
+		{in: "ＥＸＡＭＰＬＥ.com", want: "example.com"},
+		{in: "ｗｗｗ．ｅｘａｍｐｌｅ．ｃｏｍ", want: "www.example.com"},
+		{in: "faß.de", want: "xn--fa-hia.de"},
+		{in: " Example.COM ", want: "example.com"},
+		{in: "a.*.example.com", wantErr: true},
+		{in: "рф", wantErr: true},
+		{in: "xn--zz.рф", wantErr: true},
+		{in: "xn--abc-.com", wantErr: true},
+		{in: "ab--c.example.com", wantErr: true},
+		{in: "-example.com", wantErr: true},
+		{in: "при мер.рф", wantErr: true},
+		{in: "example.123", wantErr: true},
+		{in: "a..example.com", wantErr: true},
+		{in: "ex_ample.com", wantErr: true},
+		{in: "*.example.com.*", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := NormalizeDomainName(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("NormalizeDomainName(%q) = %q, want error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("NormalizeDomainName(%q) = %q, %v, want %q", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestDomainToUnicode(t *testing.T) {
+	got, err := DomainToUnicode("xn--e1afmkfd.XN--P1AI")
+	if err != nil || got != "пример.рф" {
+		t.Errorf("DomainToUnicode() = %q, %v", got, err)
+	}
+
+	got, err = DomainToUnicode("*.xn--e1afmkfd.xn--p1ai")
+	if err != nil || got != "*.пример.рф" {
+		t.Errorf("DomainToUnicode() of a wildcard = %q, %v", got, err)
+	}
+
+	if !SameDomainName("Пример.рф.", "xn--e1afmkfd.xn--p1ai") {
+		t.Error("SameDomainName() = false for Unicode and punycode spellings")
+	}
+}
+
+func TestNormalizeDomainNameNFC(t *testing.T) {
+	// "й" precomposed as U+0439 and decomposed as U+0438 U+0306
+	precomposed := "\u0439од.рф"
+	decomposed := "\u0438\u0306од.рф"
+
+	for _, name := range []string{precomposed, decomposed} {
+		got, err := NormalizeDomainName(name)
+		if err != nil || got != "xn--d1ajp.xn--p1ai" {
+			t.Errorf("NormalizeDomainName(%+q) = %q, %v, want xn--d1ajp.xn--p1ai", name, got, err)
+		}
+	}
+
+	if !SameDomainName(precomposed, decomposed) {
+		t.Error("SameDomainName() = false for precomposed and decomposed spellings")
+	}
+
+	if got, err := DomainToUnicode(decomposed); err != nil || got != precomposed {
+		t.Errorf("DomainToUnicode(%+q) = %+q, %v, want %+q", decomposed, got, err, precomposed)
+	}
+}
+
+func TestDomainNameRoundTrip(t *testing.T) {
+	for _, name := range []string{"пример.рф", "*.пример.рф", "bücher.example", "他们为什么不说中文.cn", "パフィーdeルンバ.jp", "example.com"} {
+		ascii, err := NormalizeDomainName(name)
+		if err != nil {
+			t.Errorf("NormalizeDomainName(%q): %v", name, err)
+			continue
+		}
+
+		back, err := DomainToUnicode(ascii)
+		if err != nil || back != name {
+			t.Errorf("DomainToUnicode(%q) = %q, %v, want %q", ascii, back, err, name)
+		}
+
+		if again, err := NormalizeDomainName(back); err != nil || again != ascii {
+			t.Errorf("NormalizeDomainName(%q) = %q, %v, want %q", back, again, err, ascii)
+		}
+	}
+}
+
+func TestGetResourceByName(t *testing.T) {
+	var queried []string
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("name")
+		queried = append(queried, name)
+		switch name {
+		case "пример.рф":
+			_, _ = w.Write([]byte(`{"count": 1, "results": [{"id": 7, "name": "пример.рф"}]}`))
+		case "dup.example.com":
+			_, _ = w.Write([]byte(`{"count": 2, "results": [{"id": 8, "name": "dup.example.com"}, {"id": 9, "name": "DUP.example.com."}]}`))
+		default:
+			_, _ = w.Write([]byte(`{"count": 0, "results": []}`))
+		}
+	}))
+
+	r, _, err := GetResourceByName(context.Background(), c.Resources, "XN--E1AFMKFD.xn--p1ai")
+	if err != nil || r.ID != 7 {
+		t.Fatalf("GetResourceByName() = %+v, %v", r, err)
+	}
+	if len(queried) != 2 || queried[0] != "xn--e1afmkfd.xn--p1ai" || queried[1] != "пример.рф" {
+		t.Errorf("queried names %q", queried)
+	}
+
+	// ASCII names have a single spelling
+	queried = nil
+	if _, _, err := GetResourceByName(context.Background(), c.Resources, "missing.example.com"); !errors.Is(err, ErrResourceDoesntExist) {
+		t.Errorf("GetResourceByName() of a missing resource = %v", err)
+	}
+	if len(queried) != 1 {
+		t.Errorf("queried names %q, want a single query", queried)
+	}
+
+	if _, _, err := GetResourceByName(context.Background(), c.Resources, "dup.example.com"); !errors.Is(err, ErrMultipleResourcesWithTheSameName) {
+		t.Errorf("GetResourceByName() of a duplicate name = %v", err)
+	}
+
+	if _, _, err := GetResourceByName(context.Background(), c.Resources, "missing.рф"); !errors.Is(err, ErrResourceDoesntExist) {
+		t.Errorf("GetResourceByName() of a missing resource = %v", err)
+	}
+}
+
+func TestGetResourceByNameAllPages(t *testing.T) {
+	var offsets []string
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		offsets = append(offsets, r.URL.Query().Get("offset"))
+
+		// the API matches names by substring, the exact match is on the second page
+		results := make([]map[string]any, 0, listPageSize)
+		if r.URL.Query().Get("offset") == "" {
+			for i := range listPageSize {
+				results = append(results, map[string]any{"id": i + 1, "name": fmt.Sprintf("a%d.example.com", i)})
+			}
+		} else {
+			results = append(results, map[string]any{"id": 500, "name": "example.com"})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"count": listPageSize + 1, "results": results})
+	}))
+
+	r, _, err := GetResourceByName(context.Background(), c.Resources, "example.com")
+	if err != nil || r.ID != 500 {
+		t.Fatalf("GetResourceByName() = %+v, %v", r, err)
+	}
+	if len(offsets) != 2 || offsets[1] != fmt.Sprint(listPageSize) {
+		t.Errorf("offsets = %q", offsets)
+	}
+}
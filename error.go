@@ -8,6 +8,10 @@ import (
 var (
 	ErrMultipleResourcesWithTheSameName = errors.New("there are multiple resources with the same name")
 	ErrResourceDoesntExist              = errors.New("resource doesn't exist")
+	ErrLocked                           = errors.New("lock file is held by another process")
+	ErrLockLost                         = errors.New("lock file was replaced by another process")
+	ErrUpdateConflict                   = errors.New("object was modified concurrently, giving up after retries")
+	ErrDuplicateHeader                  = errors.New("header with the same key already exists")
 )
 
 // ArgError is an error that represents an error with an input to edgecloud. It
@@ -30,3 +34,19 @@ func NewArgError(arg, reason string) *ArgError {
 func (e *ArgError) Error() string {
 	return fmt.Sprintf("%s is invalid because %s", e.arg, e.reason)
 }
+
+// isResponseStatus reports whether err is a ResponseError with one of the given status codes
+func isResponseStatus(err error, codes ...int) bool {
+	var respErr *ResponseError
+	if !errors.As(err, &respErr) || respErr.Response == nil {
+		return false
+	}
+
+	for _, code := range codes {
+		if respErr.Response.StatusCode == code {
+			return true
+		}
+	}
+
+	return false
+}
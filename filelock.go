@@ -0,0 +1,82 @@
+package edgecenterprotection_go
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+)
+
+// FileLock is an exclusive lock on a file guaranteeing a single running instance, e.g. of a ban sweeper.
+// The lock is held by an open file, so the operating system releases it when the process dies.
+// The file holds a random token identifying the owner, checked before the lock is used.
+type FileLock struct {
+	path  string
+	file  *os.File
+	token string
+}
+
+// AcquireFileLock locks the file at path, creating it if needed. ErrLocked is returned if another process holds
+// the lock. The file is left in place on Release, as removing it would let two processes lock different files.
+func AcquireFileLock(path string) (*FileLock, error) {
+	f, err := lockFile(path, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	l := &FileLock{path: path, file: f, token: hex.EncodeToString(b[:])}
+
+	if err := f.Truncate(0); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	if _, err := f.WriteAt([]byte(fmt.Sprintf("%s %d\n", l.token, os.Getpid())), 0); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// Check returns ErrLockLost if the file at the lock path is no longer the locked file holding the token of this lock,
+// e.g. because it was removed and locked again by another process
+func (l *FileLock) Check() error {
+	locked, err := l.file.Stat()
+	if err != nil {
+		return err
+	}
+
+	current, err := os.Stat(l.path)
+	if err != nil || !os.SameFile(locked, current) {
+		return ErrLockLost
+	}
+
+	data, err := os.ReadFile(l.path)
+	if err != nil {
+		return err
+	}
+
+	if token, _, _ := bytes.Cut(data, []byte(" ")); string(token) != l.token {
+		return ErrLockLost
+	}
+
+	return nil
+}
+
+// Release unlocks the file. ErrLockLost is returned if the lock was lost before, the file is unlocked anyway.
+func (l *FileLock) Release() error {
+	err := l.Check()
+	if cErr := l.file.Close(); err == nil {
+		err = cErr
+	}
+
+	return err
+}
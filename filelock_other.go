@@ -0,0 +1,13 @@
+//go:build !(darwin || dragonfly || freebsd || illumos || linux || netbsd || openbsd || windows)
+
+package edgecenterprotection_go
+
+import (
+	"errors"
+	"os"
+)
+
+// lockFile is not supported on platforms without flock
+func lockFile(path string, _ bool) (*os.File, error) {
+	return nil, &os.PathError{Op: "lock", Path: path, Err: errors.ErrUnsupported}
+}
@@ -0,0 +1,42 @@
+//go:build windows
+
+package edgecenterprotection_go
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"time"
+)
+
+// errorSharingViolation is returned by CreateFile if another handle denies the requested access
+const errorSharingViolation syscall.Errno = 32
+
+// lockRetryInterval is the wait between attempts of a waiting lockFile
+const lockRetryInterval = 10 * time.Millisecond
+
+// lockFile opens the file at path, creating it if needed, sharing it for reading only, so no other process can
+// open it for writing until it is closed. Without wait, ErrLocked is returned if another process holds the lock.
+func lockFile(path string, wait bool) (*os.File, error) {
+	name, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		h, err := syscall.CreateFile(name, syscall.GENERIC_READ|syscall.GENERIC_WRITE, syscall.FILE_SHARE_READ,
+			nil, syscall.OPEN_ALWAYS, syscall.FILE_ATTRIBUTE_NORMAL, 0)
+		if err == nil {
+			return os.NewFile(uintptr(h), path), nil
+		}
+
+		if !errors.Is(err, errorSharingViolation) {
+			return nil, &os.PathError{Op: "open", Path: path, Err: err}
+		}
+		if !wait {
+			return nil, ErrLocked
+		}
+
+		time.Sleep(lockRetryInterval)
+	}
+}
@@ -0,0 +1,250 @@
+package edgecenterprotection_go
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// MaxGeoIPListLength is the maximum length of the GeoIP list of DDoS resource
+	MaxGeoIPListLength = 255
+
+	// MaxGeoIPListCountries is the maximum number of countries fitting into the GeoIP list
+	MaxGeoIPListCountries = (MaxGeoIPListLength + 1) / 3
+)
+
+// CountrySet is a set of ISO 3166-1 alpha-2 country codes, as used in GeoIP lists of DDoS resources.
+// The zero value is an empty set ready to use.
+type CountrySet map[string]struct{}
+
+// regionPresets lists the countries of commonly used regions
+var regionPresets = map[string][]string{
+	// member states of the European Union
+	"EU": {"AT", "BE", "BG", "CY", "CZ", "DE", "DK", "EE", "ES", "FI", "FR", "GR", "HR", "HU", "IE", "IT", "LT",
+		"LU", "LV", "MT", "NL", "PL", "PT", "RO", "SE", "SI", "SK"},
+
+	// European Economic Area: the European Union, Iceland, Liechtenstein and Norway
+	"EEA": {"AT", "BE", "BG", "CY", "CZ", "DE", "DK", "EE", "ES", "FI", "FR", "GR", "HR", "HU", "IE", "IS", "IT",
+		"LI", "LT", "LU", "LV", "MT", "NL", "NO", "PL", "PT", "RO", "SE", "SI", "SK"},
+
+	// member and associate states of the Commonwealth of Independent States
+	"CIS": {"AM", "AZ", "BY", "KG", "KZ", "MD", "RU", "TJ", "TM", "UZ"},
+
+	// member states of the Eurasian Economic Union
+	"EAEU": {"AM", "BY", "KG", "KZ", "RU"},
+}
+
+// NewCountrySet returns a set of the given country codes. Codes are case-insensitive.
+func NewCountrySet(codes ...string) (CountrySet, error) {
+	s := make(CountrySet, len(codes))
+	if err := s.Add(codes...); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// ParseCountrySet parses a GeoIP list. Codes may be separated by commas, semicolons or whitespace.
+func ParseCountrySet(list string) (CountrySet, error) {
+	codes := strings.FieldsFunc(list, func(r rune) bool {
+		return r == ',' || r == ';' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	})
+
+	return NewCountrySet(codes...)
+}
+
+// RegionPreset returns the countries of a region: EU, EEA, CIS or EAEU
+func RegionPreset(name string) (CountrySet, error) {
+	codes, ok := regionPresets[strings.ToUpper(name)]
+	if !ok {
+		return nil, NewArgError("name", fmt.Sprintf("%q is not a known region, must be one of %s", name, strings.Join(RegionPresetNames(), ", ")))
+	}
+
+	return NewCountrySet(codes...)
+}
+
+// RegionPresetNames returns the names accepted by RegionPreset
+func RegionPresetNames() []string {
+	names := make([]string, 0, len(regionPresets))
+	for name := range regionPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// CountryName returns the English short name of the country with the given ISO 3166-1 alpha-2 code
+func CountryName(code string) (string, bool) {
+	name, ok := isoCountries[strings.ToUpper(code)]
+	return name, ok
+}
+
+// Add adds country codes to the set. No code is added if any of them is not an ISO 3166-1 alpha-2 code.
+func (s *CountrySet) Add(codes ...string) error {
+	normalized := make([]string, len(codes))
+	for i, code := range codes {
+		code = strings.ToUpper(strings.TrimSpace(code))
+		if _, ok := isoCountries[code]; !ok {
+			return NewArgError("GeoIPList", fmt.Sprintf("%q is not an ISO 3166-1 alpha-2 country code", code))
+		}
+		normalized[i] = code
+	}
+
+	if *s == nil {
+		*s = make(CountrySet, len(normalized))
+	}
+	for _, code := range normalized {
+		(*s)[code] = struct{}{}
+	}
+
+	return nil
+}
+
+// Remove removes country codes from the set
+func (s CountrySet) Remove(codes ...string) {
+	for _, code := range codes {
+		delete(s, strings.ToUpper(strings.TrimSpace(code)))
+	}
+}
+
+// Contains reports whether the set contains the country code
+func (s CountrySet) Contains(code string) bool {
+	_, ok := s[strings.ToUpper(strings.TrimSpace(code))]
+	return ok
+}
+
+// Len returns the number of countries in the set
+func (s CountrySet) Len() int {
+	return len(s)
+}
+
+// Codes returns the sorted country codes of the set
+func (s CountrySet) Codes() []string {
+	codes := make([]string, 0, len(s))
+	for code := range s {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	return codes
+}
+
+// Union returns a new set with the countries of both sets
+func (s CountrySet) Union(o CountrySet) CountrySet {
+	u := make(CountrySet, len(s)+len(o))
+	for code := range s {
+		u[code] = struct{}{}
+	}
+	for code := range o {
+		u[code] = struct{}{}
+	}
+
+	return u
+}
+
+// Intersect returns a new set with the countries present in both sets
+func (s CountrySet) Intersect(o CountrySet) CountrySet {
+	i := make(CountrySet)
+	for code := range s {
+		if _, ok := o[code]; ok {
+			i[code] = struct{}{}
+		}
+	}
+
+	return i
+}
+
+// Difference returns a new set with the countries of s that are not in o
+func (s CountrySet) Difference(o CountrySet) CountrySet {
+	d := make(CountrySet)
+	for code := range s {
+		if _, ok := o[code]; !ok {
+			d[code] = struct{}{}
+		}
+	}
+
+	return d
+}
+
+// Equal reports whether both sets contain the same countries
+func (s CountrySet) Equal(o CountrySet) bool {
+	if len(s) != len(o) {
+		return false
+	}
+
+	for code := range s {
+		if _, ok := o[code]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// String returns the GeoIP list in the API format: sorted codes separated by commas
+func (s CountrySet) String() string {
+	return strings.Join(s.Codes(), ",")
+}
+
+// EncodedLen returns the length of the GeoIP list in the API format
+func (s CountrySet) EncodedLen() int {
+	if len(s) == 0 {
+		return 0
+	}
+
+	return 3*len(s) - 1
+}
+
+// Fits reports whether the GeoIP list does not exceed MaxGeoIPListLength
+func (s CountrySet) Fits() bool {
+	return s.EncodedLen() <= MaxGeoIPListLength
+}
+
+// MarshalText returns the GeoIP list in the API format
+func (s CountrySet) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText parses a GeoIP list
+func (s *CountrySet) UnmarshalText(text []byte) error {
+	parsed, err := ParseCountrySet(string(text))
+	if err != nil {
+		return err
+	}
+
+	*s = parsed
+
+	return nil
+}
+
+// GeoIPCountries parses the GeoIP list of the resource
+func (r *Resource) GeoIPCountries() (CountrySet, error) {
+	return ParseCountrySet(r.GeoIPList)
+}
+
+// WithGeoIPListCheck is a client option that makes Resources.Create and Resources.Update refuse GeoIP lists
+// with codes other than ISO 3166-1 alpha-2 country codes. Without it only the length of the list is checked.
+func WithGeoIPListCheck() ClientOpt {
+	return func(c *Client) error {
+		c.geoIPListCheck = true
+		return nil
+	}
+}
+
+// validateGeoIPList checks the GeoIP list of resource create and update requests, including its country codes
+// if the client has the check enabled
+func (s *ResourcesServiceOp) validateGeoIPList(list string) error {
+	if len(list) > MaxGeoIPListLength {
+		return NewArgError("GeoIPList", fmt.Sprintf("length cannot exceed %d symbols", MaxGeoIPListLength))
+	}
+
+	if s.client == nil || !s.client.geoIPListCheck {
+		return nil
+	}
+
+	_, err := ParseCountrySet(list)
+
+	return err
+}
@@ -0,0 +1,175 @@
+package edgecenterprotection_go
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseCountrySet(t *testing.T) {
+	s, err := ParseCountrySet(" ru,by;kz\tDE\nfr, RU ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.String(); got != "BY,DE,FR,KZ,RU" {
+		t.Errorf("String() = %q", got)
+	}
+	if s.Len() != 5 || !s.Contains(" de ") || s.Contains("US") {
+		t.Errorf("set = %v", s.Codes())
+	}
+
+	empty, err := ParseCountrySet("")
+	if err != nil || empty.Len() != 0 || empty.String() != "" {
+		t.Errorf("ParseCountrySet(\"\") = %v, %v", empty, err)
+	}
+
+	var argErr *ArgError
+	if _, err := ParseCountrySet("RU,XX"); !errors.As(err, &argErr) || !strings.Contains(argErr.reason, `"XX"`) {
+		t.Errorf("ParseCountrySet with XX = %v, want ArgError naming XX", err)
+	}
+	if _, err := ParseCountrySet("EU"); err == nil {
+		t.Error("a region name is not a country code")
+	}
+}
+
+func TestCountrySetAdd(t *testing.T) {
+	// the zero value is usable
+	var s CountrySet
+	if err := s.Add("ru", "BY"); err != nil {
+		t.Fatal(err)
+	}
+	if s.String() != "BY,RU" {
+		t.Errorf("String() = %q", s.String())
+	}
+
+	// no code is added if one is invalid
+	if err := s.Add("KZ", "SU"); err == nil {
+		t.Error("Add with SU succeeded")
+	}
+	if s.Contains("KZ") {
+		t.Error("KZ added despite the invalid code")
+	}
+
+	s.Remove("by", "US")
+	if s.String() != "RU" {
+		t.Errorf("String() after Remove = %q", s.String())
+	}
+
+	var zero CountrySet
+	zero.Remove("RU")
+	if zero.Contains("RU") || zero.Len() != 0 || !zero.Fits() {
+		t.Error("zero set is not empty")
+	}
+}
+
+func TestCountrySetOperations(t *testing.T) {
+	eu, err := RegionPreset("eu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	eea, err := RegionPreset("EEA")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if eu.Len() != 27 || eea.Len() != 30 {
+		t.Errorf("EU has %d countries, EEA %d, want 27 and 30", eu.Len(), eea.Len())
+	}
+	if got := eea.Difference(eu).String(); got != "IS,LI,NO" {
+		t.Errorf("EEA - EU = %q", got)
+	}
+	if !eea.Intersect(eu).Equal(eu) || !eu.Union(eea).Equal(eea) {
+		t.Error("EU is not a subset of EEA")
+	}
+
+	cis, _ := RegionPreset("CIS")
+	eaeu, _ := RegionPreset("EAEU")
+	if got := cis.Intersect(eaeu).String(); got != eaeu.String() {
+		t.Errorf("CIS & EAEU = %q, want %q", got, eaeu.String())
+	}
+	if eu.Equal(eea) || eu.Intersect(cis).Len() != 0 {
+		t.Error("unexpected overlap between regions")
+	}
+
+	var argErr *ArgError
+	if _, err := RegionPreset("Atlantis"); !errors.As(err, &argErr) || !strings.Contains(argErr.reason, "CIS, EAEU, EEA, EU") {
+		t.Errorf("RegionPreset(Atlantis) = %v", err)
+	}
+	if !slices.Equal(RegionPresetNames(), []string{"CIS", "EAEU", "EEA", "EU"}) {
+		t.Errorf("RegionPresetNames() = %v", RegionPresetNames())
+	}
+}
+
+func TestCountrySetFits(t *testing.T) {
+	all := make(CountrySet)
+	for code := range isoCountries {
+		all[code] = struct{}{}
+	}
+
+	var s CountrySet
+	for _, code := range all.Codes() {
+		if err := s.Add(code); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := len(s.String()); got != s.EncodedLen() {
+			t.Fatalf("EncodedLen() = %d, len(String()) = %d", s.EncodedLen(), got)
+		}
+		if want := s.Len() <= MaxGeoIPListCountries; s.Fits() != want {
+			t.Fatalf("Fits() with %d countries = %v, want %v", s.Len(), s.Fits(), want)
+		}
+	}
+}
+
+func TestCountrySetText(t *testing.T) {
+	var v struct {
+		Countries CountrySet `json:"countries"`
+	}
+	if err := json.Unmarshal([]byte(`{"countries":"de,at"}`), &v); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"countries":"AT,DE"}` {
+		t.Errorf("Marshal() = %s", data)
+	}
+
+	if err := json.Unmarshal([]byte(`{"countries":"DE,ZZ"}`), &v); err == nil {
+		t.Error("expected error for ZZ")
+	}
+
+	r := Resource{GeoIPList: "ru,by"}
+	if countries, err := r.GeoIPCountries(); err != nil || countries.String() != "BY,RU" {
+		t.Errorf("GeoIPCountries() = %v, %v", countries, err)
+	}
+}
+
+func TestValidateGeoIPList(t *testing.T) {
+	s := &ResourcesServiceOp{client: &Client{}}
+
+	// without the check, only the length is validated
+	if err := s.ValidateResourceCreate(ResourceCreateRequest{Name: "example.com", GeoIPList: "RU,XX"}); err != nil {
+		t.Errorf("ValidateResourceCreate() = %v", err)
+	}
+	long := strings.Repeat("RU,", 86)
+	if err := s.ValidateResourceUpdate(ResourceUpdateRequest{GeoIPList: &long}); err == nil {
+		t.Errorf("ValidateResourceUpdate() with %d symbols succeeded", len(long))
+	}
+
+	if err := WithGeoIPListCheck()(s.client); err != nil {
+		t.Fatal(err)
+	}
+
+	var argErr *ArgError
+	if err := s.ValidateResourceCreate(ResourceCreateRequest{Name: "example.com", GeoIPList: "RU,XX"}); !errors.As(err, &argErr) || argErr.arg != "GeoIPList" {
+		t.Errorf("ValidateResourceCreate() = %v, want GeoIPList ArgError", err)
+	}
+	if err := s.ValidateResourceUpdate(ResourceUpdateRequest{GeoIPList: PtrTo("ru, by")}); err != nil {
+		t.Errorf("ValidateResourceUpdate() = %v", err)
+	}
+}
@@ -0,0 +1,267 @@
+package edgecenterprotection_go
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Keywords of Content-Security-Policy source lists
+const (
+	CSPSelf          = "'self'"
+	CSPNone          = "'none'"
+	CSPUnsafeInline  = "'unsafe-inline'"
+	CSPUnsafeEval    = "'unsafe-eval'"
+	CSPStrictDynamic = "'strict-dynamic'"
+)
+
+// hstsPreloadMinAge is the minimum max-age accepted by the HSTS preload list
+const hstsPreloadMinAge = 365 * 24 * time.Hour
+
+// HSTS builds the value of the Strict-Transport-Security header
+type HSTS struct {
+	MaxAge            time.Duration
+	IncludeSubDomains bool
+	Preload           bool
+}
+
+// String returns the header value, e.g. "max-age=31536000; includeSubDomains"
+func (h HSTS) String() string {
+	v := "max-age=" + strconv.FormatInt(int64(h.MaxAge/time.Second), 10)
+	if h.IncludeSubDomains {
+		v += "; includeSubDomains"
+	}
+	if h.Preload {
+		v += "; preload"
+	}
+
+	return v
+}
+
+// Validate checks the policy, preload requires includeSubDomains and max-age of at least one year
+func (h HSTS) Validate() error {
+	if h.MaxAge < 0 {
+		return NewArgError("MaxAge", "cannot be negative")
+	}
+
+	if h.Preload && (!h.IncludeSubDomains || h.MaxAge < hstsPreloadMinAge) {
+		return NewArgError("Preload", "requires IncludeSubDomains and MaxAge of at least one year")
+	}
+
+	return nil
+}
+
+// CSP builds the value of the Content-Security-Policy header. Directives keep the order they were added in.
+type CSP struct {
+	directives []cspDirective
+}
+
+type cspDirective struct {
+	name    string
+	sources []string
+}
+
+// NewCSP returns an empty policy
+func NewCSP() *CSP {
+	return &CSP{}
+}
+
+// Add appends sources to the directive, adding the directive if the policy has none with that name
+func (c *CSP) Add(directive string, sources ...string) *CSP {
+	directive = strings.ToLower(strings.TrimSpace(directive))
+	for i := range c.directives {
+		if c.directives[i].name == directive {
+			c.directives[i].sources = append(c.directives[i].sources, sources...)
+			return c
+		}
+	}
+
+	c.directives = append(c.directives, cspDirective{name: directive, sources: sources})
+
+	return c
+}
+
+// Set replaces the sources of the directive
+func (c *CSP) Set(directive string, sources ...string) *CSP {
+	c.Remove(directive)
+	return c.Add(directive, sources...)
+}
+
+// Remove removes the directive from the policy
+func (c *CSP) Remove(directive string) *CSP {
+	directive = strings.ToLower(strings.TrimSpace(directive))
+	for i := range c.directives {
+		if c.directives[i].name == directive {
+			c.directives = append(c.directives[:i], c.directives[i+1:]...)
+			break
+		}
+	}
+
+	return c
+}
+
+// String returns the header value, e.g. "default-src 'self'; object-src 'none'"
+func (c *CSP) String() string {
+	parts := make([]string, len(c.directives))
+	for i, d := range c.directives {
+		parts[i] = strings.Join(append([]string{d.name}, d.sources...), " ")
+	}
+
+	return strings.Join(parts, "; ")
+}
+
+// PermissionsPolicyDisable returns a Permissions-Policy value disabling the features, e.g. "camera=(), microphone=()"
+func PermissionsPolicyDisable(features ...string) string {
+	parts := make([]string, len(features))
+	for i, f := range features {
+		parts[i] = f + "=()"
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// HeaderPreset is a named set of headers applied to DDoS resource with ApplyHeaderPreset
+type HeaderPreset struct {
+	Name    string
+	Headers []HeaderCreateRequest
+}
+
+// HeaderPresetOptions override the defaults of a preset. Empty fields keep the preset values.
+type HeaderPresetOptions struct {
+	HSTS              *HSTS
+	CSP               *CSP
+	FrameOptions      string
+	ReferrerPolicy    string
+	PermissionsPolicy string
+
+	// Omit lists headers of the preset that must not be applied
+	Omit []string
+
+	// Extra lists headers added to the preset, replacing preset headers with the same key
+	Extra []HeaderCreateRequest
+}
+
+// StrictHeaderPreset returns a preset for sites served only over HTTPS from their own origin: long HSTS with
+// preload, no framing, a same-origin CSP, no referrer and disabled sensitive browser features
+func StrictHeaderPreset(opts *HeaderPresetOptions) (HeaderPreset, error) {
+	return buildHeaderPreset("strict", headerPresetDefaults{
+		hsts:           HSTS{MaxAge: 2 * hstsPreloadMinAge, IncludeSubDomains: true, Preload: true},
+		csp:            NewCSP().Add("default-src", CSPSelf).Add("object-src", CSPNone).Add("base-uri", CSPSelf).Add("frame-ancestors", CSPNone),
+		frameOptions:   "DENY",
+		referrerPolicy: "no-referrer",
+		permissionsPolicy: PermissionsPolicyDisable("accelerometer", "camera", "geolocation", "gyroscope",
+			"magnetometer", "microphone", "payment", "usb"),
+	}, opts)
+}
+
+// BaselineHeaderPreset returns a preset that is safe to apply to most sites: HSTS without subdomains, same-origin
+// framing only and no restrictions on the sources of scripts and styles
+func BaselineHeaderPreset(opts *HeaderPresetOptions) (HeaderPreset, error) {
+	return buildHeaderPreset("baseline", headerPresetDefaults{
+		hsts:              HSTS{MaxAge: hstsPreloadMinAge},
+		csp:               NewCSP().Add("frame-ancestors", CSPSelf),
+		frameOptions:      "SAMEORIGIN",
+		referrerPolicy:    "strict-origin-when-cross-origin",
+		permissionsPolicy: PermissionsPolicyDisable("camera", "geolocation", "microphone"),
+	}, opts)
+}
+
+// HeaderPresetByName returns the strict or baseline preset
+func HeaderPresetByName(name string, opts *HeaderPresetOptions) (HeaderPreset, error) {
+	switch strings.ToLower(name) {
+	case "strict":
+		return StrictHeaderPreset(opts)
+	case "baseline":
+		return BaselineHeaderPreset(opts)
+	default:
+		return HeaderPreset{}, NewArgError("name", fmt.Sprintf("%q is not a known preset, must be strict or baseline", name))
+	}
+}
+
+// ApplyHeaderPreset merges the preset into the headers of DDoS resource: missing headers are created and headers
+// with a different value are updated, other headers are left intact. The result reports every change made.
+func ApplyHeaderPreset(ctx context.Context, s HeadersService, resourceID int64, preset HeaderPreset) (*HeaderSyncResult, *Response, error) {
+	return syncHeaders(ctx, s, resourceID, preset.Headers, false)
+}
+
+// DiffHeaderPreset reports the changes ApplyHeaderPreset would make to the headers of DDoS resource without
+// applying them
+func DiffHeaderPreset(ctx context.Context, s HeadersService, resourceID int64, preset HeaderPreset) ([]HeaderChange, *Response, error) {
+	existing, resp, err := s.List(ctx, resourceID)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return planHeaderChanges(existing, preset.Headers, false), resp, nil
+}
+
+type headerPresetDefaults struct {
+	hsts              HSTS
+	csp               *CSP
+	frameOptions      string
+	referrerPolicy    string
+	permissionsPolicy string
+}
+
+// buildHeaderPreset applies the options to the preset defaults and validates the resulting headers
+func buildHeaderPreset(name string, d headerPresetDefaults, opts *HeaderPresetOptions) (HeaderPreset, error) {
+	if opts == nil {
+		opts = &HeaderPresetOptions{}
+	}
+
+	if opts.HSTS != nil {
+		d.hsts = *opts.HSTS
+	}
+	if opts.CSP != nil {
+		d.csp = opts.CSP
+	}
+	if opts.FrameOptions != "" {
+		d.frameOptions = opts.FrameOptions
+	}
+	if opts.ReferrerPolicy != "" {
+		d.referrerPolicy = opts.ReferrerPolicy
+	}
+	if opts.PermissionsPolicy != "" {
+		d.permissionsPolicy = opts.PermissionsPolicy
+	}
+
+	if err := d.hsts.Validate(); err != nil {
+		return HeaderPreset{}, err
+	}
+
+	headers := []HeaderCreateRequest{
+		{Key: "Strict-Transport-Security", Value: d.hsts.String()},
+		{Key: "Content-Security-Policy", Value: d.csp.String()},
+		{Key: "X-Frame-Options", Value: d.frameOptions},
+		{Key: "X-Content-Type-Options", Value: "nosniff"},
+		{Key: "Referrer-Policy", Value: d.referrerPolicy},
+		{Key: "Permissions-Policy", Value: d.permissionsPolicy},
+	}
+
+	omit := make(map[string]bool, len(opts.Omit)+len(opts.Extra))
+	for _, key := range opts.Omit {
+		omit[http.CanonicalHeaderKey(key)] = true
+	}
+	for _, h := range opts.Extra {
+		omit[http.CanonicalHeaderKey(h.Key)] = true
+	}
+
+	preset := HeaderPreset{Name: name}
+	for _, h := range headers {
+		if !omit[http.CanonicalHeaderKey(h.Key)] && h.Value != "" {
+			preset.Headers = append(preset.Headers, h)
+		}
+	}
+	preset.Headers = append(preset.Headers, opts.Extra...)
+
+	for _, h := range preset.Headers {
+		if err := ValidateHeaderCreateRequest(h); err != nil {
+			return HeaderPreset{}, err
+		}
+	}
+
+	return preset, nil
+}
@@ -0,0 +1,223 @@
+package edgecenterprotection_go
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestHSTS(t *testing.T) {
+	year := 365 * 24 * time.Hour
+
+	tests := []struct {
+		name    string
+		h       HSTS
+		want    string
+		wantErr bool
+	}{
+		{"max-age only", HSTS{MaxAge: time.Hour}, "max-age=3600", false},
+		{"zero clears the policy", HSTS{}, "max-age=0", false},
+		{"subdomains", HSTS{MaxAge: year, IncludeSubDomains: true}, "max-age=31536000; includeSubDomains", false},
+		{"preload", HSTS{MaxAge: 2 * year, IncludeSubDomains: true, Preload: true}, "max-age=63072000; includeSubDomains; preload", false},
+		{"fraction of a second dropped", HSTS{MaxAge: 1500 * time.Millisecond}, "max-age=1", false},
+		{"negative", HSTS{MaxAge: -time.Second}, "", true},
+		{"preload without subdomains", HSTS{MaxAge: year, Preload: true}, "", true},
+		{"preload with short max-age", HSTS{MaxAge: year - time.Second, IncludeSubDomains: true, Preload: true}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.h.Validate()
+			if tt.wantErr {
+				var argErr *ArgError
+				if !errors.As(err, &argErr) {
+					t.Errorf("Validate() = %v, want ArgError", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Validate() = %v", err)
+			}
+			if got := tt.h.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCSP(t *testing.T) {
+	if got := NewCSP().String(); got != "" {
+		t.Errorf("empty policy = %q", got)
+	}
+
+	csp := NewCSP().
+		Add("default-src", CSPSelf).
+		Add("script-src", CSPSelf, "https://cdn.example.com").
+		Add(" Default-Src ", "https://static.example.com").
+		Add("upgrade-insecure-requests")
+	want := "default-src 'self' https://static.example.com; script-src 'self' https://cdn.example.com; upgrade-insecure-requests"
+	if got := csp.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	// Set keeps the other directives and moves the replaced one to the end
+	csp.Set("DEFAULT-SRC", CSPNone)
+	want = "script-src 'self' https://cdn.example.com; upgrade-insecure-requests; default-src 'none'"
+	if got := csp.String(); got != want {
+		t.Errorf("String() after Set = %q, want %q", got, want)
+	}
+
+	csp.Remove("upgrade-insecure-requests").Remove("img-src")
+	want = "script-src 'self' https://cdn.example.com; default-src 'none'"
+	if got := csp.String(); got != want {
+		t.Errorf("String() after Remove = %q, want %q", got, want)
+	}
+}
+
+func TestPermissionsPolicyDisable(t *testing.T) {
+	if got := PermissionsPolicyDisable("camera", "microphone"); got != "camera=(), microphone=()" {
+		t.Errorf("PermissionsPolicyDisable() = %q", got)
+	}
+	if got := PermissionsPolicyDisable(); got != "" {
+		t.Errorf("PermissionsPolicyDisable() = %q", got)
+	}
+}
+
+func TestHeaderPresets(t *testing.T) {
+	strict, err := StrictHeaderPreset(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []HeaderCreateRequest{
+		{Key: "Strict-Transport-Security", Value: "max-age=63072000; includeSubDomains; preload"},
+		{Key: "Content-Security-Policy", Value: "default-src 'self'; object-src 'none'; base-uri 'self'; frame-ancestors 'none'"},
+		{Key: "X-Frame-Options", Value: "DENY"},
+		{Key: "X-Content-Type-Options", Value: "nosniff"},
+		{Key: "Referrer-Policy", Value: "no-referrer"},
+		{Key: "Permissions-Policy", Value: "accelerometer=(), camera=(), geolocation=(), gyroscope=(), magnetometer=(), microphone=(), payment=(), usb=()"},
+	}
+	if strict.Name != "strict" || !slices.Equal(strict.Headers, want) {
+		t.Errorf("StrictHeaderPreset() = %+v", strict)
+	}
+
+	baseline, err := HeaderPresetByName("Baseline", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want = []HeaderCreateRequest{
+		{Key: "Strict-Transport-Security", Value: "max-age=31536000"},
+		{Key: "Content-Security-Policy", Value: "frame-ancestors 'self'"},
+		{Key: "X-Frame-Options", Value: "SAMEORIGIN"},
+		{Key: "X-Content-Type-Options", Value: "nosniff"},
+		{Key: "Referrer-Policy", Value: "strict-origin-when-cross-origin"},
+		{Key: "Permissions-Policy", Value: "camera=(), geolocation=(), microphone=()"},
+	}
+	if baseline.Name != "baseline" || !slices.Equal(baseline.Headers, want) {
+		t.Errorf("HeaderPresetByName(Baseline) = %+v", baseline)
+	}
+
+	var argErr *ArgError
+	if _, err := HeaderPresetByName("paranoid", nil); !errors.As(err, &argErr) || argErr.arg != "name" {
+		t.Errorf("HeaderPresetByName(paranoid) = %v, want name ArgError", err)
+	}
+}
+
+func TestHeaderPresetOptions(t *testing.T) {
+	preset, err := BaselineHeaderPreset(&HeaderPresetOptions{
+		HSTS:           &HSTS{MaxAge: time.Hour},
+		CSP:            NewCSP(),
+		ReferrerPolicy: "same-origin",
+		Omit:           []string{"permissions-policy"},
+		Extra: []HeaderCreateRequest{
+			{Key: "x-frame-options", Value: "DENY"},
+			{Key: "Cross-Origin-Opener-Policy", Value: "same-origin"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// an empty policy drops the header and extra headers replace the preset ones with the same key
+	want := []HeaderCreateRequest{
+		{Key: "Strict-Transport-Security", Value: "max-age=3600"},
+		{Key: "X-Content-Type-Options", Value: "nosniff"},
+		{Key: "Referrer-Policy", Value: "same-origin"},
+		{Key: "x-frame-options", Value: "DENY"},
+		{Key: "Cross-Origin-Opener-Policy", Value: "same-origin"},
+	}
+	if !slices.Equal(preset.Headers, want) {
+		t.Errorf("BaselineHeaderPreset() = %+v, want %+v", preset.Headers, want)
+	}
+
+	var argErr *ArgError
+	if _, err := StrictHeaderPreset(&HeaderPresetOptions{HSTS: &HSTS{MaxAge: time.Hour, Preload: true}}); !errors.As(err, &argErr) || argErr.arg != "Preload" {
+		t.Errorf("StrictHeaderPreset() with invalid HSTS = %v, want Preload ArgError", err)
+	}
+	if _, err := StrictHeaderPreset(&HeaderPresetOptions{ReferrerPolicy: "no-referrer\r\nSet-Cookie: a=1"}); !errors.As(err, &argErr) || argErr.arg != "Value" {
+		t.Errorf("StrictHeaderPreset() with CRLF = %v, want Value ArgError", err)
+	}
+	if _, err := StrictHeaderPreset(&HeaderPresetOptions{Extra: []HeaderCreateRequest{{Key: "Host", Value: "example.com"}}}); !errors.As(err, &argErr) || argErr.arg != "Key" {
+		t.Errorf("StrictHeaderPreset() with a denied header = %v, want Key ArgError", err)
+	}
+}
+
+func TestApplyAndDiffHeaderPreset(t *testing.T) {
+	var writes []string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			_, _ = w.Write([]byte(`[
+				{"id": 1, "header_key": "x-frame-options", "header_value": "DENY"},
+				{"id": 2, "header_key": "X-Content-Type-Options", "header_value": "nosniff"},
+				{"id": 3, "header_key": "X-Custom", "header_value": "1"}
+			]`))
+		case http.MethodPost:
+			writes = append(writes, r.Method+" "+r.URL.Path)
+			_, _ = w.Write([]byte(`{"id": 4, "header_key": "Referrer-Policy", "header_value": "same-origin"}`))
+		default:
+			writes = append(writes, r.Method+" "+r.URL.Path)
+			_, _ = w.Write([]byte(`{"id": 1, "header_key": "X-Frame-Options", "header_value": "SAMEORIGIN"}`))
+		}
+	})
+	c := newTestClient(t, handler)
+
+	preset := HeaderPreset{Name: "test", Headers: []HeaderCreateRequest{
+		{Key: "X-Frame-Options", Value: "SAMEORIGIN"},
+		{Key: "X-Content-Type-Options", Value: "nosniff"},
+		{Key: "Referrer-Policy", Value: "same-origin"},
+	}}
+
+	changes, _, err := DiffHeaderPreset(context.Background(), c.Headers, 1, preset)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// headers outside the preset are kept and matching ones are not touched
+	want := []HeaderChange{
+		{Action: HeaderUpdated, ID: 1, Key: "X-Frame-Options", Old: "DENY", New: "SAMEORIGIN"},
+		{Action: HeaderCreated, Key: "Referrer-Policy", New: "same-origin"},
+	}
+	if !slices.Equal(changes, want) {
+		t.Errorf("DiffHeaderPreset() = %+v, want %+v", changes, want)
+	}
+	if len(writes) != 0 {
+		t.Errorf("DiffHeaderPreset() sent %q", writes)
+	}
+
+	result, _, err := ApplyHeaderPreset(context.Background(), c.Headers, 1, preset)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(writes, []string{"PATCH /v2/resources/1/headers/1", "POST /v2/resources/1/headers"}) {
+		t.Errorf("ApplyHeaderPreset() sent %q", writes)
+	}
+	if len(result.Changes) != 2 || result.Changes[1].ID != 4 {
+		t.Errorf("ApplyHeaderPreset() = %+v", result.Changes)
+	}
+}
@@ -4,14 +4,41 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strings"
 )
 
 const (
 	// resourcesBasePathV2 base path for all resources requests
 	// additional path is used for specific requests
 	headersPathV2 = "headers"
+
+	// maxHeaderValueLength is the maximum length of a header value accepted by ValidateHeaderCreateRequest
+	maxHeaderValueLength = 4096
 )
 
+// deniedHeaders lists headers that cannot be set for DDoS resources with the reason, keyed by canonical name.
+// Hop-by-hop headers are consumed by the proxy itself and framing headers are managed by the edge, setting them
+// would break connections. Security-sensitive headers would leak credentials or spoof client identity.
+var deniedHeaders = map[string]string{
+	"Connection":          "is a hop-by-hop header",
+	"Keep-Alive":          "is a hop-by-hop header",
+	"Proxy-Connection":    "is a hop-by-hop header",
+	"Proxy-Authenticate":  "is a hop-by-hop header",
+	"Proxy-Authorization": "is a hop-by-hop header",
+	"Te":                  "is a hop-by-hop header",
+	"Trailer":             "is a hop-by-hop header",
+	"Transfer-Encoding":   "is a hop-by-hop header",
+	"Upgrade":             "is a hop-by-hop header",
+	"Content-Length":      "is managed by the edge",
+	"Host":                "is managed by the edge",
+	"Authorization":       "would expose credentials",
+	"Cookie":              "would expose credentials",
+	"Set-Cookie":          "would set cookies for every client",
+	"X-Forwarded-For":     "would spoof the client address",
+	"X-Real-Ip":           "would spoof the client address",
+	"Forwarded":           "would spoof the client address",
+}
+
 // HeadersService is an interface for managing headers for DDoS resources with the Edgecenter protection API.
 // See: https://apidocs.edgecenter.ru/protection#tag/headers
 type HeadersService interface {
@@ -78,14 +105,29 @@ func (s *HeadersServiceOp) Get(ctx context.Context, resourceID int64, headerID i
 	return header, resp, err
 }
 
-// Add header for DDoS resource
+// Add header for DDoS resource. With WithDuplicateHeaderCheck, fails with ErrDuplicateHeader if the resource
+// already has a header with the same key.
 func (s *HeadersServiceOp) Create(ctx context.Context, resourceID int64, reqBody *HeaderCreateRequest) (*Header, *Response, error) {
-	path := fmt.Sprintf("%s/%d/%s", resourcesBasePathV2, resourceID, headersPathV2)
-
 	if reqBody == nil {
 		return nil, nil, NewArgError("reqBody", "cannot be nil")
 	}
 
+	if err := ValidateHeaderCreateRequest(*reqBody); err != nil {
+		return nil, nil, err
+	}
+
+	if s.client.duplicateHeaderCheck {
+		if resp, err := s.checkDuplicate(ctx, resourceID, 0, reqBody.Key); err != nil {
+			return nil, resp, err
+		}
+	}
+
+	return s.create(ctx, resourceID, reqBody)
+}
+
+func (s *HeadersServiceOp) create(ctx context.Context, resourceID int64, reqBody *HeaderCreateRequest) (*Header, *Response, error) {
+	path := fmt.Sprintf("%s/%d/%s", resourcesBasePathV2, resourceID, headersPathV2)
+
 	req, err := s.client.NewRequest(ctx, http.MethodPost, path, reqBody)
 	if err != nil {
 		return nil, nil, err
@@ -113,18 +155,40 @@ func (s *HeadersServiceOp) Delete(ctx context.Context, resourceID int64, headerI
 	return resp, err
 }
 
-// Update header for DDoS resource
+// Update header for DDoS resource. With WithDuplicateHeaderCheck, fails with ErrDuplicateHeader if another header
+// of the resource has the same key.
 func (s *HeadersServiceOp) Update(ctx context.Context, resourceID int64, headerID int64, reqBody *HeaderCreateRequest) (*Header, *Response, error) {
+	return s.checkedUpdate(ctx, resourceID, headerID, reqBody, "")
+}
+
+// checkedUpdate validates the request and updates the header, sending If-Match with ifMatch unless it is empty
+func (s *HeadersServiceOp) checkedUpdate(ctx context.Context, resourceID int64, headerID int64, reqBody *HeaderCreateRequest, ifMatch string) (*Header, *Response, error) {
 	if reqBody == nil {
 		return nil, nil, NewArgError("reqBody", "cannot be nil")
 	}
 
+	if err := ValidateHeaderCreateRequest(*reqBody); err != nil {
+		return nil, nil, err
+	}
+
+	if s.client.duplicateHeaderCheck {
+		if resp, err := s.checkDuplicate(ctx, resourceID, headerID, reqBody.Key); err != nil {
+			return nil, resp, err
+		}
+	}
+
+	return s.update(ctx, resourceID, headerID, reqBody, ifMatch)
+}
+
+// update updates the header without validation, sending If-Match with ifMatch unless it is empty
+func (s *HeadersServiceOp) update(ctx context.Context, resourceID int64, headerID int64, reqBody *HeaderCreateRequest, ifMatch string) (*Header, *Response, error) {
 	path := fmt.Sprintf("%s/%d/%s/%d", resourcesBasePathV2, resourceID, headersPathV2, headerID)
 
 	req, err := s.client.NewRequest(ctx, http.MethodPatch, path, reqBody)
 	if err != nil {
 		return nil, nil, err
 	}
+	setIfMatch(req, ifMatch)
 
 	header := new(Header)
 	resp, err := s.client.Do(ctx, req, header)
@@ -134,3 +198,73 @@ func (s *HeadersServiceOp) Update(ctx context.Context, resourceID int64, headerI
 
 	return header, resp, err
 }
+
+// ValidateHeaderCreateRequest checks create request data matches restrictions: the key must be an RFC 9110 token,
+// the value must not contain control characters, and the header must not be hop-by-hop or security-sensitive.
+func ValidateHeaderCreateRequest(r HeaderCreateRequest) error {
+	if r.Key == "" {
+		return NewArgError("Key", "cannot be empty")
+	}
+
+	for i := 0; i < len(r.Key); i++ {
+		if !isTokenChar(r.Key[i]) {
+			return NewArgError("Key", fmt.Sprintf("%q contains invalid character %q", r.Key, r.Key[i]))
+		}
+	}
+
+	if reason, ok := deniedHeaders[http.CanonicalHeaderKey(r.Key)]; ok {
+		return NewArgError("Key", fmt.Sprintf("%s cannot be set because it %s", r.Key, reason))
+	}
+
+	if len(r.Value) > maxHeaderValueLength {
+		return NewArgError("Value", fmt.Sprintf("length cannot exceed %d symbols", maxHeaderValueLength))
+	}
+
+	for i := 0; i < len(r.Value); i++ {
+		if c := r.Value[i]; (c < ' ' && c != '\t') || c == 0x7f {
+			return NewArgError("Value", fmt.Sprintf("contains control character %q", c))
+		}
+	}
+
+	if strings.TrimSpace(r.Value) != r.Value {
+		return NewArgError("Value", "cannot have leading or trailing whitespace")
+	}
+
+	return nil
+}
+
+// WithDuplicateHeaderCheck is a client option that makes Headers.Create and Headers.Update refuse a header
+// with the same key as another header of the resource. Every checked request lists the headers first.
+// SyncHeaders does not need it, as it updates headers with an existing key in place.
+func WithDuplicateHeaderCheck() ClientOpt {
+	return func(c *Client) error {
+		c.duplicateHeaderCheck = true
+		return nil
+	}
+}
+
+// checkDuplicate lists the headers of DDoS resource and fails if a header other than headerID has the same key
+func (s *HeadersServiceOp) checkDuplicate(ctx context.Context, resourceID int64, headerID int64, key string) (*Response, error) {
+	headers, resp, err := s.List(ctx, resourceID)
+	if err != nil {
+		return resp, err
+	}
+
+	for _, h := range headers {
+		if h.ID != headerID && strings.EqualFold(h.Key, key) {
+			return resp, fmt.Errorf("%w: %s (id %d)", ErrDuplicateHeader, h.Key, h.ID)
+		}
+	}
+
+	return resp, nil
+}
+
+// isTokenChar reports whether c is a tchar of RFC 9110 section 5.6.2
+func isTokenChar(c byte) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		return true
+	default:
+		return strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0
+	}
+}
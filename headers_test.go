@@ -0,0 +1,198 @@
+package edgecenterprotection_go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestHeadersCreateDuplicateCheck(t *testing.T) {
+	var lists int
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			lists++
+			_, _ = w.Write([]byte(`[{"id": 1, "header_key": "X-Frame-Options", "header_value": "DENY"}]`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id": 2, "header_key": "x-frame-options", "header_value": "SAMEORIGIN"}`))
+	})
+	req := &HeaderCreateRequest{Key: "x-frame-options", Value: "SAMEORIGIN"}
+
+	c := newTestClient(t, handler)
+	if _, _, err := c.Headers.Create(context.Background(), 1, req); err != nil || lists != 0 {
+		t.Errorf("Create() without the check = %v after %d lists, want no error and no list", err, lists)
+	}
+
+	c = newTestClient(t, handler)
+	if err := WithDuplicateHeaderCheck()(c); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := c.Headers.Create(context.Background(), 1, req); !errors.Is(err, ErrDuplicateHeader) {
+		t.Errorf("Create() with the check = %v, want ErrDuplicateHeader", err)
+	}
+}
+
+func TestValidateHeaderCreateRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		r       HeaderCreateRequest
+		wantArg string
+	}{
+		{"valid", HeaderCreateRequest{Key: "X-Frame-Options", Value: "DENY"}, ""},
+		{"token symbols", HeaderCreateRequest{Key: "X-Custom_Header.v1!#$%&'*+^`|~", Value: "a"}, ""},
+		{"empty value", HeaderCreateRequest{Key: "X-Empty"}, ""},
+		{"tab inside value", HeaderCreateRequest{Key: "X-Tab", Value: "a\tb"}, ""},
+		{"UTF-8 value", HeaderCreateRequest{Key: "X-Note", Value: "привет"}, ""},
+		{"empty key", HeaderCreateRequest{Value: "a"}, "Key"},
+		{"space in key", HeaderCreateRequest{Key: "X Frame", Value: "a"}, "Key"},
+		{"colon in key", HeaderCreateRequest{Key: "X-Frame:", Value: "a"}, "Key"},
+		{"separator in key", HeaderCreateRequest{Key: "X-(Frame)", Value: "a"}, "Key"},
+		{"non-ASCII key", HeaderCreateRequest{Key: "X-Заголовок", Value: "a"}, "Key"},
+		{"CRLF injection", HeaderCreateRequest{Key: "X-Test", Value: "a\r\nSet-Cookie: session=1"}, "Value"},
+		{"LF injection", HeaderCreateRequest{Key: "X-Test", Value: "a\nb"}, "Value"},
+		{"NUL", HeaderCreateRequest{Key: "X-Test", Value: "a\x00b"}, "Value"},
+		{"DEL", HeaderCreateRequest{Key: "X-Test", Value: "a\x7fb"}, "Value"},
+		{"leading space", HeaderCreateRequest{Key: "X-Test", Value: " a"}, "Value"},
+		{"too long", HeaderCreateRequest{Key: "X-Test", Value: strings.Repeat("a", maxHeaderValueLength+1)}, "Value"},
+		{"hop-by-hop", HeaderCreateRequest{Key: "connection", Value: "close"}, "Key"},
+		{"framing", HeaderCreateRequest{Key: "Transfer-Encoding", Value: "chunked"}, "Key"},
+		{"credentials", HeaderCreateRequest{Key: "AUTHORIZATION", Value: "Bearer x"}, "Key"},
+		{"client address", HeaderCreateRequest{Key: "x-real-ip", Value: "192.0.2.1"}, "Key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateHeaderCreateRequest(tt.r)
+			if tt.wantArg == "" {
+				if err != nil {
+					t.Errorf("ValidateHeaderCreateRequest() = %v", err)
+				}
+				return
+			}
+
+			var argErr *ArgError
+			if !errors.As(err, &argErr) || argErr.arg != tt.wantArg {
+				t.Errorf("ValidateHeaderCreateRequest() = %v, want %s ArgError", err, tt.wantArg)
+			}
+		})
+	}
+}
+
+func TestPlanHeaderChanges(t *testing.T) {
+	existing := []Header{
+		{ID: 1, Key: "X-Frame-Options", Value: "DENY"},
+		{ID: 2, Key: "x-frame-options", Value: "SAMEORIGIN"},
+		{ID: 3, Key: "X-Old", Value: "1"},
+		{ID: 4, Key: "referrer-policy", Value: "no-referrer"},
+	}
+	desired := []HeaderCreateRequest{
+		{Key: "X-Frame-Options", Value: "SAMEORIGIN"},
+		{Key: "Referrer-Policy", Value: "no-referrer"},
+		{Key: "X-New", Value: "yes"},
+	}
+
+	changes := func(prune bool) []string {
+		var out []string
+		for _, c := range planHeaderChanges(existing, desired, prune) {
+			out = append(out, fmt.Sprintf("%d %s", c.ID, c))
+		}
+		return out
+	}
+
+	want := []string{
+		"2 delete x-frame-options: SAMEORIGIN",
+		"3 delete X-Old: 1",
+		"1 update X-Frame-Options: DENY -> SAMEORIGIN",
+		"0 create X-New: yes",
+	}
+	if got := changes(true); !slices.Equal(got, want) {
+		t.Errorf("changes with prune = %q, want %q", got, want)
+	}
+
+	// without prune only duplicates of desired keys are deleted
+	want = slices.Delete(want, 1, 2)
+	if got := changes(false); !slices.Equal(got, want) {
+		t.Errorf("changes without prune = %q, want %q", got, want)
+	}
+
+	if got := planHeaderChanges(nil, nil, true); len(got) != 0 {
+		t.Errorf("changes of nothing = %v", got)
+	}
+}
+
+func TestSyncHeaders(t *testing.T) {
+	var requests []string
+	var failPost bool
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+
+		switch r.Method {
+		case http.MethodGet:
+			_, _ = w.Write([]byte(`[
+				{"id": 1, "header_key": "X-Frame-Options", "header_value": "DENY"},
+				{"id": 2, "header_key": "x-frame-options", "header_value": "SAMEORIGIN"},
+				{"id": 3, "header_key": "X-Old", "header_value": "1"}
+			]`))
+		case http.MethodPost:
+			if failPost {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			_, _ = w.Write([]byte(`{"id": 4, "header_key": "X-New", "header_value": "yes"}`))
+		case http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			_, _ = w.Write([]byte(`{"id": 1, "header_key": "X-Frame-Options", "header_value": "SAMEORIGIN"}`))
+		}
+	})
+
+	// the duplicate check would refuse the update while the duplicate header still exists
+	c := newTestClient(t, handler)
+	if err := WithDuplicateHeaderCheck()(c); err != nil {
+		t.Fatal(err)
+	}
+
+	desired := []HeaderCreateRequest{{Key: "X-Frame-Options", Value: "SAMEORIGIN"}, {Key: "X-New", Value: "yes"}}
+	result, _, err := SyncHeaders(context.Background(), c.Headers, 1, desired)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"GET /v2/resources/1/headers",
+		"DELETE /v2/resources/1/headers/2",
+		"DELETE /v2/resources/1/headers/3",
+		"PATCH /v2/resources/1/headers/1",
+		"POST /v2/resources/1/headers",
+	}
+	if !slices.Equal(requests, want) {
+		t.Errorf("requests = %q, want %q", requests, want)
+	}
+	if len(result.Changes) != 4 || result.Changes[3].ID != 4 {
+		t.Errorf("changes = %+v", result.Changes)
+	}
+
+	// a failed change stops the sync and reports the changes made so far
+	failPost = true
+	result, _, err = SyncHeaders(context.Background(), c.Headers, 1, desired)
+	if err == nil || len(result.Changes) != 3 {
+		t.Errorf("SyncHeaders() = %+v, %v, want 3 changes and an error", result, err)
+	}
+
+	var argErr *ArgError
+	requests = nil
+	duplicate := []HeaderCreateRequest{{Key: "X-New", Value: "a"}, {Key: "x-new", Value: "b"}}
+	if _, _, err := SyncHeaders(context.Background(), c.Headers, 1, duplicate); !errors.As(err, &argErr) || argErr.arg != "desired" {
+		t.Errorf("SyncHeaders() with a duplicate key = %v, want desired ArgError", err)
+	}
+	if _, _, err := SyncHeaders(context.Background(), c.Headers, 1, []HeaderCreateRequest{{Key: "Host", Value: "a"}}); !errors.As(err, &argErr) {
+		t.Errorf("SyncHeaders() with a denied header = %v, want ArgError", err)
+	}
+	if len(requests) != 0 {
+		t.Errorf("invalid desired headers sent requests %q", requests)
+	}
+}
@@ -0,0 +1,154 @@
+package edgecenterprotection_go
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// HeaderChangeAction is the kind of change made to a header of DDoS resource
+type HeaderChangeAction string
+
+const (
+	HeaderCreated HeaderChangeAction = "create"
+	HeaderUpdated HeaderChangeAction = "update"
+	HeaderDeleted HeaderChangeAction = "delete"
+)
+
+// HeaderChange describes a change made to a header of DDoS resource. Old is empty for created headers
+// and New is empty for deleted ones.
+type HeaderChange struct {
+	Action HeaderChangeAction
+	ID     int64
+	Key    string
+	Old    string
+	New    string
+}
+
+// String returns a short description of the change, e.g. "update X-Frame-Options: SAMEORIGIN -> DENY"
+func (c HeaderChange) String() string {
+	switch c.Action {
+	case HeaderCreated:
+		return fmt.Sprintf("%s %s: %s", c.Action, c.Key, c.New)
+	case HeaderDeleted:
+		return fmt.Sprintf("%s %s: %s", c.Action, c.Key, c.Old)
+	default:
+		return fmt.Sprintf("%s %s: %s -> %s", c.Action, c.Key, c.Old, c.New)
+	}
+}
+
+// HeaderSyncResult contains the changes applied by SyncHeaders
+type HeaderSyncResult struct {
+	Changes []HeaderChange
+}
+
+// uncheckedHeaderWriter is implemented by header services able to write without the duplicate header check,
+// which would refuse the intermediate states of a sync
+type uncheckedHeaderWriter interface {
+	create(ctx context.Context, resourceID int64, reqBody *HeaderCreateRequest) (*Header, *Response, error)
+	update(ctx context.Context, resourceID int64, headerID int64, reqBody *HeaderCreateRequest, ifMatch string) (*Header, *Response, error)
+}
+
+// SyncHeaders makes the headers of DDoS resource match the desired set through s: missing headers are created,
+// headers with a different value are updated, and headers not in the set are deleted. Keys are compared
+// case-insensitively. Changes are applied one by one; on failure the result contains the changes applied so far.
+func SyncHeaders(ctx context.Context, s HeadersService, resourceID int64, desired []HeaderCreateRequest) (*HeaderSyncResult, *Response, error) {
+	return syncHeaders(ctx, s, resourceID, desired, true)
+}
+
+// syncHeaders validates the desired headers, plans the changes against the current headers and applies them
+func syncHeaders(ctx context.Context, s HeadersService, resourceID int64, desired []HeaderCreateRequest, prune bool) (*HeaderSyncResult, *Response, error) {
+	if s == nil {
+		return nil, nil, NewArgError("s", "cannot be nil")
+	}
+
+	seen := make(map[string]bool, len(desired))
+	for _, h := range desired {
+		if err := ValidateHeaderCreateRequest(h); err != nil {
+			return nil, nil, err
+		}
+
+		key := http.CanonicalHeaderKey(h.Key)
+		if seen[key] {
+			return nil, nil, NewArgError("desired", fmt.Sprintf("contains header %s more than once", h.Key))
+		}
+		seen[key] = true
+	}
+
+	existing, resp, err := s.List(ctx, resourceID)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	create, update := s.Create, s.Update
+	if w, ok := s.(uncheckedHeaderWriter); ok {
+		create = w.create
+		update = func(ctx context.Context, resourceID int64, headerID int64, reqBody *HeaderCreateRequest) (*Header, *Response, error) {
+			return w.update(ctx, resourceID, headerID, reqBody, "")
+		}
+	}
+
+	result := &HeaderSyncResult{}
+	for _, change := range planHeaderChanges(existing, desired, prune) {
+		switch change.Action {
+		case HeaderCreated:
+			var h *Header
+			h, resp, err = create(ctx, resourceID, &HeaderCreateRequest{Key: change.Key, Value: change.New})
+			if err == nil {
+				change.ID = h.ID
+			}
+		case HeaderUpdated:
+			_, resp, err = update(ctx, resourceID, change.ID, &HeaderCreateRequest{Key: change.Key, Value: change.New})
+		case HeaderDeleted:
+			resp, err = s.Delete(ctx, resourceID, change.ID)
+		}
+
+		if err != nil {
+			return result, resp, err
+		}
+
+		result.Changes = append(result.Changes, change)
+	}
+
+	return result, resp, nil
+}
+
+// planHeaderChanges returns the changes turning existing headers into desired ones, deletions first so that
+// keys are free before they are created. Extra headers with a desired key are deleted, other headers not in
+// desired are deleted only if prune is set.
+func planHeaderChanges(existing []Header, desired []HeaderCreateRequest, prune bool) []HeaderChange {
+	wanted := make(map[string]HeaderCreateRequest, len(desired))
+	for _, h := range desired {
+		wanted[http.CanonicalHeaderKey(h.Key)] = h
+	}
+
+	var deletes, updates, creates []HeaderChange
+
+	matched := make(map[string]bool, len(desired))
+	for _, h := range existing {
+		key := http.CanonicalHeaderKey(h.Key)
+
+		want, ok := wanted[key]
+		switch {
+		case !ok:
+			if prune {
+				deletes = append(deletes, HeaderChange{Action: HeaderDeleted, ID: h.ID, Key: h.Key, Old: h.Value})
+			}
+		case matched[key]:
+			deletes = append(deletes, HeaderChange{Action: HeaderDeleted, ID: h.ID, Key: h.Key, Old: h.Value})
+		default:
+			matched[key] = true
+			if h.Value != want.Value {
+				updates = append(updates, HeaderChange{Action: HeaderUpdated, ID: h.ID, Key: want.Key, Old: h.Value, New: want.Value})
+			}
+		}
+	}
+
+	for _, h := range desired {
+		if !matched[http.CanonicalHeaderKey(h.Key)] {
+			creates = append(creates, HeaderChange{Action: HeaderCreated, Key: h.Key, New: h.Value})
+		}
+	}
+
+	return append(append(deletes, updates...), creates...)
+}
@@ -0,0 +1,179 @@
+package edgecenterprotection_go
+
+import (
+	"context"
+	"fmt"
+)
+
+// ListConflictKind describes how a whitelist entry and a blacklist entry of the same resource overlap
+type ListConflictKind int
+
+const (
+	// ListConflictDuplicate means both entries cover exactly the same addresses
+	ListConflictDuplicate ListConflictKind = iota + 1
+
+	// ListConflictWhitelistContainsBlacklist means the whitelist entry covers the whole blacklist entry
+	ListConflictWhitelistContainsBlacklist
+
+	// ListConflictBlacklistContainsWhitelist means the blacklist entry covers the whole whitelist entry
+	ListConflictBlacklistContainsWhitelist
+
+	// ListConflictPartialOverlap means the entries share some addresses, but neither contains the other
+	ListConflictPartialOverlap
+)
+
+// String returns the name of the conflict kind
+func (k ListConflictKind) String() string {
+	switch k {
+	case ListConflictDuplicate:
+		return "duplicate"
+	case ListConflictWhitelistContainsBlacklist:
+		return "whitelist contains blacklist"
+	case ListConflictBlacklistContainsWhitelist:
+		return "blacklist contains whitelist"
+	case ListConflictPartialOverlap:
+		return "partial overlap"
+	default:
+		return fmt.Sprintf("ListConflictKind(%d)", int(k))
+	}
+}
+
+// ListConflict represents an overlap between a whitelist and a blacklist entry of the same resource
+type ListConflict struct {
+	Kind      ListConflictKind
+	Whitelist Whitelist
+	Blacklist Blacklist
+}
+
+// String describes the conflict in a form suitable for incident reports
+func (c ListConflict) String() string {
+	w := listEntryString("whitelist", c.Whitelist.ID, c.Whitelist.IP)
+	b := listEntryString("blacklist", c.Blacklist.ID, c.Blacklist.IP)
+
+	switch c.Kind {
+	case ListConflictDuplicate:
+		return fmt.Sprintf("%s duplicates %s", b, w)
+	case ListConflictWhitelistContainsBlacklist:
+		return fmt.Sprintf("%s contains %s", w, b)
+	case ListConflictBlacklistContainsWhitelist:
+		return fmt.Sprintf("%s contains %s", b, w)
+	default:
+		return fmt.Sprintf("%s partially overlaps %s", b, w)
+	}
+}
+
+// WithListConflictCheck is a client option that makes Blacklists.Create/Update and Whitelists.Create/Update
+// refuse entries overlapping the opposite list of the same resource. Every checked request lists all pages
+// of the opposite list first.
+func WithListConflictCheck() ClientOpt {
+	return func(c *Client) error {
+		c.listConflictCheck = true
+		return nil
+	}
+}
+
+// FindListConflicts loads whitelist and blacklist entries of DDoS resource and reports overlaps between them
+func FindListConflicts(ctx context.Context, c *Client, resourceID int64) ([]ListConflict, error) {
+	whitelists, err := listAllWhitelists(ctx, c.Whitelists, resourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	blacklists, err := listAllBlacklists(ctx, c.Blacklists, resourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	return DetectListConflicts(whitelists, blacklists), nil
+}
+
+// DetectListConflicts reports exact duplicates, containment and partial overlaps between whitelist and
+// blacklist entries. Entries that are not addresses, prefixes or address ranges are ignored.
+func DetectListConflicts(whitelists []Whitelist, blacklists []Blacklist) []ListConflict {
+	blacklistRanges := make([]*ipRange, len(blacklists))
+	for i, b := range blacklists {
+		if r, err := parseIPRange(b.IP); err == nil {
+			blacklistRanges[i] = &r
+		}
+	}
+
+	var conflicts []ListConflict
+	for _, w := range whitelists {
+		wr, err := parseIPRange(w.IP)
+		if err != nil {
+			continue
+		}
+
+		for i, br := range blacklistRanges {
+			if br == nil {
+				continue
+			}
+
+			if kind := listConflictKind(wr, *br); kind != 0 {
+				conflicts = append(conflicts, ListConflict{Kind: kind, Whitelist: w, Blacklist: blacklists[i]})
+			}
+		}
+	}
+
+	return conflicts
+}
+
+// checkBlacklistConflict returns an ArgError if ip overlaps a whitelist entry of DDoS resource
+func checkBlacklistConflict(ctx context.Context, c *Client, resourceID int64, ip string) error {
+	whitelists, err := listAllWhitelists(ctx, c.Whitelists, resourceID)
+	if err != nil {
+		return err
+	}
+
+	conflicts := DetectListConflicts(whitelists, []Blacklist{{IP: ip}})
+	if len(conflicts) > 0 {
+		return NewArgError("IP", conflicts[0].String())
+	}
+
+	return nil
+}
+
+// checkWhitelistConflict returns an ArgError if ip overlaps a blacklist entry of DDoS resource
+func checkWhitelistConflict(ctx context.Context, c *Client, resourceID int64, ip string) error {
+	blacklists, err := listAllBlacklists(ctx, c.Blacklists, resourceID)
+	if err != nil {
+		return err
+	}
+
+	conflicts := DetectListConflicts([]Whitelist{{IP: ip}}, blacklists)
+	if len(conflicts) > 0 {
+		return NewArgError("IP", conflicts[0].String())
+	}
+
+	return nil
+}
+
+// listConflictKind classifies the overlap of a whitelist range w and a blacklist range b, zero if disjoint
+func listConflictKind(w, b ipRange) ListConflictKind {
+	if w.from.Is4() != b.from.Is4() || w.to.Less(b.from) || b.to.Less(w.from) {
+		return 0
+	}
+
+	wCoversB := w.from.Compare(b.from) <= 0 && b.to.Compare(w.to) <= 0
+	bCoversW := b.from.Compare(w.from) <= 0 && w.to.Compare(b.to) <= 0
+
+	switch {
+	case wCoversB && bCoversW:
+		return ListConflictDuplicate
+	case wCoversB:
+		return ListConflictWhitelistContainsBlacklist
+	case bCoversW:
+		return ListConflictBlacklistContainsWhitelist
+	default:
+		return ListConflictPartialOverlap
+	}
+}
+
+// listEntryString formats a list entry for conflict descriptions
+func listEntryString(list string, id int64, ip string) string {
+	if id == 0 {
+		return fmt.Sprintf("%s entry %s", list, ip)
+	}
+
+	return fmt.Sprintf("%s entry %s (id %d)", list, ip, id)
+}
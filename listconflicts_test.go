@@ -0,0 +1,122 @@
+package edgecenterprotection_go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+type fakeWhitelists struct {
+	WhitelistsService
+	existing []Whitelist
+}
+
+func (f *fakeWhitelists) List(_ context.Context, _ int64, opts *WhitelistListOptions) ([]Whitelist, *Response, error) {
+	return pageOf(f.existing, opts.Limit, opts.Offset), nil, nil
+}
+
+func TestListConflictKind(t *testing.T) {
+	tests := []struct {
+		w, b string
+		want ListConflictKind
+	}{
+		{"192.0.2.0/24", "192.0.2.0/24", ListConflictDuplicate},
+		{"192.0.2.7", "192.0.2.7/32", ListConflictDuplicate},
+		{"192.0.2.0/24", "192.0.2.128/25", ListConflictWhitelistContainsBlacklist},
+		{"192.0.2.10", "192.0.2.0/24", ListConflictBlacklistContainsWhitelist},
+		{"192.0.2.0-192.0.2.10", "192.0.2.8/29", ListConflictPartialOverlap},
+		{"192.0.2.0/25", "192.0.2.128/25", 0},
+		{"192.0.2.0/24", "2001:db8::/32", 0},
+		{"::ffff:192.0.2.1", "192.0.2.1", ListConflictDuplicate},
+		{"2001:db8::/32", "2001:db8:1::/48", ListConflictWhitelistContainsBlacklist},
+	}
+
+	for _, tt := range tests {
+		w, err := parseIPRange(tt.w)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := parseIPRange(tt.b)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := listConflictKind(w, b); got != tt.want {
+			t.Errorf("listConflictKind(%s, %s) = %v, want %v", tt.w, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestDetectListConflicts(t *testing.T) {
+	whitelists := []Whitelist{
+		{ID: 1, IP: "192.0.2.0/24"},
+		{ID: 2, IP: "not-an-ip"},
+		{ID: 3, IP: "198.51.100.5"},
+	}
+	blacklists := []Blacklist{
+		{ID: 10, IP: "192.0.2.64/26"},
+		{ID: 11, IP: "example.com"},
+		{ID: 12, IP: "198.51.100.0/24"},
+		{ID: 13, IP: "203.0.113.1"},
+	}
+
+	got := DetectListConflicts(whitelists, blacklists)
+
+	want := []string{
+		"whitelist entry 192.0.2.0/24 (id 1) contains blacklist entry 192.0.2.64/26 (id 10)",
+		"blacklist entry 198.51.100.0/24 (id 12) contains whitelist entry 198.51.100.5 (id 3)",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d conflicts, want %d: %v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		if s := got[i].String(); s != w {
+			t.Errorf("conflict %d = %q, want %q", i, s, w)
+		}
+	}
+}
+
+func TestFindListConflictsAllPages(t *testing.T) {
+	w := &fakeWhitelists{}
+	b := &fakeBlacklists{}
+	for i := range listPageSize + 1 {
+		w.existing = append(w.existing, Whitelist{ID: int64(i + 1), IP: fmt.Sprintf("10.1.%d.%d", i/256, i%256)})
+		b.existing = append(b.existing, Blacklist{ID: int64(i + 1), IP: fmt.Sprintf("10.2.%d.%d", i/256, i%256)})
+	}
+	// the conflicting entries are on the second page of both lists
+	w.existing[listPageSize].IP = "192.0.2.0/24"
+	b.existing[listPageSize].IP = "192.0.2.1"
+
+	conflicts, err := FindListConflicts(context.Background(), &Client{Whitelists: w, Blacklists: b}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(conflicts) != 1 || conflicts[0].Kind != ListConflictWhitelistContainsBlacklist {
+		t.Errorf("conflicts = %v, want one whitelist contains blacklist conflict", conflicts)
+	}
+}
+
+func TestWithListConflictCheck(t *testing.T) {
+	w := &fakeWhitelists{}
+	for i := range listPageSize {
+		w.existing = append(w.existing, Whitelist{ID: int64(i + 1), IP: fmt.Sprintf("10.1.0.%d", i)})
+	}
+	w.existing = append(w.existing, Whitelist{ID: 500, IP: "192.0.2.0/24"})
+
+	c := &Client{Whitelists: w}
+	if err := WithListConflictCheck()(c); err != nil {
+		t.Fatal(err)
+	}
+	c.Blacklists = &BlacklistsServiceOp{client: c}
+
+	_, _, err := c.Blacklists.Create(context.Background(), 1, &BlacklistCreateRequest{IP: "192.0.2.10"})
+	var argErr *ArgError
+	if !errors.As(err, &argErr) || argErr.arg != "IP" {
+		t.Fatalf("err = %v, want ArgError for IP", err)
+	}
+	if want := "whitelist entry 192.0.2.0/24 (id 500) contains blacklist entry 192.0.2.10"; argErr.reason != want {
+		t.Errorf("reason = %q, want %q", argErr.reason, want)
+	}
+}
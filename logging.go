@@ -0,0 +1,207 @@
+package edgecenterprotection_go
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/go-retryablehttp"
+)
+
+// maxLoggedBodySize limits request bodies logged at debug level
+const maxLoggedBodySize = 4 << 10
+
+// LogLevels sets the levels requests are logged at, depending on their outcome
+type LogLevels struct {
+	// Success is used for 2xx responses, debug by default
+	Success slog.Level
+
+	// ClientError is used for 4xx responses, warn by default
+	ClientError slog.Level
+
+	// ServerError is used for 5xx responses and transport errors, error by default
+	ServerError slog.Level
+}
+
+// DefaultLogLevels are the levels used by WithLogger if none are given
+var DefaultLogLevels = LogLevels{
+	Success:     slog.LevelDebug,
+	ClientError: slog.LevelWarn,
+	ServerError: slog.LevelError,
+}
+
+// attemptsContextKey is the context key of the attempt counter updated by the retryable client
+type attemptsContextKey struct{}
+
+// WithLogger is a client option that logs every API request with its method, path, status, duration, number of
+// attempts and request ID. The Authorization header and SSL private keys are redacted. Unless RetryConfig.Logger
+// is set, the logger also receives the messages of the retryable client.
+func WithLogger(logger *slog.Logger, levels *LogLevels) ClientOpt {
+	return func(c *Client) error {
+		if logger == nil {
+			return NewArgError("logger", "cannot be nil")
+		}
+
+		c.logger = logger
+		c.logLevels = DefaultLogLevels
+		if levels != nil {
+			c.logLevels = *levels
+		}
+
+		return nil
+	}
+}
+
+// RetryableLogger adapts slog.Logger to the LeveledLogger interface of go-retryablehttp
+type RetryableLogger struct {
+	Logger *slog.Logger
+}
+
+var _ retryablehttp.LeveledLogger = RetryableLogger{}
+
+// NewRetryableLogger returns a go-retryablehttp logger writing to the slog logger, for use in RetryConfig.Logger
+func NewRetryableLogger(logger *slog.Logger) RetryableLogger {
+	return RetryableLogger{Logger: logger}
+}
+
+func (l RetryableLogger) Error(msg string, keysAndValues ...interface{}) {
+	l.log(slog.LevelError, msg, keysAndValues)
+}
+
+func (l RetryableLogger) Info(msg string, keysAndValues ...interface{}) {
+	l.log(slog.LevelInfo, msg, keysAndValues)
+}
+
+func (l RetryableLogger) Debug(msg string, keysAndValues ...interface{}) {
+	l.log(slog.LevelDebug, msg, keysAndValues)
+}
+
+func (l RetryableLogger) Warn(msg string, keysAndValues ...interface{}) {
+	l.log(slog.LevelWarn, msg, keysAndValues)
+}
+
+// log writes the message, replacing requests and responses among the values by their redacted summaries
+func (l RetryableLogger) log(level slog.Level, msg string, keysAndValues []interface{}) {
+	args := make([]any, len(keysAndValues))
+	for i, v := range keysAndValues {
+		switch v := v.(type) {
+		case *http.Request:
+			args[i] = fmt.Sprintf("%s %s", v.Method, v.URL.Redacted())
+		case *http.Response:
+			args[i] = v.Status
+		default:
+			args[i] = v
+		}
+	}
+
+	l.Logger.Log(context.Background(), level, msg, args...)
+}
+
+// withAttemptCounter returns a context with a counter of request attempts, updated by countAttempt
+func withAttemptCounter(ctx context.Context) (context.Context, *atomic.Int32) {
+	counter := new(atomic.Int32)
+	return context.WithValue(ctx, attemptsContextKey{}, counter), counter
+}
+
+// countAttempt is the RequestLogHook of the retryable client recording the attempt number in the request context
+func countAttempt(_ retryablehttp.Logger, req *http.Request, attempt int) {
+	if counter, ok := req.Context().Value(attemptsContextKey{}).(*atomic.Int32); ok {
+		counter.Store(int32(attempt + 1))
+	}
+}
+
+// requestAttempts returns the number of times the request was sent
+func requestAttempts(counter *atomic.Int32, resp *Response) int {
+	if counter != nil {
+		if n := counter.Load(); n > 0 {
+			return int(n)
+		}
+	}
+
+	if resp != nil && resp.Response != nil {
+		if n, err := strconv.Atoi(resp.Header.Get(internalHeaderRetryAttempts)); err == nil {
+			return n
+		}
+	}
+
+	return 1
+}
+
+// logRequest logs the outcome of an API request
+func (c *Client) logRequest(ctx context.Context, req *http.Request, resp *Response, err error, attempts int, duration time.Duration) {
+	status := apiStatusCode(resp, err)
+
+	level := c.logLevels.Success
+	switch {
+	case status == 0 && err != nil:
+		level = c.logLevels.ServerError
+	case status >= http.StatusInternalServerError:
+		level = c.logLevels.ServerError
+	case status >= http.StatusBadRequest || err != nil:
+		level = c.logLevels.ClientError
+	}
+
+	if !c.logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("path", req.URL.Path),
+		slog.Int("status", status),
+		slog.Duration("duration", duration),
+		slog.Int("attempts", attempts),
+	}
+
+	if resp != nil && resp.RequestID != "" {
+		attrs = append(attrs, slog.String("request_id", resp.RequestID))
+	}
+
+	if err != nil {
+		attrs = append(attrs, slog.String("error", string(redactJSONSecrets([]byte(err.Error())))))
+	}
+
+	if c.logger.Enabled(ctx, slog.LevelDebug) {
+		attrs = append(attrs, slog.Any("request_headers", redactHeader(req.Header)))
+		if body := loggedRequestBody(req); body != "" {
+			attrs = append(attrs, slog.String("request_body", body))
+		}
+	}
+
+	c.logger.LogAttrs(ctx, level, "edgecenter protection API request", attrs...)
+}
+
+// loggedRequestBody returns the redacted and truncated request body
+func loggedRequestBody(req *http.Request) string {
+	if req.GetBody == nil {
+		return ""
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return ""
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(body, maxLoggedBodySize+1))
+	if err != nil {
+		return ""
+	}
+
+	truncated := len(data) > maxLoggedBodySize
+	if truncated {
+		data = data[:maxLoggedBodySize]
+	}
+
+	data = redactJSONSecrets(data)
+	if truncated {
+		return string(data) + "..."
+	}
+
+	return string(data)
+}
@@ -0,0 +1,551 @@
+package edgecenterprotection_go
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	accessLogTimeLayout = "02/Jan/2006:15:04:05 -0700"
+
+	defaultAccessLogWindow           = time.Minute
+	defaultAccessLogPollInterval     = time.Second
+	defaultAccessLogWhitelistRefresh = 5 * time.Minute
+)
+
+// accessLogPattern matches the common and combined log formats of nginx and Apache
+var accessLogPattern = regexp.MustCompile(
+	`^(\S+) \S+ \S+ \[([^\]]+)\] "((?:[^"\\]|\\.)*)" (\d{3}) (\S+)(?: "((?:[^"\\]|\\.)*)" "((?:[^"\\]|\\.)*)")?`)
+
+// AccessLogEntry represents a single request of a common or combined format access log
+type AccessLogEntry struct {
+	RemoteAddr netip.Addr
+	Time       time.Time
+	Method     string
+	Path       string
+	Protocol   string
+	Status     int
+	Bytes      int64
+	Referer    string
+	UserAgent  string
+}
+
+// AccessLogRule describes abusive behaviour of a single address within a sliding time window.
+// An address is banned when it makes more than MaxRequests counted requests within Window, or when
+// counted requests exceed MaxRatio of all its requests within Window and there are at least MinRequests of them.
+// A request is counted if it matches PathPattern and Statuses, all requests are counted if both are empty.
+type AccessLogRule struct {
+	Name string
+
+	// Window is the length of the sliding window, one minute by default
+	Window time.Duration
+
+	// PathPattern restricts counted requests to paths matching the pattern
+	PathPattern *regexp.Regexp
+
+	// Statuses restricts counted requests to the listed status codes. Values 1 to 5 match a whole
+	// status class, e.g. 4 matches all 4xx responses.
+	Statuses []int
+
+	MaxRequests int
+	MaxRatio    float64
+	MinRequests int
+}
+
+// AccessLogBan represents a ban issued by AccessLogWatcher
+type AccessLogBan struct {
+	Addr netip.Addr
+	Rule string
+	Time time.Time
+}
+
+// AccessLogWatcher tails access logs and adds addresses violating its rules to the blacklist of DDoS resource.
+// Addresses covered by the whitelist of the resource are never banned.
+type AccessLogWatcher struct {
+	client     *Client
+	resourceID int64
+	rules      []AccessLogRule
+
+	// bans issues time-limited bans for banTTL, addresses are blacklisted permanently if it is nil
+	bans   *BanManager
+	banTTL time.Duration
+
+	// PollInterval is how often log files are checked for new lines, one second by default
+	PollInterval time.Duration
+
+	// WhitelistRefresh is how often the whitelist is reloaded, five minutes by default
+	WhitelistRefresh time.Duration
+
+	// FromStart reads log files from the beginning instead of only following new lines
+	FromStart bool
+
+	// OnBan is called for every issued ban
+	OnBan func(AccessLogBan)
+
+	// OnError is called with errors that do not stop Run
+	OnError func(error)
+
+	mu        sync.Mutex
+	window    time.Duration
+	latest    time.Time
+	history   map[netip.Addr][]accessLogEvent
+	banned    map[netip.Addr]time.Time
+	whitelist []ipRange
+	loaded    bool
+}
+
+// accessLogEvent records a request of an address and which rules counted it
+type accessLogEvent struct {
+	time    time.Time
+	matches []bool
+}
+
+// accessLogTail follows a single log file across truncation and rotation
+type accessLogTail struct {
+	path    string
+	file    *os.File
+	reader  *bufio.Reader
+	partial string
+}
+
+// ParseAccessLogLine parses a line of common or combined format access log
+func ParseAccessLogLine(line string) (*AccessLogEntry, error) {
+	m := accessLogPattern.FindStringSubmatch(line)
+	if m == nil {
+		return nil, NewArgError("line", "does not match the common or combined log format")
+	}
+
+	addr, err := netip.ParseAddr(m[1])
+	if err != nil {
+		return nil, NewArgError("line", fmt.Sprintf("remote address %q is not an IP address", m[1]))
+	}
+
+	t, err := time.Parse(accessLogTimeLayout, m[2])
+	if err != nil {
+		return nil, NewArgError("line", fmt.Sprintf("time %q is invalid", m[2]))
+	}
+
+	status, _ := strconv.Atoi(m[4])
+	bytes, _ := strconv.ParseInt(m[5], 10, 64)
+
+	entry := &AccessLogEntry{
+		RemoteAddr: addr.Unmap(),
+		Time:       t,
+		Status:     status,
+		Bytes:      bytes,
+		Referer:    m[6],
+		UserAgent:  m[7],
+	}
+
+	request := strings.Fields(m[3])
+	switch len(request) {
+	case 3:
+		entry.Method, entry.Path, entry.Protocol = request[0], request[1], request[2]
+	case 2:
+		entry.Method, entry.Path = request[0], request[1]
+	default:
+		entry.Path = m[3]
+	}
+
+	return entry, nil
+}
+
+// NewAccessLogWatcher returns an AccessLogWatcher banning addresses on DDoS resource according to rules.
+// If bans is nil, bans are permanent blacklist entries, otherwise they are issued by bans and expire after banTTL.
+func NewAccessLogWatcher(c *Client, resourceID int64, bans *BanManager, banTTL time.Duration, rules ...AccessLogRule) (*AccessLogWatcher, error) {
+	if bans != nil && banTTL <= 0 {
+		return nil, NewArgError("banTTL", "must be positive with a ban manager")
+	}
+
+	w := &AccessLogWatcher{
+		client:     c,
+		resourceID: resourceID,
+		bans:       bans,
+		banTTL:     banTTL,
+		rules:      make([]AccessLogRule, len(rules)),
+		history:    make(map[netip.Addr][]accessLogEvent),
+		banned:     make(map[netip.Addr]time.Time),
+	}
+
+	for i, rule := range rules {
+		if rule.Window <= 0 {
+			rule.Window = defaultAccessLogWindow
+		}
+		w.rules[i] = rule
+		w.window = max(w.window, rule.Window)
+	}
+
+	return w, nil
+}
+
+// RefreshWhitelist reloads the whitelist of the resource
+func (w *AccessLogWatcher) RefreshWhitelist(ctx context.Context) error {
+	whitelists, err := listAllWhitelists(ctx, w.client.Whitelists, w.resourceID)
+	if err != nil {
+		return err
+	}
+
+	ranges := make([]ipRange, 0, len(whitelists))
+	for _, wl := range whitelists {
+		if r, err := parseIPRange(wl.IP); err == nil {
+			ranges = append(ranges, r)
+		}
+	}
+
+	w.mu.Lock()
+	w.whitelist = mergeRanges(ranges)
+	w.loaded = true
+	w.mu.Unlock()
+
+	return nil
+}
+
+// Process applies the rules to a single request and bans its address if a rule is violated.
+// It returns the issued ban, or nil if the address was not banned.
+func (w *AccessLogWatcher) Process(ctx context.Context, entry AccessLogEntry) (*AccessLogBan, error) {
+	w.mu.Lock()
+	loaded := w.loaded
+	w.mu.Unlock()
+
+	if !loaded {
+		if err := w.RefreshWhitelist(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	rule := w.record(entry)
+	if rule == "" {
+		return nil, nil
+	}
+
+	ban := AccessLogBan{Addr: entry.RemoteAddr, Rule: rule, Time: entry.Time}
+	ip := entry.RemoteAddr.String()
+	reason := fmt.Sprintf("access log rule %q", rule)
+
+	var err error
+	if w.bans != nil {
+		_, err = w.bans.Ban(ctx, w.resourceID, ip, w.banTTL, reason)
+	} else {
+		_, _, err = w.client.Blacklists.Create(ctx, w.resourceID, &BlacklistCreateRequest{IP: ip})
+	}
+
+	if err != nil {
+		w.mu.Lock()
+		delete(w.banned, entry.RemoteAddr)
+		w.mu.Unlock()
+
+		return nil, err
+	}
+
+	if w.OnBan != nil {
+		w.OnBan(ban)
+	}
+
+	return &ban, nil
+}
+
+// Run follows the log files at paths and processes every new line until ctx is done.
+// Lines that are not in common or combined log format are skipped.
+func (w *AccessLogWatcher) Run(ctx context.Context, paths ...string) error {
+	if len(paths) == 0 {
+		return NewArgError("paths", "cannot be empty")
+	}
+
+	if err := w.RefreshWhitelist(ctx); err != nil {
+		return err
+	}
+
+	tails := make([]*accessLogTail, 0, len(paths))
+	defer func() {
+		for _, t := range tails {
+			t.close()
+		}
+	}()
+
+	for _, path := range paths {
+		t := &accessLogTail{path: path}
+		if err := t.open(!w.FromStart); err != nil {
+			return err
+		}
+		tails = append(tails, t)
+	}
+
+	pollInterval := w.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = defaultAccessLogPollInterval
+	}
+
+	whitelistRefresh := w.WhitelistRefresh
+	if whitelistRefresh <= 0 {
+		whitelistRefresh = defaultAccessLogWhitelistRefresh
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	lastRefresh := time.Now()
+	for {
+		for _, t := range tails {
+			err := t.poll(func(line string) {
+				entry, err := ParseAccessLogLine(line)
+				if err != nil {
+					return
+				}
+
+				if _, err := w.Process(ctx, *entry); err != nil {
+					w.reportError(err)
+				}
+			})
+			if err != nil {
+				w.reportError(err)
+			}
+		}
+
+		if time.Since(lastRefresh) >= whitelistRefresh {
+			if err := w.RefreshWhitelist(ctx); err != nil {
+				w.reportError(err)
+			}
+			lastRefresh = time.Now()
+		}
+
+		w.forget()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// record adds the request to the history of its address and returns the name of the violated rule, if any.
+// The address is marked as banned before returning, so concurrent calls ban it only once.
+func (w *AccessLogWatcher) record(entry AccessLogEntry) string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	addr := entry.RemoteAddr
+	if until, ok := w.banned[addr]; ok && (until.IsZero() || entry.Time.Before(until)) {
+		return ""
+	}
+
+	if entry.Time.After(w.latest) {
+		w.latest = entry.Time
+	}
+
+	for _, r := range w.whitelist {
+		if r.from.Is4() == addr.Is4() && r.from.Compare(addr) <= 0 && addr.Compare(r.to) <= 0 {
+			return ""
+		}
+	}
+
+	event := accessLogEvent{time: entry.Time, matches: make([]bool, len(w.rules))}
+	for i, rule := range w.rules {
+		event.matches[i] = rule.counts(entry)
+	}
+
+	history := w.history[addr]
+	for len(history) > 0 && entry.Time.Sub(history[0].time) > w.window {
+		history = history[1:]
+	}
+	history = append(history, event)
+	w.history[addr] = history
+
+	for i, rule := range w.rules {
+		total, counted := 0, 0
+		for _, e := range history {
+			if entry.Time.Sub(e.time) > rule.Window {
+				continue
+			}
+
+			total++
+			if e.matches[i] {
+				counted++
+			}
+		}
+
+		if rule.violated(total, counted) {
+			var until time.Time
+			if w.bans != nil {
+				until = entry.Time.Add(w.banTTL)
+			}
+
+			w.banned[addr] = until
+			delete(w.history, addr)
+
+			return rule.Name
+		}
+	}
+
+	return ""
+}
+
+// forget drops addresses without requests within the longest rule window and expired bans,
+// relative to the latest processed request
+func (w *AccessLogWatcher) forget() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for addr, history := range w.history {
+		if w.latest.Sub(history[len(history)-1].time) > w.window {
+			delete(w.history, addr)
+		}
+	}
+
+	for addr, until := range w.banned {
+		if !until.IsZero() && !w.latest.Before(until) {
+			delete(w.banned, addr)
+		}
+	}
+}
+
+func (w *AccessLogWatcher) reportError(err error) {
+	if w.OnError != nil {
+		w.OnError(err)
+	}
+}
+
+// counts reports whether the rule counts the request
+func (r AccessLogRule) counts(entry AccessLogEntry) bool {
+	if r.PathPattern != nil && !r.PathPattern.MatchString(entry.Path) {
+		return false
+	}
+
+	if len(r.Statuses) == 0 {
+		return true
+	}
+
+	for _, status := range r.Statuses {
+		if status == entry.Status || (status >= 1 && status <= 5 && entry.Status/100 == status) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// violated reports whether total requests with counted matching requests violate the rule
+func (r AccessLogRule) violated(total, counted int) bool {
+	if r.MaxRequests > 0 && counted > r.MaxRequests {
+		return true
+	}
+
+	if r.MaxRatio > 0 && total > 0 && total >= r.MinRequests {
+		return float64(counted)/float64(total) > r.MaxRatio
+	}
+
+	return false
+}
+
+// open opens the log file, positioned at its end if atEnd is set
+func (t *accessLogTail) open(atEnd bool) error {
+	f, err := os.Open(t.path)
+	if err != nil {
+		return err
+	}
+
+	if atEnd {
+		if _, err := f.Seek(0, io.SeekEnd); err != nil {
+			_ = f.Close()
+			return err
+		}
+	}
+
+	t.file = f
+	t.reader = bufio.NewReader(f)
+	t.partial = ""
+
+	return nil
+}
+
+// poll passes complete new lines to handle, reopening the file if it was rotated or truncated
+func (t *accessLogTail) poll(handle func(string)) error {
+	if t.file == nil {
+		if err := t.open(false); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+	}
+
+	if err := t.drain(handle); err != nil {
+		return err
+	}
+
+	current, err := t.file.Stat()
+	if err != nil {
+		return err
+	}
+
+	offset, err := t.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+
+	if current.Size() < offset {
+		// truncated in place, e.g. by copytruncate
+		if _, err := t.file.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		t.reader.Reset(t.file)
+		t.partial = ""
+
+		return t.drain(handle)
+	}
+
+	info, err := os.Stat(t.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if !os.SameFile(current, info) {
+		// rotated, the rest of the old file has already been drained
+		t.close()
+		if err := t.open(false); err != nil {
+			return err
+		}
+
+		return t.drain(handle)
+	}
+
+	return nil
+}
+
+// drain reads all complete lines available in the file
+func (t *accessLogTail) drain(handle func(string)) error {
+	for {
+		chunk, err := t.reader.ReadString('\n')
+		if err == io.EOF {
+			t.partial += chunk
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		line := strings.TrimRight(t.partial+chunk, "\r\n")
+		t.partial = ""
+		handle(line)
+	}
+}
+
+func (t *accessLogTail) close() {
+	if t.file != nil {
+		_ = t.file.Close()
+		t.file = nil
+	}
+}
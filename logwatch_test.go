@@ -0,0 +1,335 @@
+package edgecenterprotection_go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestNewAccessLogWatcherBanTTL(t *testing.T) {
+	bans := &BanManager{}
+
+	var argErr *ArgError
+	if _, err := NewAccessLogWatcher(nil, 1, bans, 0); !errors.As(err, &argErr) {
+		t.Errorf("NewAccessLogWatcher() with zero banTTL = %v, want ArgError", err)
+	}
+
+	if _, err := NewAccessLogWatcher(nil, 1, bans, time.Hour); err != nil {
+		t.Errorf("NewAccessLogWatcher() = %v", err)
+	}
+	if _, err := NewAccessLogWatcher(nil, 1, nil, 0); err != nil {
+		t.Errorf("NewAccessLogWatcher() without bans = %v", err)
+	}
+}
+
+func TestParseAccessLogLine(t *testing.T) {
+	ts := time.Date(2024, 3, 10, 13, 55, 36, 0, time.FixedZone("", -7*3600))
+
+	tests := []struct {
+		name string
+		line string
+		want AccessLogEntry
+	}{
+		{
+			name: "common",
+			line: `192.0.2.1 - frank [10/Mar/2024:13:55:36 -0700] "GET /index.html HTTP/1.1" 200 2326`,
+			want: AccessLogEntry{RemoteAddr: netip.MustParseAddr("192.0.2.1"), Time: ts, Method: "GET", Path: "/index.html", Protocol: "HTTP/1.1", Status: 200, Bytes: 2326},
+		},
+		{
+			name: "combined",
+			line: `2001:db8::1 - - [10/Mar/2024:13:55:36 -0700] "POST /login HTTP/2.0" 401 - "https://example.com/" "curl/8.0 \"quoted\""`,
+			want: AccessLogEntry{RemoteAddr: netip.MustParseAddr("2001:db8::1"), Time: ts, Method: "POST", Path: "/login", Protocol: "HTTP/2.0", Status: 401, Referer: "https://example.com/", UserAgent: `curl/8.0 \"quoted\"`},
+		},
+		{
+			name: "mapped address without protocol",
+			line: `::ffff:198.51.100.7 - - [10/Mar/2024:13:55:36 -0700] "GET /" 304 0`,
+			want: AccessLogEntry{RemoteAddr: netip.MustParseAddr("198.51.100.7"), Time: ts, Method: "GET", Path: "/", Status: 304},
+		},
+		{
+			name: "garbage request",
+			line: `192.0.2.1 - - [10/Mar/2024:13:55:36 -0700] "\x16\x03\x01" 400 157`,
+			want: AccessLogEntry{RemoteAddr: netip.MustParseAddr("192.0.2.1"), Time: ts, Path: `\x16\x03\x01`, Status: 400, Bytes: 157},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAccessLogLine(tt.line)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Time.Equal(tt.want.Time) {
+				t.Errorf("Time = %v, want %v", got.Time, tt.want.Time)
+			}
+			got.Time = tt.want.Time
+			if *got != tt.want {
+				t.Errorf("got %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+
+	for _, line := range []string{
+		"",
+		`example.com - - [10/Mar/2024:13:55:36 -0700] "GET / HTTP/1.1" 200 1`,
+		`192.0.2.1 - - [10/Mar/2024 13:55:36] "GET / HTTP/1.1" 200 1`,
+		`192.0.2.1 - - [10/Mar/2024:13:55:36 -0700] "GET / HTTP/1.1" OK 1`,
+	} {
+		var argErr *ArgError
+		if _, err := ParseAccessLogLine(line); !errors.As(err, &argErr) {
+			t.Errorf("ParseAccessLogLine(%q) = %v, want ArgError", line, err)
+		}
+	}
+}
+
+func TestAccessLogRuleCounts(t *testing.T) {
+	rule := AccessLogRule{PathPattern: regexp.MustCompile(`^/login`), Statuses: []int{4, 503}}
+
+	tests := []struct {
+		path   string
+		status int
+		want   bool
+	}{
+		{"/login", 401, true},
+		{"/login?next=/", 429, true},
+		{"/login", 503, true},
+		{"/login", 500, false},
+		{"/login", 200, false},
+		{"/index.html", 404, false},
+	}
+	for _, tt := range tests {
+		if got := rule.counts(AccessLogEntry{Path: tt.path, Status: tt.status}); got != tt.want {
+			t.Errorf("counts(%s %d) = %v, want %v", tt.path, tt.status, got, tt.want)
+		}
+	}
+
+	if !(AccessLogRule{}).counts(AccessLogEntry{Path: "/", Status: 200}) {
+		t.Error("a rule without filters should count every request")
+	}
+}
+
+func TestAccessLogRuleViolated(t *testing.T) {
+	tests := []struct {
+		rule           AccessLogRule
+		total, counted int
+		want           bool
+	}{
+		{AccessLogRule{MaxRequests: 10}, 20, 10, false},
+		{AccessLogRule{MaxRequests: 10}, 20, 11, true},
+		{AccessLogRule{MaxRatio: 0.5, MinRequests: 10}, 9, 9, false},
+		{AccessLogRule{MaxRatio: 0.5, MinRequests: 10}, 10, 5, false},
+		{AccessLogRule{MaxRatio: 0.5, MinRequests: 10}, 10, 6, true},
+		{AccessLogRule{MaxRequests: 100, MaxRatio: 0.5}, 4, 3, true},
+		{AccessLogRule{}, 1000, 1000, false},
+	}
+	for _, tt := range tests {
+		if got := tt.rule.violated(tt.total, tt.counted); got != tt.want {
+			t.Errorf("%+v violated(%d, %d) = %v, want %v", tt.rule, tt.total, tt.counted, got, tt.want)
+		}
+	}
+}
+
+func TestAccessLogWatcherRecord(t *testing.T) {
+	rules := []AccessLogRule{
+		{Name: "burst", Window: 10 * time.Second, MaxRequests: 2},
+		{Name: "errors", Window: time.Minute, Statuses: []int{4}, MaxRatio: 0.5, MinRequests: 4},
+	}
+	w, err := NewAccessLogWatcher(nil, 1, nil, 0, rules...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.whitelist = []ipRange{mustIPRange(t, "203.0.113.0/24")}
+
+	start := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	entry := func(addr string, offset time.Duration, status int) AccessLogEntry {
+		return AccessLogEntry{RemoteAddr: netip.MustParseAddr(addr), Time: start.Add(offset), Status: status}
+	}
+
+	// requests leaving the burst window do not count
+	for i, off := range []time.Duration{0, 5 * time.Second, 11 * time.Second, 16 * time.Second} {
+		if rule := w.record(entry("192.0.2.1", off, 200)); rule != "" {
+			t.Fatalf("request %d violated %q", i, rule)
+		}
+	}
+	if rule := w.record(entry("192.0.2.1", 17*time.Second, 200)); rule != "burst" {
+		t.Fatalf("third request within 10s violated %q, want burst", rule)
+	}
+	if _, ok := w.history[netip.MustParseAddr("192.0.2.1")]; ok {
+		t.Error("history of the banned address is kept")
+	}
+	if rule := w.record(entry("192.0.2.1", 18*time.Second, 200)); rule != "" {
+		t.Errorf("banned address violated %q again", rule)
+	}
+
+	// the error ratio needs the minimum number of requests within the minute
+	for i, status := range []int{404, 200, 404} {
+		if rule := w.record(entry("192.0.2.2", time.Duration(i)*20*time.Second, status)); rule != "" {
+			t.Fatalf("request %d violated %q", i, rule)
+		}
+	}
+	if rule := w.record(entry("192.0.2.2", 61*time.Second, 404)); rule != "" {
+		t.Fatalf("request after the first left the window violated %q", rule)
+	}
+	if rule := w.record(entry("192.0.2.2", 62*time.Second, 403)); rule != "errors" {
+		t.Errorf("fourth error of five requests violated %q, want errors", rule)
+	}
+
+	for i := range 5 {
+		if rule := w.record(entry("203.0.113.9", time.Duration(i), 200)); rule != "" {
+			t.Fatalf("whitelisted address violated %q", rule)
+		}
+	}
+
+	// forget drops addresses idle for longer than the longest window
+	w.record(entry("192.0.2.3", 70*time.Second, 200))
+	w.latest = start.Add(200 * time.Second)
+	w.forget()
+	if len(w.history) != 0 {
+		t.Errorf("history = %v, want it forgotten", w.history)
+	}
+}
+
+func TestAccessLogWatcherTimedBanExpires(t *testing.T) {
+	rule := AccessLogRule{Name: "burst", Window: time.Minute, MaxRequests: 1}
+	w, err := NewAccessLogWatcher(nil, 1, &BanManager{}, time.Minute, rule)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	entry := AccessLogEntry{RemoteAddr: netip.MustParseAddr("192.0.2.1"), Time: start}
+
+	w.record(entry)
+	entry.Time = start.Add(time.Second)
+	if rule := w.record(entry); rule != "burst" {
+		t.Fatalf("violated %q, want burst", rule)
+	}
+
+	w.latest = start.Add(2 * time.Minute)
+	w.forget()
+	if len(w.banned) != 0 {
+		t.Fatalf("banned = %v, want the expired ban forgotten", w.banned)
+	}
+
+	entry.Time = start.Add(2 * time.Minute)
+	w.record(entry)
+	entry.Time = entry.Time.Add(time.Second)
+	if rule := w.record(entry); rule != "burst" {
+		t.Errorf("violated %q after the ban expired, want burst", rule)
+	}
+}
+
+func TestAccessLogWatcherProcessWhitelistPages(t *testing.T) {
+	wl := &fakeWhitelists{}
+	for i := range listPageSize {
+		wl.existing = append(wl.existing, Whitelist{ID: int64(i + 1), IP: fmt.Sprintf("10.0.0.%d", i)})
+	}
+	wl.existing = append(wl.existing, Whitelist{ID: 500, IP: "192.0.2.0/24"})
+	bl := &fakeBlacklists{}
+
+	w, err := NewAccessLogWatcher(&Client{Whitelists: wl, Blacklists: bl}, 1, nil, 0, AccessLogRule{Name: "any", MaxRequests: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var bans []AccessLogBan
+	w.OnBan = func(b AccessLogBan) {
+		bans = append(bans, b)
+	}
+
+	now := time.Now()
+	for _, addr := range []string{"192.0.2.1", "192.0.2.1", "198.51.100.1", "198.51.100.1", "198.51.100.1"} {
+		if _, err := w.Process(context.Background(), AccessLogEntry{RemoteAddr: netip.MustParseAddr(addr), Time: now}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !slices.Equal(bl.created, []string{"198.51.100.1"}) {
+		t.Errorf("created = %v, want only the address outside the second whitelist page", bl.created)
+	}
+	if len(bans) != 1 || bans[0].Rule != "any" {
+		t.Errorf("bans = %+v", bans)
+	}
+}
+
+func TestAccessLogTail(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "access.log")
+	writeFile(t, path, "old line\n", os.O_CREATE|os.O_WRONLY)
+
+	tail := &accessLogTail{path: path}
+	if err := tail.open(true); err != nil {
+		t.Fatal(err)
+	}
+	defer tail.close()
+
+	var lines []string
+	poll := func(want ...string) {
+		t.Helper()
+
+		lines = nil
+		if err := tail.poll(func(line string) {
+			lines = append(lines, line)
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(lines, want) {
+			t.Errorf("lines = %q, want %q", lines, want)
+		}
+	}
+
+	// partial lines wait for their end
+	writeFile(t, path, "first\r\nsec", os.O_APPEND|os.O_WRONLY)
+	poll("first")
+	writeFile(t, path, "ond\n", os.O_APPEND|os.O_WRONLY)
+	poll("second")
+
+	// truncated in place
+	writeFile(t, path, "new\n", os.O_TRUNC|os.O_WRONLY)
+	poll("new")
+
+	// rotated, the rest of the old file is read before the new one
+	writeFile(t, path, "last of old\n", os.O_APPEND|os.O_WRONLY)
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, path, "first of new\n", os.O_CREATE|os.O_EXCL|os.O_WRONLY)
+	poll("last of old", "first of new")
+
+	// removed until recreated
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	poll()
+}
+
+func mustIPRange(t *testing.T, s string) ipRange {
+	t.Helper()
+
+	r, err := parseIPRange(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return r
+}
+
+func writeFile(t *testing.T, path, data string, flag int) {
+	t.Helper()
+
+	f, err := os.OpenFile(path, flag, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(data); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,287 @@
+package edgecenterprotection_go
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultMetricsNamespace = "edgecenter_protection"
+
+// DefaultLatencyBuckets are the upper bounds in seconds of the request duration histogram of PrometheusMetrics
+var DefaultLatencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// RequestMetrics describes a completed API request
+type RequestMetrics struct {
+	Method string
+
+	// Endpoint is the request path relative to the base URL with IDs replaced, e.g. /v2/resources/{id}/origins
+	Endpoint string
+
+	// StatusCode is 0 if no API response was received, e.g. on transport and decoding errors
+	StatusCode int
+
+	// Duration includes every attempt and the waits between them
+	Duration time.Duration
+
+	Attempts int
+}
+
+// StatusClass returns the class of the status code, e.g. 2xx, or "error" if no API response was received
+func (m RequestMetrics) StatusClass() string {
+	if m.StatusCode == 0 {
+		return "error"
+	}
+
+	return fmt.Sprintf("%dxx", m.StatusCode/100)
+}
+
+// MetricsRecorder records API requests, see WithMetrics
+type MetricsRecorder interface {
+	RecordRequest(m RequestMetrics)
+}
+
+// WithMetrics is a client option that records every API request to the recorder, e.g. PrometheusMetrics
+func WithMetrics(recorder MetricsRecorder) ClientOpt {
+	return func(c *Client) error {
+		if recorder == nil {
+			return NewArgError("recorder", "cannot be nil")
+		}
+
+		c.metrics = recorder
+
+		return nil
+	}
+}
+
+// endpointKey identifies the metrics of an endpoint
+type endpointKey struct {
+	method   string
+	endpoint string
+}
+
+// endpointMetrics are the metrics of an endpoint
+type endpointMetrics struct {
+	requests uint64
+	retries  uint64
+	errors   map[string]uint64
+
+	// buckets counts durations per histogram bucket, not cumulative
+	buckets []uint64
+	sum     float64
+}
+
+// PrometheusMetrics is a MetricsRecorder serving the recorded metrics in the Prometheus text exposition format:
+// request counts, request duration histograms, error counts by status class and retry counts per endpoint
+type PrometheusMetrics struct {
+	namespace string
+	buckets   []float64
+
+	mu        sync.Mutex
+	endpoints map[endpointKey]*endpointMetrics
+}
+
+var (
+	_ MetricsRecorder = (*PrometheusMetrics)(nil)
+	_ http.Handler    = (*PrometheusMetrics)(nil)
+	_ io.WriterTo     = (*PrometheusMetrics)(nil)
+)
+
+// NewPrometheusMetrics returns an empty PrometheusMetrics. An empty namespace selects edgecenter_protection
+// and nil buckets select DefaultLatencyBuckets. Bucket bounds are inclusive, as the le label of Prometheus.
+func NewPrometheusMetrics(namespace string, buckets []float64) (*PrometheusMetrics, error) {
+	if namespace == "" {
+		namespace = defaultMetricsNamespace
+	}
+
+	for i, r := range namespace {
+		if !(r == '_' || r == ':' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && r >= '0' && r <= '9') {
+			return nil, NewArgError("namespace", fmt.Sprintf("%q is not a valid metric name prefix", namespace))
+		}
+	}
+
+	if buckets == nil {
+		buckets = DefaultLatencyBuckets
+	}
+
+	buckets = slices.Clone(buckets)
+	for _, le := range buckets {
+		if math.IsNaN(le) || math.IsInf(le, 0) {
+			return nil, NewArgError("buckets", "must be finite, the +Inf bucket is always written")
+		}
+	}
+	slices.Sort(buckets)
+	if len(slices.Compact(buckets)) != len(buckets) {
+		return nil, NewArgError("buckets", "must be unique")
+	}
+
+	return &PrometheusMetrics{
+		namespace: namespace,
+		buckets:   buckets,
+		endpoints: make(map[endpointKey]*endpointMetrics),
+	}, nil
+}
+
+// RecordRequest adds the request to the metrics of its endpoint
+func (p *PrometheusMetrics) RecordRequest(m RequestMetrics) {
+	key := endpointKey{method: m.Method, endpoint: m.Endpoint}
+	seconds := m.Duration.Seconds()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	em, ok := p.endpoints[key]
+	if !ok {
+		em = &endpointMetrics{errors: make(map[string]uint64), buckets: make([]uint64, len(p.buckets))}
+		p.endpoints[key] = em
+	}
+
+	em.requests++
+	if m.Attempts > 1 {
+		em.retries += uint64(m.Attempts - 1)
+	}
+	if m.StatusCode == 0 || m.StatusCode >= http.StatusBadRequest {
+		em.errors[m.StatusClass()]++
+	}
+
+	if i, _ := slices.BinarySearch(p.buckets, seconds); i < len(p.buckets) {
+		em.buckets[i]++
+	}
+	em.sum += seconds
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format
+func (p *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = p.WriteTo(w)
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format
+func (p *PrometheusMetrics) WriteTo(w io.Writer) (int64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	keys := make([]endpointKey, 0, len(p.endpoints))
+	for k := range p.endpoints {
+		keys = append(keys, k)
+	}
+	slices.SortFunc(keys, func(a, b endpointKey) int {
+		if c := strings.Compare(a.endpoint, b.endpoint); c != 0 {
+			return c
+		}
+		return strings.Compare(a.method, b.method)
+	})
+
+	var b strings.Builder
+
+	name := p.namespace + "_requests_total"
+	fmt.Fprintf(&b, "# HELP %s Number of API requests.\n# TYPE %s counter\n", name, name)
+	for _, k := range keys {
+		fmt.Fprintf(&b, "%s{%s} %d\n", name, k.labels(), p.endpoints[k].requests)
+	}
+
+	name = p.namespace + "_request_errors_total"
+	fmt.Fprintf(&b, "# HELP %s Number of failed API requests by status class.\n# TYPE %s counter\n", name, name)
+	for _, k := range keys {
+		em := p.endpoints[k]
+		classes := make([]string, 0, len(em.errors))
+		for class := range em.errors {
+			classes = append(classes, class)
+		}
+		slices.Sort(classes)
+
+		for _, class := range classes {
+			fmt.Fprintf(&b, "%s{%s,class=%s} %d\n", name, k.labels(), quoteLabelValue(class), em.errors[class])
+		}
+	}
+
+	name = p.namespace + "_request_retries_total"
+	fmt.Fprintf(&b, "# HELP %s Number of retried API request attempts.\n# TYPE %s counter\n", name, name)
+	for _, k := range keys {
+		fmt.Fprintf(&b, "%s{%s} %d\n", name, k.labels(), p.endpoints[k].retries)
+	}
+
+	name = p.namespace + "_request_duration_seconds"
+	fmt.Fprintf(&b, "# HELP %s Duration of API requests including retries.\n# TYPE %s histogram\n", name, name)
+	for _, k := range keys {
+		em := p.endpoints[k]
+
+		var cumulative uint64
+		for i, le := range p.buckets {
+			cumulative += em.buckets[i]
+			fmt.Fprintf(&b, "%s_bucket{%s,le=\"%s\"} %d\n", name, k.labels(), strconv.FormatFloat(le, 'g', -1, 64), cumulative)
+		}
+		fmt.Fprintf(&b, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, k.labels(), em.requests)
+		fmt.Fprintf(&b, "%s_sum{%s} %s\n", name, k.labels(), strconv.FormatFloat(em.sum, 'g', -1, 64))
+		fmt.Fprintf(&b, "%s_count{%s} %d\n", name, k.labels(), em.requests)
+	}
+
+	n, err := io.WriteString(w, b.String())
+
+	return int64(n), err
+}
+
+// labels returns the method and endpoint labels of the metrics
+func (k endpointKey) labels() string {
+	return "method=" + quoteLabelValue(k.method) + ",endpoint=" + quoteLabelValue(k.endpoint)
+}
+
+// labelValueEscaper escapes label values as required by the Prometheus text format
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// quoteLabelValue returns the quoted and escaped label value
+func quoteLabelValue(v string) string {
+	return `"` + labelValueEscaper.Replace(v) + `"`
+}
+
+// metricsEndpoint returns the request path relative to the base path with numeric segments replaced by {id},
+// so the number of endpoints stays bounded
+func metricsEndpoint(basePath, urlPath string) string {
+	basePath = strings.TrimSuffix(basePath, "/")
+	if rel, ok := strings.CutPrefix(urlPath, basePath); ok && (rel == "" || rel[0] == '/') {
+		urlPath = rel
+	}
+
+	segments := strings.Split(urlPath, "/")
+	for i, s := range segments {
+		if s != "" && strings.Trim(s, "0123456789") == "" {
+			segments[i] = "{id}"
+		}
+	}
+
+	endpoint := strings.Join(segments, "/")
+	if !strings.HasPrefix(endpoint, "/") {
+		endpoint = "/" + endpoint
+	}
+
+	return endpoint
+}
+
+// recordMetrics records the outcome of an API request
+func (c *Client) recordMetrics(req *http.Request, resp *Response, err error, attempts int, duration time.Duration) {
+	c.metrics.RecordRequest(RequestMetrics{
+		Method:     req.Method,
+		Endpoint:   metricsEndpoint(c.BaseURL.Path, req.URL.Path),
+		StatusCode: apiStatusCode(resp, err),
+		Duration:   duration,
+		Attempts:   attempts,
+	})
+}
+
+// apiStatusCode returns the status code of the API response, or 0 after a transport error, when Do makes up
+// the response, or if the response body could not be decoded
+func apiStatusCode(resp *Response, err error) int {
+	var respErr *ResponseError
+	if resp == nil || resp.Response == nil || err != nil && !errors.As(err, &respErr) {
+		return 0
+	}
+
+	return resp.StatusCode
+}
@@ -0,0 +1,259 @@
+package edgecenterprotection_go
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// metricsRecorder collects the recorded requests
+type metricsRecorder struct {
+	mu       sync.Mutex
+	requests []RequestMetrics
+}
+
+func (r *metricsRecorder) RecordRequest(m RequestMetrics) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, m)
+}
+
+func TestPrometheusMetricsWriteTo(t *testing.T) {
+	p, err := NewPrometheusMetrics("test", []float64{1, 0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p.RecordRequest(RequestMetrics{Method: "GET", Endpoint: "/v2/resources/{id}", StatusCode: 200, Duration: 250 * time.Millisecond, Attempts: 1})
+	p.RecordRequest(RequestMetrics{Method: "GET", Endpoint: "/v2/resources/{id}", StatusCode: 503, Duration: time.Second, Attempts: 3})
+	p.RecordRequest(RequestMetrics{Method: "POST", Endpoint: "/v2/resources", Duration: 2 * time.Second, Attempts: 1})
+	p.RecordRequest(RequestMetrics{Method: "DELETE", Endpoint: "/v2/resources", StatusCode: 404, Duration: 500 * time.Millisecond, Attempts: 1})
+
+	want := `# HELP test_requests_total Number of API requests.
+# TYPE test_requests_total counter
+test_requests_total{method="DELETE",endpoint="/v2/resources"} 1
+test_requests_total{method="POST",endpoint="/v2/resources"} 1
+test_requests_total{method="GET",endpoint="/v2/resources/{id}"} 2
+# HELP test_request_errors_total Number of failed API requests by status class.
+# TYPE test_request_errors_total counter
+test_request_errors_total{method="DELETE",endpoint="/v2/resources",class="4xx"} 1
+test_request_errors_total{method="POST",endpoint="/v2/resources",class="error"} 1
+test_request_errors_total{method="GET",endpoint="/v2/resources/{id}",class="5xx"} 1
+# HELP test_request_retries_total Number of retried API request attempts.
+# TYPE test_request_retries_total counter
+test_request_retries_total{method="DELETE",endpoint="/v2/resources"} 0
+test_request_retries_total{method="POST",endpoint="/v2/resources"} 0
+test_request_retries_total{method="GET",endpoint="/v2/resources/{id}"} 2
+# HELP test_request_duration_seconds Duration of API requests including retries.
+# TYPE test_request_duration_seconds histogram
+test_request_duration_seconds_bucket{method="DELETE",endpoint="/v2/resources",le="0.5"} 1
+test_request_duration_seconds_bucket{method="DELETE",endpoint="/v2/resources",le="1"} 1
+test_request_duration_seconds_bucket{method="DELETE",endpoint="/v2/resources",le="+Inf"} 1
+test_request_duration_seconds_sum{method="DELETE",endpoint="/v2/resources"} 0.5
+test_request_duration_seconds_count{method="DELETE",endpoint="/v2/resources"} 1
+test_request_duration_seconds_bucket{method="POST",endpoint="/v2/resources",le="0.5"} 0
+test_request_duration_seconds_bucket{method="POST",endpoint="/v2/resources",le="1"} 0
+test_request_duration_seconds_bucket{method="POST",endpoint="/v2/resources",le="+Inf"} 1
+test_request_duration_seconds_sum{method="POST",endpoint="/v2/resources"} 2
+test_request_duration_seconds_count{method="POST",endpoint="/v2/resources"} 1
+test_request_duration_seconds_bucket{method="GET",endpoint="/v2/resources/{id}",le="0.5"} 1
+test_request_duration_seconds_bucket{method="GET",endpoint="/v2/resources/{id}",le="1"} 2
+test_request_duration_seconds_bucket{method="GET",endpoint="/v2/resources/{id}",le="+Inf"} 2
+test_request_duration_seconds_sum{method="GET",endpoint="/v2/resources/{id}"} 1.25
+test_request_duration_seconds_count{method="GET",endpoint="/v2/resources/{id}"} 2
+`
+
+	var b strings.Builder
+	n, err := p.WriteTo(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != want {
+		t.Errorf("WriteTo() =\n%s\nwant\n%s", b.String(), want)
+	}
+	if n != int64(len(want)) {
+		t.Errorf("WriteTo() = %d bytes, want %d", n, len(want))
+	}
+
+	rec := httptest.NewRecorder()
+	p.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Body.String() != want || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Errorf("ServeHTTP() = %s %q", rec.Header().Get("Content-Type"), rec.Body.String())
+	}
+}
+
+func TestPrometheusMetricsBuckets(t *testing.T) {
+	p, err := NewPrometheusMetrics("", []float64{0.1, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// bounds are inclusive, durations above the last bound only count towards +Inf
+	for _, d := range []time.Duration{0, 100 * time.Millisecond, 100*time.Millisecond + time.Nanosecond, time.Second, time.Second + time.Nanosecond, time.Minute} {
+		p.RecordRequest(RequestMetrics{Method: "GET", Endpoint: "/v2/resources", StatusCode: 200, Duration: d, Attempts: 1})
+	}
+
+	var b strings.Builder
+	if _, err := p.WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		`edgecenter_protection_request_duration_seconds_bucket{method="GET",endpoint="/v2/resources",le="0.1"} 2`,
+		`edgecenter_protection_request_duration_seconds_bucket{method="GET",endpoint="/v2/resources",le="1"} 4`,
+		`edgecenter_protection_request_duration_seconds_bucket{method="GET",endpoint="/v2/resources",le="+Inf"} 6`,
+		`edgecenter_protection_request_duration_seconds_count{method="GET",endpoint="/v2/resources"} 6`,
+	} {
+		if !strings.Contains(b.String(), want+"\n") {
+			t.Errorf("output is missing %s:\n%s", want, b.String())
+		}
+	}
+
+	// successful requests have no error series
+	if strings.Contains(b.String(), "request_errors_total{") {
+		t.Errorf("output has errors of successful requests:\n%s", b.String())
+	}
+}
+
+func TestNewPrometheusMetricsArgs(t *testing.T) {
+	tests := []struct {
+		name      string
+		namespace string
+		buckets   []float64
+		wantArg   string
+	}{
+		{"defaults", "", nil, ""},
+		{"colons and digits", "app:edge_2", []float64{1}, ""},
+		{"leading digit", "2app", nil, "namespace"},
+		{"dash", "edge-center", nil, "namespace"},
+		{"duplicate buckets", "", []float64{1, 0.5, 1}, "buckets"},
+		{"infinite bucket", "", []float64{1, math.Inf(1)}, "buckets"},
+		{"NaN bucket", "", []float64{math.NaN()}, "buckets"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewPrometheusMetrics(tt.namespace, tt.buckets)
+			if tt.wantArg == "" {
+				if err != nil {
+					t.Errorf("NewPrometheusMetrics() = %v", err)
+				}
+				return
+			}
+
+			var argErr *ArgError
+			if !errors.As(err, &argErr) || argErr.arg != tt.wantArg {
+				t.Errorf("NewPrometheusMetrics() = %v, want %s ArgError", err, tt.wantArg)
+			}
+		})
+	}
+
+	// the caller's buckets are sorted in a copy
+	buckets := []float64{5, 1}
+	p, _ := NewPrometheusMetrics("", buckets)
+	if !slices.Equal(p.buckets, []float64{1, 5}) || buckets[0] != 5 {
+		t.Errorf("buckets = %v, caller's = %v", p.buckets, buckets)
+	}
+}
+
+func TestQuoteLabelValue(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"/v2/resources", `"/v2/resources"`},
+		{`a"b`, `"a\"b"`},
+		{`a\b`, `"a\\b"`},
+		{"a\nb", `"a\nb"`},
+		{`\"` + "\n", `"\\\"\n"`},
+		{"привет", `"привет"`},
+	}
+
+	for _, tt := range tests {
+		if got := quoteLabelValue(tt.in); got != tt.want {
+			t.Errorf("quoteLabelValue(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+
+	p, _ := NewPrometheusMetrics("", nil)
+	p.RecordRequest(RequestMetrics{Method: "GET", Endpoint: `/v2/"a"\b`, StatusCode: 200})
+
+	var b strings.Builder
+	_, _ = p.WriteTo(&b)
+	if !strings.Contains(b.String(), `edgecenter_protection_requests_total{method="GET",endpoint="/v2/\"a\"\\b"} 1`) {
+		t.Errorf("output does not escape the endpoint:\n%s", b.String())
+	}
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	tests := []struct {
+		base, path, want string
+	}{
+		{"", "/v2/resources", "/v2/resources"},
+		{"/", "/v2/resources/123/origins/45", "/v2/resources/{id}/origins/{id}"},
+		{"/protection", "/protection/v2/resources/1", "/v2/resources/{id}"},
+		{"/protection/", "/protection", "/"},
+		{"/protection", "/protectionv2/resources", "/protectionv2/resources"},
+		{"", "/v2/resources/abc1/headers", "/v2/resources/abc1/headers"},
+		{"", "v2/resources/7", "/v2/resources/{id}"},
+	}
+
+	for _, tt := range tests {
+		if got := metricsEndpoint(tt.base, tt.path); got != tt.want {
+			t.Errorf("metricsEndpoint(%q, %q) = %q, want %q", tt.base, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestRequestMetricsStatusClass(t *testing.T) {
+	for code, want := range map[int]string{0: "error", 200: "2xx", 204: "2xx", 429: "4xx", 503: "5xx"} {
+		if got := (RequestMetrics{StatusCode: code}).StatusClass(); got != want {
+			t.Errorf("StatusClass() of %d = %q, want %q", code, got, want)
+		}
+	}
+}
+
+func TestWithMetrics(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/resources/2":
+			w.WriteHeader(http.StatusNotFound)
+		case "/v2/resources/3":
+			_, _ = w.Write([]byte(`not json`))
+		default:
+			_, _ = w.Write([]byte(`{}`))
+		}
+	})
+
+	c := newTestClient(t, handler)
+	recorder := &metricsRecorder{}
+	if err := WithMetrics(recorder)(c); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []int64{1, 2, 3} {
+		_, _, _ = c.Resources.Get(context.Background(), id)
+	}
+
+	// a response that cannot be decoded is not an API status
+	want := []int{200, 404, 0}
+	if len(recorder.requests) != len(want) {
+		t.Fatalf("recorded %+v", recorder.requests)
+	}
+	for i, m := range recorder.requests {
+		if m.Method != http.MethodGet || m.Endpoint != "/v2/resources/{id}" || m.StatusCode != want[i] || m.Attempts != 1 || m.Duration <= 0 {
+			t.Errorf("request %d = %+v, want status %d", i, m, want[i])
+		}
+	}
+
+	var argErr *ArgError
+	if _, err := New(nil, WithMetrics(nil)); !errors.As(err, &argErr) {
+		t.Errorf("WithMetrics(nil) = %v, want ArgError", err)
+	}
+}
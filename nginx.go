@@ -0,0 +1,698 @@
+package edgecenterprotection_go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxNginxIncludeDepth limits nested includes, protecting against include cycles
+const maxNginxIncludeDepth = 16
+
+// NginxParseOptions configure ParseNginxConfig
+type NginxParseOptions struct {
+	// ReadFile reads configuration files, os.ReadFile by default
+	ReadFile func(path string) ([]byte, error)
+
+	// Glob expands include patterns, filepath.Glob by default
+	Glob func(pattern string) ([]string, error)
+
+	// Prefix is the directory relative include paths are resolved against, the directory of the main file by default
+	Prefix string
+
+	// OriginLimits rejects origins with settings above the limits, see WithOriginLimits
+	OriginLimits OriginLimits
+}
+
+// NginxSite is a site found in an nginx configuration, converted to the requests that onboard it
+type NginxSite struct {
+	Resource ResourceCreateRequest
+	Aliases  []AliasCreateRequest
+	Origins  []OriginCreateRequest
+
+	// Warnings describe parts of the configuration that could not be converted
+	Warnings []string
+
+	// Rejected lists the upstream servers and proxy_pass targets that are not valid origins
+	Rejected []NginxRejectedOrigin
+}
+
+// NginxRejectedOrigin is an origin of a site that failed validation and is not onboarded
+type NginxRejectedOrigin struct {
+	Origin OriginCreateRequest
+
+	// Pos is the file and line of the upstream server or proxy_pass directive
+	Pos string
+
+	Err error
+}
+
+// nginxDirective is a simple or block directive of an nginx configuration
+type nginxDirective struct {
+	name  string
+	args  []string
+	block []nginxDirective
+	pos   string
+}
+
+// nginxToken is a word or one of the special characters "{", "}" and ";"
+type nginxToken struct {
+	text   string
+	quoted bool
+	line   int
+}
+
+// nginxServer collects the settings of server blocks sharing their names
+type nginxServer struct {
+	names     []string
+	tls       bool
+	redirect  bool
+	protocols []string
+	proxyPass string
+	proxyPos  string
+	proxyRoot bool
+	warnings  []string
+}
+
+// nginxUpstream is a parsed upstream block
+type nginxUpstream struct {
+	servers  []nginxOrigin
+	ipHash   bool
+	warnings []string
+	rejected []NginxRejectedOrigin
+}
+
+// nginxOrigin is an origin with the position of the directive it was converted from
+type nginxOrigin struct {
+	origin OriginCreateRequest
+	pos    string
+}
+
+// ParseNginxConfig reads an nginx configuration with its includes and converts every server block with a usable
+// server_name into a site. Server blocks with the same first name, e.g. a port 80 block redirecting to HTTPS and
+// a port 443 block, are merged. Origins come from the proxy_pass of the server, either directly or from the
+// upstream block it refers to, with weight, max_fails, fail_timeout and backup mapped to origin settings.
+// Origins failing validation are left out of the site and reported in its Rejected list.
+func ParseNginxConfig(path string, opts *NginxParseOptions) ([]NginxSite, error) {
+	o := NginxParseOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.ReadFile == nil {
+		o.ReadFile = os.ReadFile
+	}
+	if o.Glob == nil {
+		o.Glob = filepath.Glob
+	}
+	if o.Prefix == "" {
+		o.Prefix = filepath.Dir(path)
+	}
+
+	directives, err := parseNginxFile(&o, path, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	upstreams := make(map[string]*nginxUpstream)
+	var servers []*nginxServer
+	collectNginx(directives, upstreams, &servers)
+
+	return nginxSites(servers, upstreams, o.OriginLimits), nil
+}
+
+// OnboardNginxSite creates the resource of the site with its origins and aliases. If any request fails,
+// the resource is deleted again and the error is returned.
+func OnboardNginxSite(ctx context.Context, c *Client, site *NginxSite) (*Resource, error) {
+	if site == nil {
+		return nil, NewArgError("site", "cannot be nil")
+	}
+
+	resource, _, err := c.Resources.Create(ctx, &site.Resource)
+	if err != nil {
+		return nil, err
+	}
+
+	fail := func(err error) (*Resource, error) {
+		if _, delErr := c.Resources.Delete(context.WithoutCancel(ctx), resource.ID); delErr != nil {
+			return resource, errors.Join(err, fmt.Errorf("deleting resource %d: %w", resource.ID, delErr))
+		}
+		return nil, err
+	}
+
+	for i := range site.Origins {
+		if _, _, err := c.Origins.Create(ctx, resource.ID, &site.Origins[i]); err != nil {
+			return fail(fmt.Errorf("creating origin %s: %w", site.Origins[i].IP, err))
+		}
+	}
+
+	for i := range site.Aliases {
+		if _, _, err := c.Aliases.Create(ctx, resource.ID, &site.Aliases[i]); err != nil {
+			return fail(fmt.Errorf("creating alias %s: %w", site.Aliases[i].Name, err))
+		}
+	}
+
+	return resource, nil
+}
+
+// parseNginxFile reads and parses a configuration file, expanding includes
+func parseNginxFile(o *NginxParseOptions, path string, depth int) ([]nginxDirective, error) {
+	if depth > maxNginxIncludeDepth {
+		return nil, fmt.Errorf("%s: includes are nested deeper than %d levels", path, maxNginxIncludeDepth)
+	}
+
+	data, err := o.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := tokenizeNginx(path, string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	directives, rest, err := parseNginxBlock(o, path, tokens, depth)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("%s:%d: unexpected \"}\"", path, rest[0].line)
+	}
+
+	return directives, nil
+}
+
+// parseNginxBlock parses directives until the end of tokens or a closing brace, returning the tokens after it
+func parseNginxBlock(o *NginxParseOptions, path string, tokens []nginxToken, depth int) ([]nginxDirective, []nginxToken, error) {
+	var directives []nginxDirective
+
+	for len(tokens) > 0 {
+		t := tokens[0]
+		if !t.quoted && t.text == "}" {
+			return directives, tokens, nil
+		}
+		if !t.quoted && (t.text == "{" || t.text == ";") {
+			return nil, nil, fmt.Errorf("%s:%d: unexpected %q", path, t.line, t.text)
+		}
+
+		d := nginxDirective{name: t.text, pos: fmt.Sprintf("%s:%d", path, t.line)}
+		tokens = tokens[1:]
+
+		for {
+			if len(tokens) == 0 {
+				return nil, nil, fmt.Errorf("%s: unexpected end of file, expecting \";\" or \"}\"", d.pos)
+			}
+
+			t = tokens[0]
+			tokens = tokens[1:]
+
+			if t.quoted || (t.text != ";" && t.text != "{" && t.text != "}") {
+				d.args = append(d.args, t.text)
+				continue
+			}
+
+			if t.text == "}" {
+				return nil, nil, fmt.Errorf("%s:%d: unexpected \"}\"", path, t.line)
+			}
+
+			if t.text == "{" {
+				var err error
+				d.block, tokens, err = parseNginxBlock(o, path, tokens, depth)
+				if err != nil {
+					return nil, nil, err
+				}
+				if len(tokens) == 0 {
+					return nil, nil, fmt.Errorf("%s: unexpected end of file, expecting \"}\"", d.pos)
+				}
+				tokens = tokens[1:]
+			}
+
+			break
+		}
+
+		if d.name == "include" && d.block == nil {
+			included, err := includeNginx(o, d, depth)
+			if err != nil {
+				return nil, nil, err
+			}
+			directives = append(directives, included...)
+			continue
+		}
+
+		directives = append(directives, d)
+	}
+
+	return directives, nil, nil
+}
+
+// includeNginx parses the files matched by an include directive
+func includeNginx(o *NginxParseOptions, d nginxDirective, depth int) ([]nginxDirective, error) {
+	if len(d.args) != 1 {
+		return nil, fmt.Errorf("%s: include must have one argument", d.pos)
+	}
+
+	pattern := d.args[0]
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(o.Prefix, pattern)
+	}
+
+	paths := []string{pattern}
+	if strings.ContainsAny(pattern, "*?[") {
+		var err error
+		if paths, err = o.Glob(pattern); err != nil {
+			return nil, fmt.Errorf("%s: %w", d.pos, err)
+		}
+	}
+
+	var directives []nginxDirective
+	for _, path := range paths {
+		included, err := parseNginxFile(o, path, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		directives = append(directives, included...)
+	}
+
+	return directives, nil
+}
+
+// tokenizeNginx splits a configuration into tokens, removing comments and resolving quotes and escapes
+func tokenizeNginx(path, data string) ([]nginxToken, error) {
+	var tokens []nginxToken
+
+	line := 1
+	for i := 0; i < len(data); {
+		c := data[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case c == '#':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+		case c == '{' || c == '}' || c == ';':
+			tokens = append(tokens, nginxToken{text: string(c), line: line})
+			i++
+		case c == '"' || c == '\'':
+			start := line
+			var b strings.Builder
+			for i++; ; i++ {
+				if i >= len(data) {
+					return nil, fmt.Errorf("%s:%d: unterminated quoted string", path, start)
+				}
+				if data[i] == c {
+					i++
+					break
+				}
+				if data[i] == '\\' && i+1 < len(data) {
+					i++
+				}
+				if data[i] == '\n' {
+					line++
+				}
+				b.WriteByte(data[i])
+			}
+			tokens = append(tokens, nginxToken{text: b.String(), quoted: true, line: start})
+		default:
+			var b strings.Builder
+			for i < len(data) && !strings.ContainsRune(" \t\r\n{};", rune(data[i])) {
+				if data[i] == '\\' && i+1 < len(data) {
+					i++
+				}
+				b.WriteByte(data[i])
+				i++
+			}
+			tokens = append(tokens, nginxToken{text: b.String(), line: line})
+		}
+	}
+
+	return tokens, nil
+}
+
+// collectNginx finds upstream and server blocks at the top level and in http blocks
+func collectNginx(directives []nginxDirective, upstreams map[string]*nginxUpstream, servers *[]*nginxServer) {
+	for _, d := range directives {
+		switch {
+		case d.name == "http" && d.block != nil:
+			collectNginx(d.block, upstreams, servers)
+		case d.name == "upstream" && d.block != nil && len(d.args) == 1:
+			upstreams[d.args[0]] = parseNginxUpstream(d)
+		case d.name == "server" && d.block != nil:
+			*servers = append(*servers, parseNginxServer(d))
+		}
+	}
+}
+
+// parseNginxServer extracts the settings of a server block
+func parseNginxServer(d nginxDirective) *nginxServer {
+	s := &nginxServer{}
+
+	for _, sd := range d.block {
+		switch sd.name {
+		case "server_name":
+			s.names = append(s.names, sd.args...)
+		case "listen":
+			for _, arg := range sd.args {
+				if arg == "ssl" || arg == "443" || strings.HasSuffix(arg, ":443") {
+					s.tls = true
+				}
+			}
+		case "ssl_protocols":
+			s.protocols = append(s.protocols, sd.args...)
+		case "return", "rewrite":
+			for _, arg := range sd.args {
+				if strings.HasPrefix(arg, "https://") {
+					s.redirect = true
+				}
+			}
+		case "location":
+			s.collectLocation(sd)
+		}
+	}
+
+	// a server that only redirects to HTTPS is the plain HTTP part of the site
+	s.redirect = s.redirect && s.proxyPass == ""
+
+	return s
+}
+
+// collectLocation takes proxy_pass from a location, preferring "location /" over others
+func (s *nginxServer) collectLocation(d nginxDirective) {
+	root := len(d.args) == 1 && d.args[0] == "/" || len(d.args) == 2 && d.args[0] == "=" && d.args[1] == "/"
+
+	for _, ld := range d.block {
+		switch ld.name {
+		case "proxy_pass":
+			s.setProxyPass(ld, root)
+		case "location":
+			s.collectLocation(ld)
+		}
+	}
+}
+
+func (s *nginxServer) setProxyPass(d nginxDirective, root bool) {
+	if len(d.args) != 1 {
+		s.warnings = append(s.warnings, fmt.Sprintf("%s: proxy_pass must have one argument", d.pos))
+		return
+	}
+
+	if s.proxyPass == "" || root && !s.proxyRoot {
+		s.proxyPass, s.proxyPos, s.proxyRoot = d.args[0], d.pos, root
+	} else if s.proxyPass != d.args[0] {
+		s.warnings = append(s.warnings, fmt.Sprintf("%s: proxy_pass %s ignored, the site already proxies to %s",
+			d.pos, d.args[0], s.proxyPass))
+	}
+}
+
+// parseNginxUpstream converts the servers of an upstream block to origins
+func parseNginxUpstream(d nginxDirective) *nginxUpstream {
+	u := &nginxUpstream{}
+
+	for _, ud := range d.block {
+		switch ud.name {
+		case "ip_hash":
+			u.ipHash = true
+		case "server":
+			if origin, ok := u.parseServer(ud); ok {
+				u.servers = append(u.servers, nginxOrigin{origin: origin, pos: ud.pos})
+			}
+		case "keepalive", "keepalive_timeout", "keepalive_requests", "zone":
+		default:
+			u.warnings = append(u.warnings, fmt.Sprintf("%s: upstream directive %s is not supported", ud.pos, ud.name))
+		}
+	}
+
+	return u
+}
+
+// parseServer converts a server of an upstream block to an origin
+func (u *nginxUpstream) parseServer(d nginxDirective) (OriginCreateRequest, bool) {
+	if len(d.args) == 0 {
+		u.warnings = append(u.warnings, fmt.Sprintf("%s: upstream server without an address", d.pos))
+		return OriginCreateRequest{}, false
+	}
+
+	if strings.HasPrefix(d.args[0], "unix:") {
+		u.warnings = append(u.warnings, fmt.Sprintf("%s: unix socket %s cannot be an origin", d.pos, d.args[0]))
+		return OriginCreateRequest{}, false
+	}
+
+	origin := OriginCreateRequest{IP: d.args[0], Mode: OriginModePrimary}
+	for _, param := range d.args[1:] {
+		name, value, _ := strings.Cut(param, "=")
+
+		var err error
+		switch name {
+		case "weight":
+			origin.Weight, err = strconv.Atoi(value)
+		case "max_fails":
+			origin.MaxFails, err = strconv.Atoi(value)
+		case "fail_timeout":
+			origin.FailTimeout, err = parseNginxSeconds(value)
+		case "backup":
+			origin.Mode = OriginModeBackup
+		case "down":
+			u.warnings = append(u.warnings, fmt.Sprintf("%s: server %s is marked down and skipped", d.pos, d.args[0]))
+			return OriginCreateRequest{}, false
+		default:
+			u.warnings = append(u.warnings, fmt.Sprintf("%s: server parameter %s is not supported", d.pos, name))
+		}
+
+		if err != nil {
+			u.rejected = append(u.rejected, NginxRejectedOrigin{
+				Origin: origin,
+				Pos:    d.pos,
+				Err:    NewArgError(name, fmt.Sprintf("%q is not a valid value", value)),
+			})
+			return OriginCreateRequest{}, false
+		}
+	}
+
+	return origin, true
+}
+
+// nginxSites merges server blocks by their first name and converts them to sites
+func nginxSites(servers []*nginxServer, upstreams map[string]*nginxUpstream, limits OriginLimits) []NginxSite {
+	var order []string
+	merged := make(map[string]*nginxServer)
+
+	for _, s := range servers {
+		if len(s.names) == 0 {
+			continue
+		}
+
+		key := strings.ToLower(s.names[0])
+		m, ok := merged[key]
+		if !ok {
+			merged[key] = s
+			order = append(order, key)
+			continue
+		}
+
+		for _, name := range s.names {
+			if !containsFold(m.names, name) {
+				m.names = append(m.names, name)
+			}
+		}
+		m.tls = m.tls || s.tls
+		m.redirect = m.redirect || s.redirect
+		m.protocols = append(m.protocols, s.protocols...)
+		m.warnings = append(m.warnings, s.warnings...)
+		if m.proxyPass == "" {
+			m.proxyPass, m.proxyPos, m.proxyRoot = s.proxyPass, s.proxyPos, s.proxyRoot
+		} else if s.proxyPass != "" && s.proxyPass != m.proxyPass {
+			m.warnings = append(m.warnings, fmt.Sprintf("%s: proxy_pass %s ignored, the site already proxies to %s",
+				s.proxyPos, s.proxyPass, m.proxyPass))
+		}
+	}
+
+	var sites []NginxSite
+	for _, key := range order {
+		if site, ok := merged[key].site(upstreams, limits); ok {
+			sites = append(sites, site)
+		}
+	}
+
+	return sites
+}
+
+// site converts merged server blocks to a site, reporting false if the server has no usable name
+func (s *nginxServer) site(upstreams map[string]*nginxUpstream, limits OriginLimits) (NginxSite, bool) {
+	site := NginxSite{Warnings: s.warnings}
+
+	var names []string
+	for _, name := range s.names {
+		switch {
+		case name == "_" || name == "" || name == "localhost":
+			continue
+		case strings.HasPrefix(name, "~"):
+			site.Warnings = append(site.Warnings, fmt.Sprintf("regular expression server name %s cannot be imported", name))
+			continue
+		case strings.HasPrefix(name, "."):
+			names = append(names, name[1:], "*"+name)
+			continue
+		case strings.HasSuffix(name, ".*"):
+			site.Warnings = append(site.Warnings, fmt.Sprintf("server name %s with a wildcard suffix cannot be imported", name))
+			continue
+		}
+		names = append(names, name)
+	}
+
+	var normalized []string
+	for _, name := range names {
+		n, err := NormalizeDomainName(name)
+		if err != nil {
+			site.Warnings = append(site.Warnings, err.Error())
+			continue
+		}
+		if !containsFold(normalized, n) {
+			normalized = append(normalized, n)
+		}
+	}
+
+	for _, name := range normalized {
+		if site.Resource.Name == "" && !isWildcardDomain(name) {
+			site.Resource.Name = name
+			continue
+		}
+		site.Aliases = append(site.Aliases, AliasCreateRequest{Name: name})
+		if isWildcardDomain(name) {
+			site.Resource.WidlcardAliases = true
+		}
+	}
+
+	if site.Resource.Name == "" {
+		return NginxSite{}, false
+	}
+
+	site.Resource.Active = true
+	site.Resource.RedirectToHTTPS = s.redirect
+	site.Resource.TLSEnabled = nginxTLSVersions(s.protocols)
+	if s.proxyPass == "" {
+		site.Warnings = append(site.Warnings, fmt.Sprintf("server %s has no proxy_pass, no origins imported", site.Resource.Name))
+	} else {
+		s.origins(&site, upstreams, limits)
+	}
+	site.Resource.MultipleOrigins = len(site.Origins) > 1
+
+	return site, true
+}
+
+// origins converts the proxy_pass of the server to origins of the site, rejecting the ones failing validation
+func (s *nginxServer) origins(site *NginxSite, upstreams map[string]*nginxUpstream, limits OriginLimits) {
+	if strings.Contains(s.proxyPass, "$") {
+		site.Warnings = append(site.Warnings, fmt.Sprintf("%s: proxy_pass with variables cannot be imported", s.proxyPos))
+		return
+	}
+
+	u, err := url.Parse(s.proxyPass)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		site.Warnings = append(site.Warnings, fmt.Sprintf("%s: proxy_pass %s is not an HTTP URL", s.proxyPos, s.proxyPass))
+		return
+	}
+
+	if s.tls && u.Scheme == "http" {
+		site.Resource.HTTPS2HTTP = HTTPS2HTTPEnabled
+	}
+
+	var origins []nginxOrigin
+	if upstream, ok := upstreams[u.Host]; ok {
+		site.Warnings = append(site.Warnings, upstream.warnings...)
+		site.Rejected = append(site.Rejected, upstream.rejected...)
+		origins = upstream.servers
+		if upstream.ipHash {
+			site.Resource.IPHash = IPHashEnabled
+		}
+	} else {
+		origins = []nginxOrigin{{origin: OriginCreateRequest{IP: u.Host, Mode: OriginModePrimary}, pos: s.proxyPos}}
+	}
+
+	for _, o := range origins {
+		if err := ValidateOriginCreateRequest(o.origin, limits); err != nil {
+			site.Rejected = append(site.Rejected, NginxRejectedOrigin{Origin: o.origin, Pos: o.pos, Err: err})
+			continue
+		}
+		site.Origins = append(site.Origins, o.origin)
+	}
+}
+
+// nginxTLSVersions maps ssl_protocols to the TLS versions of the resource, TLS 1.2 and 1.3 by default
+func nginxTLSVersions(protocols []string) []string {
+	var versions []string
+	for _, p := range protocols {
+		v, ok := map[string]string{"TLSv1": "1", "TLSv1.1": "1.1", "TLSv1.2": "1.2", "TLSv1.3": "1.3"}[p]
+		if ok && !containsFold(versions, v) {
+			versions = append(versions, v)
+		}
+	}
+
+	if len(versions) == 0 {
+		return []string{"1.2", "1.3"}
+	}
+
+	return versions
+}
+
+// parseNginxSeconds parses an nginx time such as "30", "10s" or "1m30s" and rounds it up to whole seconds
+func parseNginxSeconds(s string) (int, error) {
+	units := []struct {
+		suffix string
+		d      time.Duration
+	}{
+		{"ms", time.Millisecond}, {"s", time.Second}, {"m", time.Minute}, {"h", time.Hour},
+		{"d", 24 * time.Hour}, {"w", 7 * 24 * time.Hour},
+	}
+
+	if n, err := strconv.Atoi(s); err == nil && n >= 0 {
+		return n, nil
+	}
+
+	var total time.Duration
+	for rest := s; rest != ""; {
+		i := strings.IndexFunc(rest, func(r rune) bool { return r < '0' || r > '9' })
+		if i <= 0 {
+			return 0, fmt.Errorf("invalid time %q", s)
+		}
+
+		n, err := strconv.Atoi(rest[:i])
+		if err != nil {
+			return 0, fmt.Errorf("invalid time %q", s)
+		}
+		rest = rest[i:]
+
+		found := false
+		for _, u := range units {
+			if strings.HasPrefix(rest, u.suffix) {
+				total += time.Duration(n) * u.d
+				rest = rest[len(u.suffix):]
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("invalid time %q", s)
+		}
+	}
+
+	return int(math.Ceil(total.Seconds())), nil
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+
+	return false
+}
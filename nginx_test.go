@@ -0,0 +1,232 @@
+package edgecenterprotection_go
+
+import (
+	"errors"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestTokenizeNginx(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    []string
+		lines   []int
+		wantErr bool
+	}{
+		{
+			name:  "simple directive",
+			in:    "listen 443 ssl;",
+			want:  []string{"listen", "443", "ssl", ";"},
+			lines: []int{1, 1, 1, 1},
+		},
+		{
+			name:  "comments",
+			in:    "# server {\nworker_processes 4; # trailing ; comment\n",
+			want:  []string{"worker_processes", "4", ";"},
+			lines: []int{2, 2, 2},
+		},
+		{
+			name:  "quoted strings",
+			in:    `add_header X-Test "a; b { c }" 'it\'s';`,
+			want:  []string{"add_header", "X-Test", "a; b { c }", "it's", ";"},
+			lines: []int{1, 1, 1, 1, 1},
+		},
+		{
+			name:  "multiline quoted string",
+			in:    "return 200 \"one\ntwo\";\nlisten 80;",
+			want:  []string{"return", "200", "one\ntwo", ";", "listen", "80", ";"},
+			lines: []int{1, 1, 1, 2, 3, 3, 3},
+		},
+		{
+			name:  "blocks and escapes",
+			in:    "location ~ \\.php$ {\n\tproxy_pass http://php;\n}",
+			want:  []string{"location", "~", ".php$", "{", "proxy_pass", "http://php", ";", "}"},
+			lines: []int{1, 1, 1, 1, 2, 2, 2, 3},
+		},
+		{
+			name:    "unterminated quote",
+			in:      `server_name "example.com;`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, err := tokenizeNginx("test.conf", tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("tokenizeNginx() = %v, want error", tokens)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var texts []string
+			var lines []int
+			for _, tok := range tokens {
+				texts = append(texts, tok.text)
+				lines = append(lines, tok.line)
+			}
+			if !slices.Equal(texts, tt.want) || !slices.Equal(lines, tt.lines) {
+				t.Errorf("tokens = %q at lines %v, want %q at lines %v", texts, lines, tt.want, tt.lines)
+			}
+		})
+	}
+}
+
+// parseNginxFiles parses nginx.conf from an in-memory set of files
+func parseNginxFiles(t *testing.T, files map[string]string, limits OriginLimits) ([]NginxSite, error) {
+	t.Helper()
+
+	return ParseNginxConfig("/etc/nginx/nginx.conf", &NginxParseOptions{
+		ReadFile: func(name string) ([]byte, error) {
+			data, ok := files[name]
+			if !ok {
+				return nil, os.ErrNotExist
+			}
+			return []byte(data), nil
+		},
+		Glob: func(pattern string) ([]string, error) {
+			var matches []string
+			for name := range files {
+				if ok, _ := path.Match(pattern, name); ok {
+					matches = append(matches, name)
+				}
+			}
+			slices.Sort(matches)
+			return matches, nil
+		},
+		OriginLimits: limits,
+	})
+}
+
+func TestParseNginxConfig(t *testing.T) {
+	files := map[string]string{
+		"/etc/nginx/nginx.conf": `
+http {
+	include conf.d/*.conf;
+}
+`,
+		"/etc/nginx/conf.d/a.conf": `
+upstream app {
+	ip_hash;
+	server 10.0.0.1:8080 weight=5 max_fails=3 fail_timeout=1m;
+	server 10.0.0.2:8080 backup;
+	server 10.0.0.3:8080 down;
+	server 10.0.0.4:8080 weight=500;
+	server 10.0.0.5:8080 weight=heavy;
+}
+
+server {
+	listen 80;
+	server_name example.com .example.org;
+	return 301 https://$host$request_uri;
+}
+
+server {
+	listen 443 ssl; # TLS part of the site
+	server_name "example.com" www.example.com *.example.com example.* ~^api\d+\.example\.com$;
+	ssl_protocols TLSv1.2 TLSv1.3;
+	location / {
+		proxy_pass http://app;
+	}
+}
+`,
+		"/etc/nginx/conf.d/b.conf": `
+server {
+	server_name пример.рф;
+	location /api/ {
+		proxy_pass http://192.0.2.10:8000;
+	}
+}
+`,
+	}
+
+	sites, err := parseNginxFiles(t, files, OriginLimits{MaxWeight: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sites) != 2 {
+		t.Fatalf("got %d sites, want 2: %+v", len(sites), sites)
+	}
+
+	site := sites[0]
+	if site.Resource.Name != "example.com" || !site.Resource.RedirectToHTTPS || !site.Resource.WidlcardAliases ||
+		site.Resource.IPHash != IPHashEnabled || !site.Resource.MultipleOrigins {
+		t.Errorf("resource = %+v", site.Resource)
+	}
+
+	var aliases []string
+	for _, a := range site.Aliases {
+		aliases = append(aliases, a.Name)
+	}
+	if want := []string{"example.org", "*.example.org", "www.example.com", "*.example.com"}; !slices.Equal(aliases, want) {
+		t.Errorf("aliases = %v, want %v", aliases, want)
+	}
+
+	wantOrigins := []OriginCreateRequest{
+		{IP: "10.0.0.1:8080", Mode: OriginModePrimary, Weight: 5, MaxFails: 3, FailTimeout: 60},
+		{IP: "10.0.0.2:8080", Mode: OriginModeBackup},
+	}
+	if !slices.Equal(site.Origins, wantOrigins) {
+		t.Errorf("origins = %+v, want %+v", site.Origins, wantOrigins)
+	}
+
+	if len(site.Rejected) != 2 {
+		t.Fatalf("rejected = %+v, want 2 entries", site.Rejected)
+	}
+	for i, want := range []struct{ ip, arg string }{{"10.0.0.5:8080", "weight"}, {"10.0.0.4:8080", "Weight"}} {
+		r := site.Rejected[i]
+		var argErr *ArgError
+		if r.Origin.IP != want.ip || !errors.As(r.Err, &argErr) || argErr.arg != want.arg || !strings.HasPrefix(r.Pos, "/etc/nginx/conf.d/a.conf:") {
+			t.Errorf("rejected[%d] = %+v, want %s rejected for %s", i, r, want.ip, want.arg)
+		}
+	}
+
+	joined := strings.Join(site.Warnings, "\n")
+	for _, w := range []string{"10.0.0.3:8080 is marked down", "example.*", "regular expression"} {
+		if !strings.Contains(joined, w) {
+			t.Errorf("warnings %q do not mention %q", site.Warnings, w)
+		}
+	}
+
+	site = sites[1]
+	if site.Resource.Name != "xn--e1afmkfd.xn--p1ai" || len(site.Origins) != 1 || site.Origins[0].IP != "192.0.2.10:8000" {
+		t.Errorf("site = %+v", site)
+	}
+
+	sites, err = parseNginxFiles(t, files, OriginLimits{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sites[0].Origins) != 3 || len(sites[0].Rejected) != 1 {
+		t.Errorf("without limits origins = %+v, rejected = %+v", sites[0].Origins, sites[0].Rejected)
+	}
+}
+
+func TestParseNginxConfigErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+	}{
+		{name: "missing include", files: map[string]string{"/etc/nginx/nginx.conf": "include missing.conf;"}},
+		{name: "include cycle", files: map[string]string{"/etc/nginx/nginx.conf": "include nginx.conf;"}},
+		{name: "unbalanced braces", files: map[string]string{"/etc/nginx/nginx.conf": "http { server {}"}},
+		{name: "stray brace", files: map[string]string{"/etc/nginx/nginx.conf": "}"}},
+		{name: "missing semicolon", files: map[string]string{"/etc/nginx/nginx.conf": "worker_processes 4"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if sites, err := parseNginxFiles(t, tt.files, OriginLimits{}); err == nil {
+				t.Errorf("ParseNginxConfig() = %+v, want error", sites)
+			}
+		})
+	}
+}
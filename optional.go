@@ -0,0 +1,79 @@
+package edgecenterprotection_go
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Optional is a field of a partial update request that can be left unset, set to a value or set to null.
+// The zero value is unset and is omitted from the request, use NewOptional and NullOptional to set it.
+type Optional[T any] struct {
+	value T
+	set   bool
+	null  bool
+}
+
+// NewOptional returns an Optional set to v
+func NewOptional[T any](v T) Optional[T] {
+	return Optional[T]{value: v, set: true}
+}
+
+// NullOptional returns an Optional set to null
+func NullOptional[T any]() Optional[T] {
+	return Optional[T]{set: true, null: true}
+}
+
+// OptionalFromPtr returns an Optional set to *p, or set to null if p is nil
+func OptionalFromPtr[T any](p *T) Optional[T] {
+	if p == nil {
+		return NullOptional[T]()
+	}
+
+	return NewOptional(*p)
+}
+
+// IsSet reports whether the field is sent in the request, either with a value or as null
+func (o Optional[T]) IsSet() bool {
+	return o.set
+}
+
+// IsNull reports whether the field is set to null
+func (o Optional[T]) IsNull() bool {
+	return o.null
+}
+
+// Get returns the value of the field and whether it is set to a value
+func (o Optional[T]) Get() (T, bool) {
+	return o.value, o.set && !o.null
+}
+
+// IsZero reports whether the field is unset, making the omitzero option of encoding/json omit it
+func (o Optional[T]) IsZero() bool {
+	return !o.set
+}
+
+// MarshalJSON encodes the value of the field or null
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if !o.set || o.null {
+		return []byte("null"), nil
+	}
+
+	return json.Marshal(o.value)
+}
+
+// UnmarshalJSON sets the field to the decoded value or to null
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		*o = NullOptional[T]()
+		return nil
+	}
+
+	var v T
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	*o = NewOptional(v)
+
+	return nil
+}
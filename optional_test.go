@@ -0,0 +1,45 @@
+package edgecenterprotection_go
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestResourceUpdateRequestOptionalFields(t *testing.T) {
+	tests := []struct {
+		name string
+		req  ResourceUpdateRequest
+		want string
+	}{
+		{name: "unset", req: ResourceUpdateRequest{WAF: PtrTo(true)}, want: `{"is_waf_enabled":true}`},
+		{name: "null", req: ResourceUpdateRequest{SSLType: NullOptional[string]()}, want: `{"ssl_type":null}`},
+		{name: "empty", req: ResourceUpdateRequest{SSLType: NewOptional(""), TLSEnabled: NewOptional([]string{})}, want: `{"tls_enabled":[],"ssl_type":""}`},
+		{name: "value", req: ResourceUpdateRequest{SSLType: OptionalFromPtr(PtrTo("le")), TLSEnabled: NewOptional([]string{"1.3"})}, want: `{"tls_enabled":["1.3"],"ssl_type":"le"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("Marshal() = %s, want %s", data, tt.want)
+			}
+		})
+	}
+}
+
+func TestOptionalUnmarshalJSON(t *testing.T) {
+	var req ResourceUpdateRequest
+	if err := json.Unmarshal([]byte(`{"ssl_type":null,"tls_enabled":["1.2"]}`), &req); err != nil {
+		t.Fatal(err)
+	}
+
+	if !req.SSLType.IsSet() || !req.SSLType.IsNull() {
+		t.Errorf("SSLType = %+v, want null", req.SSLType)
+	}
+	if v, ok := req.TLSEnabled.Get(); !ok || len(v) != 1 || v[0] != "1.2" {
+		t.Errorf("TLSEnabled = %v, %v", v, ok)
+	}
+}
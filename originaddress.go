@@ -0,0 +1,223 @@
+package edgecenterprotection_go
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// OriginLimits are upper bounds of origin settings checked by ValidateOriginCreateRequest, see WithOriginLimits.
+// The API documents no bounds, so zero fields are not checked.
+type OriginLimits struct {
+	MaxWeight      int
+	MaxFails       int
+	MaxFailTimeout int
+}
+
+// WithOriginLimits is a client option that makes Origins.Create and Origins.Update refuse origins with settings
+// above the limits, e.g. the ones of the DDoS protection plan
+func WithOriginLimits(limits OriginLimits) ClientOpt {
+	return func(c *Client) error {
+		if limits.MaxWeight < 0 || limits.MaxFails < 0 || limits.MaxFailTimeout < 0 {
+			return NewArgError("limits", "cannot be negative")
+		}
+
+		c.originLimits = limits
+
+		return nil
+	}
+}
+
+// OriginMode defines whether the origin receives traffic normally or only when all primary origins are down
+type OriginMode string
+
+const (
+	OriginModePrimary OriginMode = "primary"
+	OriginModeBackup  OriginMode = "backup"
+)
+
+// Valid reports whether the mode is known to the API
+func (m OriginMode) Valid() bool {
+	return m == OriginModePrimary || m == OriginModeBackup
+}
+
+// OriginAddress is a parsed origin address: an IP address or a host name, with an optional port
+type OriginAddress struct {
+	// IP is the address of the origin, it is invalid if the origin is given by a host name
+	IP netip.Addr
+
+	// Host is the host name of the origin in punycode form, it is empty if the origin is given by an IP address
+	Host string
+
+	// Port is zero if the address has no port
+	Port uint16
+}
+
+// ParseOriginAddress parses an origin address in any of the forms accepted by the API: "192.0.2.1", "192.0.2.1:8080",
+// "2001:db8::1", "[2001:db8::1]:8080", "origin.example.com" or "origin.example.com:8080".
+// Host names are normalized with NormalizeDomainName.
+func ParseOriginAddress(s string) (OriginAddress, error) {
+	return parseOriginAddress(s, true)
+}
+
+// parseOriginAddress parses an origin address, rejecting IP addresses the edge cannot reach if checkReachable is set
+func parseOriginAddress(s string, checkReachable bool) (OriginAddress, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return OriginAddress{}, NewArgError("IP", "cannot be empty")
+	}
+
+	if strings.Contains(s, "://") || strings.ContainsAny(s, "/?#@") {
+		return OriginAddress{}, NewArgError("IP", fmt.Sprintf("%q must be a host with an optional port, not a URL", s))
+	}
+
+	if ip, err := netip.ParseAddr(s); err == nil {
+		return originAddressFromIP(s, ip, 0, checkReachable)
+	}
+
+	if strings.HasPrefix(s, "[") {
+		end := strings.IndexByte(s, ']')
+		if end < 0 {
+			return OriginAddress{}, NewArgError("IP", fmt.Sprintf("%q is missing the closing bracket", s))
+		}
+
+		host, portText := s[1:end], s[end+1:]
+		if portText != "" && !strings.HasPrefix(portText, ":") {
+			return OriginAddress{}, NewArgError("IP", fmt.Sprintf("%q has unexpected text after the closing bracket", s))
+		}
+		hasPort := portText != ""
+		portText = strings.TrimPrefix(portText, ":")
+
+		ip, err := netip.ParseAddr(host)
+		if err != nil || !ip.Is6() || ip.Is4In6() {
+			return OriginAddress{}, NewArgError("IP", fmt.Sprintf("%q must contain an IPv6 address in brackets", s))
+		}
+
+		port, err := parseOriginPort(s, portText, hasPort)
+		if err != nil {
+			return OriginAddress{}, err
+		}
+
+		return originAddressFromIP(s, ip, port, checkReachable)
+	}
+
+	if strings.Count(s, ":") > 1 {
+		return OriginAddress{}, NewArgError("IP", fmt.Sprintf("%q is not a valid address, IPv6 addresses with a port must be in brackets", s))
+	}
+
+	host, portText, hasPort := strings.Cut(s, ":")
+	port, err := parseOriginPort(s, portText, hasPort)
+	if err != nil {
+		return OriginAddress{}, err
+	}
+
+	if ip, err := netip.ParseAddr(host); err == nil {
+		return originAddressFromIP(s, ip, port, checkReachable)
+	}
+
+	name, err := normalizeDomainName("IP", host, false)
+	if err != nil {
+		return OriginAddress{}, err
+	}
+
+	return OriginAddress{Host: name, Port: port}, nil
+}
+
+// IsIP reports whether the origin is given by an IP address
+func (a OriginAddress) IsIP() bool {
+	return a.IP.IsValid()
+}
+
+// Hostname returns the host name or the IP address of the origin without brackets
+func (a OriginAddress) Hostname() string {
+	if a.IsIP() {
+		return a.IP.String()
+	}
+
+	return a.Host
+}
+
+// HostPort returns the address for dialing, using defaultPort if the address has no port
+func (a OriginAddress) HostPort(defaultPort uint16) string {
+	port := a.Port
+	if port == 0 {
+		port = defaultPort
+	}
+
+	return net.JoinHostPort(a.Hostname(), strconv.Itoa(int(port)))
+}
+
+// String returns the address in the canonical form sent to the API
+func (a OriginAddress) String() string {
+	if a.Port == 0 {
+		return a.Hostname()
+	}
+
+	return net.JoinHostPort(a.Hostname(), strconv.Itoa(int(a.Port)))
+}
+
+// ValidateOriginCreateRequest checks create request data matches restrictions. Zero limits are not checked,
+// Origins.Create and Origins.Update use the limits set with WithOriginLimits.
+func ValidateOriginCreateRequest(r OriginCreateRequest, limits OriginLimits) error {
+	if _, err := ParseOriginAddress(r.IP); err != nil {
+		return err
+	}
+
+	if r.Mode != "" && !r.Mode.Valid() {
+		return NewArgError("Mode", "must be primary or backup")
+	}
+
+	if err := checkOriginLimit("Weight", r.Weight, limits.MaxWeight); err != nil {
+		return err
+	}
+
+	if err := checkOriginLimit("MaxFails", r.MaxFails, limits.MaxFails); err != nil {
+		return err
+	}
+
+	return checkOriginLimit("FailTimeout", r.FailTimeout, limits.MaxFailTimeout)
+}
+
+// checkOriginLimit checks that an origin setting is not negative and does not exceed limit unless it is zero.
+// Zero settings are left to the API defaults.
+func checkOriginLimit(arg string, v, limit int) error {
+	if v < 0 {
+		return NewArgError(arg, "cannot be negative")
+	}
+
+	if limit > 0 && v > limit {
+		return NewArgError(arg, fmt.Sprintf("must be at most %d", limit))
+	}
+
+	return nil
+}
+
+// originAddressFromIP returns the address of the origin, checking that the edge can reach it if checkReachable is set
+func originAddressFromIP(s string, ip netip.Addr, port uint16, checkReachable bool) (OriginAddress, error) {
+	if ip.Zone() != "" {
+		return OriginAddress{}, NewArgError("IP", fmt.Sprintf("%q cannot have an IPv6 zone", s))
+	}
+
+	ip = ip.Unmap()
+	if checkReachable && (ip.IsUnspecified() || ip.IsLoopback() || ip.IsMulticast() || ip.IsLinkLocalUnicast()) {
+		return OriginAddress{}, NewArgError("IP", fmt.Sprintf("%q is not a unicast address reachable from the edge", s))
+	}
+
+	return OriginAddress{IP: ip, Port: port}, nil
+}
+
+// parseOriginPort parses the port of an origin address, which must be present if the address has a colon
+func parseOriginPort(s, port string, hasPort bool) (uint16, error) {
+	if !hasPort {
+		return 0, nil
+	}
+
+	n, err := strconv.ParseUint(port, 10, 16)
+	if err != nil || n == 0 {
+		return 0, NewArgError("IP", fmt.Sprintf("%q has an invalid port, must be between 1 and 65535", s))
+	}
+
+	return uint16(n), nil
+}
@@ -0,0 +1,152 @@
+package edgecenterprotection_go
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/netip"
+	"testing"
+)
+
+func TestParseOriginAddress(t *testing.T) {
+	tests := []struct {
+		in       string
+		want     OriginAddress
+		wantText string
+		wantErr  bool
+	}{
+		{in: "192.0.2.1", want: OriginAddress{IP: netip.MustParseAddr("192.0.2.1")}, wantText: "192.0.2.1"},
+		{in: " 192.0.2.1:8080 ", want: OriginAddress{IP: netip.MustParseAddr("192.0.2.1"), Port: 8080}, wantText: "192.0.2.1:8080"},
+		{in: "2001:DB8:0::1", want: OriginAddress{IP: netip.MustParseAddr("2001:db8::1")}, wantText: "2001:db8::1"},
+		{in: "[2001:db8::1]", want: OriginAddress{IP: netip.MustParseAddr("2001:db8::1")}, wantText: "2001:db8::1"},
+		{in: "[2001:db8::1]:443", want: OriginAddress{IP: netip.MustParseAddr("2001:db8::1"), Port: 443}, wantText: "[2001:db8::1]:443"},
+		{in: "::ffff:192.0.2.1", want: OriginAddress{IP: netip.MustParseAddr("192.0.2.1")}, wantText: "192.0.2.1"},
+		{in: "Origin.Example.COM.", want: OriginAddress{Host: "origin.example.com"}, wantText: "origin.example.com"},
+		{in: "пример.рф:65535", want: OriginAddress{Host: "xn--e1afmkfd.xn--p1ai", Port: 65535}, wantText: "xn--e1afmkfd.xn--p1ai:65535"},
+		{in: "192.0.2.1:1", want: OriginAddress{IP: netip.MustParseAddr("192.0.2.1"), Port: 1}, wantText: "192.0.2.1:1"},
+
+		{in: "", wantErr: true},
+		{in: "http://192.0.2.1", wantErr: true},
+		{in: "192.0.2.1/path", wantErr: true},
+		{in: "user@origin.example.com", wantErr: true},
+		{in: "origin.example.com?a=1", wantErr: true},
+		{in: "127.0.0.1", wantErr: true},
+		{in: "[::1]:80", wantErr: true},
+		{in: "0.0.0.0", wantErr: true},
+		{in: "169.254.0.1", wantErr: true},
+		{in: "fe80::1%eth0", wantErr: true},
+		{in: "224.0.0.1", wantErr: true},
+		{in: "192.0.2.1:0", wantErr: true},
+		{in: "192.0.2.1:65536", wantErr: true},
+		{in: "192.0.2.1:", wantErr: true},
+		{in: "192.0.2.1:http", wantErr: true},
+		{in: "2001:db8::1:443x", wantErr: true},
+		{in: "[2001:db8::1", wantErr: true},
+		{in: "[2001:db8::1]443", wantErr: true},
+		{in: "[192.0.2.1]:443", wantErr: true},
+		{in: "[::ffff:192.0.2.1]", wantErr: true},
+		{in: "origin..example.com", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseOriginAddress(tt.in)
+			if tt.wantErr {
+				var argErr *ArgError
+				if !errors.As(err, &argErr) || argErr.arg != "IP" {
+					t.Errorf("ParseOriginAddress() = %+v, %v, want IP ArgError", got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseOriginAddress() = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseOriginAddress() = %+v, want %+v", got, tt.want)
+			}
+			if got.String() != tt.wantText {
+				t.Errorf("String() = %q, want %q", got.String(), tt.wantText)
+			}
+		})
+	}
+}
+
+func TestOriginAddressHostPort(t *testing.T) {
+	addr, _ := ParseOriginAddress("2001:db8::1")
+	if got := addr.HostPort(80); got != "[2001:db8::1]:80" {
+		t.Errorf("HostPort() = %q", got)
+	}
+
+	addr, _ = ParseOriginAddress("origin.example.com:8443")
+	if got := addr.HostPort(80); got != "origin.example.com:8443" {
+		t.Errorf("HostPort() = %q", got)
+	}
+	if addr.IsIP() || addr.Hostname() != "origin.example.com" {
+		t.Errorf("IsIP() = %v, Hostname() = %q", addr.IsIP(), addr.Hostname())
+	}
+}
+
+func TestValidateOriginCreateRequest(t *testing.T) {
+	r := OriginCreateRequest{IP: "192.0.2.1", Weight: 500, MaxFails: 1000, FailTimeout: 86400}
+	if err := ValidateOriginCreateRequest(r, OriginLimits{}); err != nil {
+		t.Errorf("ValidateOriginCreateRequest() without limits = %v", err)
+	}
+
+	var argErr *ArgError
+	err := ValidateOriginCreateRequest(r, OriginLimits{MaxWeight: 100})
+	if !errors.As(err, &argErr) || argErr.arg != "Weight" {
+		t.Errorf("ValidateOriginCreateRequest() above MaxWeight = %v, want Weight ArgError", err)
+	}
+
+	r.Weight = -1
+	if err := ValidateOriginCreateRequest(r, OriginLimits{}); !errors.As(err, &argErr) || argErr.arg != "Weight" {
+		t.Errorf("ValidateOriginCreateRequest() with negative weight = %v, want Weight ArgError", err)
+	}
+
+	r = OriginCreateRequest{IP: "192.0.2.1", Mode: "standby"}
+	if err := ValidateOriginCreateRequest(r, OriginLimits{}); !errors.As(err, &argErr) || argErr.arg != "Mode" {
+		t.Errorf("ValidateOriginCreateRequest() with unknown mode = %v, want Mode ArgError", err)
+	}
+
+	if _, err := New(nil, WithOriginLimits(OriginLimits{MaxFails: -1})); !errors.As(err, &argErr) {
+		t.Errorf("WithOriginLimits() with negative limit = %v, want ArgError", err)
+	}
+}
+
+func TestOriginsNormalizeRequest(t *testing.T) {
+	var sent []string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body OriginCreateRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		sent = append(sent, r.Method+" "+body.IP)
+		_, _ = w.Write([]byte(`{"id": 1}`))
+	})
+	c := newTestClient(t, handler)
+	if err := WithOriginLimits(OriginLimits{MaxFailTimeout: 3600})(c); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if _, _, err := c.Origins.Create(ctx, 1, &OriginCreateRequest{IP: "[2001:DB8::1]"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := c.Origins.Update(ctx, 1, 1, &OriginCreateRequest{IP: "Origin.Example.com:8080"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var argErr *ArgError
+	if _, _, err := c.Origins.Update(ctx, 1, 1, &OriginCreateRequest{IP: "127.0.0.1"}); !errors.As(err, &argErr) || argErr.arg != "IP" {
+		t.Errorf("Update() with loopback = %v, want IP ArgError", err)
+	}
+	if _, _, err := c.Origins.Create(ctx, 1, &OriginCreateRequest{IP: "192.0.2.1", FailTimeout: 7200}); !errors.As(err, &argErr) || argErr.arg != "FailTimeout" {
+		t.Errorf("Create() above client limit = %v, want FailTimeout ArgError", err)
+	}
+
+	want := []string{"POST 2001:db8::1", "PATCH origin.example.com:8080"}
+	if len(sent) != len(want) || sent[0] != want[0] || sent[1] != want[1] {
+		t.Errorf("sent %q, want %q", sent, want)
+	}
+}
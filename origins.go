@@ -31,23 +31,23 @@ var _ OriginsService = &OriginsServiceOp{}
 
 // Origin represents an origin for Edgecenter DDoS protection resource
 type Origin struct {
-	ID          int64  `json:"id"`
-	IP          string `json:"origin_data"`
-	Mode        string `json:"origin_mode"`
-	Weight      int    `json:"origin_weight"`
-	MaxFails    int    `json:"origin_max_fails"`
-	FailTimeout int    `json:"origin_fail_timeout"`
-	Comment     string `json:"origin_comment"`
+	ID          int64      `json:"id"`
+	IP          string     `json:"origin_data"`
+	Mode        OriginMode `json:"origin_mode"`
+	Weight      int        `json:"origin_weight"`
+	MaxFails    int        `json:"origin_max_fails"`
+	FailTimeout int        `json:"origin_fail_timeout"`
+	Comment     string     `json:"origin_comment"`
 }
 
 // OriginCreateRequest represents a request to create an origin for DDoS protection resource
 type OriginCreateRequest struct {
-	IP          string `json:"origin_data"`
-	Mode        string `json:"origin_mode,omitempty"`
-	Weight      int    `json:"origin_weight,omitempty"`
-	MaxFails    int    `json:"origin_max_fails,omitempty"`
-	FailTimeout int    `json:"origin_fail_timeout,omitempty"`
-	Comment     string `json:"origin_comment,omitempty"`
+	IP          string     `json:"origin_data"`
+	Mode        OriginMode `json:"origin_mode,omitempty"`
+	Weight      int        `json:"origin_weight,omitempty"`
+	MaxFails    int        `json:"origin_max_fails,omitempty"`
+	FailTimeout int        `json:"origin_fail_timeout,omitempty"`
+	Comment     string     `json:"origin_comment,omitempty"`
 }
 
 // OriginListOptions specifies the optional query parameters to List method
@@ -56,6 +56,18 @@ type OriginListOptions struct {
 	Offset int `url:"offset,omitempty" validate:"omitempty"`
 }
 
+// OriginCreateRequestFromOrigin returns a request with every field set from the origin, for read-modify-write updates
+func OriginCreateRequestFromOrigin(o *Origin) *OriginCreateRequest {
+	return &OriginCreateRequest{
+		IP:          o.IP,
+		Mode:        o.Mode,
+		Weight:      o.Weight,
+		MaxFails:    o.MaxFails,
+		FailTimeout: o.FailTimeout,
+		Comment:     o.Comment,
+	}
+}
+
 // List origins for single DDoS resource
 func (s *OriginsServiceOp) List(ctx context.Context, resourceID int64, opts *OriginListOptions) ([]Origin, *Response, error) {
 	path := fmt.Sprintf("%s/%d/%s", resourcesBasePathV2, resourceID, originsPathV2)
@@ -99,13 +111,33 @@ func (s *OriginsServiceOp) Get(ctx context.Context, resourceID int64, originID i
 
 // Add origin for DDoS resource
 func (s *OriginsServiceOp) Create(ctx context.Context, resourceID int64, reqBody *OriginCreateRequest) (*Origin, *Response, error) {
-	path := fmt.Sprintf("%s/%d/%s", resourcesBasePathV2, resourceID, originsPathV2)
-
 	if reqBody == nil {
 		return nil, nil, NewArgError("reqBody", "cannot be nil")
 	}
 
-	req, err := s.client.NewRequest(ctx, http.MethodPost, path, reqBody)
+	if s.client.parentValidation {
+		parent, resp, err := s.client.Resources.Get(ctx, resourceID)
+		if err != nil {
+			return nil, resp, err
+		}
+
+		if err := validateOriginForResource(ctx, s, parent); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return s.create(ctx, resourceID, reqBody)
+}
+
+func (s *OriginsServiceOp) create(ctx context.Context, resourceID int64, reqBody *OriginCreateRequest) (*Origin, *Response, error) {
+	path := fmt.Sprintf("%s/%d/%s", resourcesBasePathV2, resourceID, originsPathV2)
+
+	body, err := s.normalizedRequest(reqBody)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req, err := s.client.NewRequest(ctx, http.MethodPost, path, body)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -132,18 +164,29 @@ func (s *OriginsServiceOp) Delete(ctx context.Context, resourceID int64, originI
 	return resp, err
 }
 
-// Update origin for DDoS resource
+// Update origin for DDoS resource. The request is validated and its address is sent in canonical form as in Create.
 func (s *OriginsServiceOp) Update(ctx context.Context, resourceID int64, originID int64, reqBody *OriginCreateRequest) (*Origin, *Response, error) {
+	return s.update(ctx, resourceID, originID, reqBody, "")
+}
+
+// update updates the origin, sending If-Match with ifMatch unless it is empty
+func (s *OriginsServiceOp) update(ctx context.Context, resourceID int64, originID int64, reqBody *OriginCreateRequest, ifMatch string) (*Origin, *Response, error) {
 	if reqBody == nil {
 		return nil, nil, NewArgError("reqBody", "cannot be nil")
 	}
 
+	body, err := s.normalizedRequest(reqBody)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	path := fmt.Sprintf("%s/%d/%s/%d", resourcesBasePathV2, resourceID, originsPathV2, originID)
 
-	req, err := s.client.NewRequest(ctx, http.MethodPatch, path, reqBody)
+	req, err := s.client.NewRequest(ctx, http.MethodPatch, path, body)
 	if err != nil {
 		return nil, nil, err
 	}
+	setIfMatch(req, ifMatch)
 
 	origin := new(Origin)
 	resp, err := s.client.Do(ctx, req, origin)
@@ -153,3 +196,25 @@ func (s *OriginsServiceOp) Update(ctx context.Context, resourceID int64, originI
 
 	return origin, resp, err
 }
+
+// normalizedRequest validates the request and returns a copy with the origin address in canonical form
+func (s *OriginsServiceOp) normalizedRequest(reqBody *OriginCreateRequest) (*OriginCreateRequest, error) {
+	if err := ValidateOriginCreateRequest(*reqBody, s.client.originLimits); err != nil {
+		return nil, err
+	}
+
+	addr, _ := ParseOriginAddress(reqBody.IP)
+
+	body := *reqBody
+	body.IP = addr.String()
+
+	return &body, nil
+}
+
+// listAllOrigins returns the origins of DDoS resource from every page
+func listAllOrigins(ctx context.Context, s OriginsService, resourceID int64) ([]Origin, error) {
+	return listAll(ctx, func(ctx context.Context, limit, offset int) ([]Origin, error) {
+		origins, _, err := s.List(ctx, resourceID, &OriginListOptions{Limit: limit, Offset: offset})
+		return origins, err
+	})
+}
@@ -0,0 +1,117 @@
+package edgecenterprotection_go
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// WithParentValidation is a client option that makes Origins.Create and Aliases.Create check the request
+// against the feature flags of the parent resource before sending it. Every checked request fetches the
+// parent resource first, use CreateOriginForResource and CreateAliasForResource to validate against an already
+// loaded resource instead.
+func WithParentValidation() ClientOpt {
+	return func(c *Client) error {
+		c.parentValidation = true
+		return nil
+	}
+}
+
+// ValidateOriginForResource checks that an origin can be added to the parent resource having the existing origins
+func ValidateOriginForResource(parent *Resource, existing []Origin) error {
+	if parent == nil {
+		return NewArgError("parent", "cannot be nil")
+	}
+
+	if !parent.MultipleOrigins && len(existing) > 0 {
+		return NewArgError("reqBody", fmt.Sprintf(
+			"resource %s (id %d) already has origin %s and multiple origins are disabled for it",
+			parent.Name, parent.ID, existing[0].IP))
+	}
+
+	return nil
+}
+
+// ValidateAliasForResource checks that an alias can be added to the parent resource
+func ValidateAliasForResource(parent *Resource, r AliasCreateRequest) error {
+	if parent == nil {
+		return NewArgError("parent", "cannot be nil")
+	}
+
+	if isWildcardDomain(r.Name) && !parent.WidlcardAliases {
+		return NewArgError("Name", fmt.Sprintf(
+			"wildcard alias %s requires wildcard aliases to be enabled for resource %s (id %d)",
+			r.Name, parent.Name, parent.ID))
+	}
+
+	return nil
+}
+
+// uncheckedOriginCreator is implemented by origin services able to create without checking the parent again
+type uncheckedOriginCreator interface {
+	create(ctx context.Context, resourceID int64, reqBody *OriginCreateRequest) (*Origin, *Response, error)
+}
+
+// uncheckedAliasCreator is implemented by alias services able to create without checking the parent again
+type uncheckedAliasCreator interface {
+	create(ctx context.Context, resourceID int64, reqBody *AliasCreateRequest) (*Alias, *Response, error)
+}
+
+// CreateOriginForResource adds origin for the given DDoS resource through s after validating it against the
+// resource feature flags. The resource is used as is, so a cached copy saves a request.
+func CreateOriginForResource(ctx context.Context, s OriginsService, parent *Resource, reqBody *OriginCreateRequest) (*Origin, *Response, error) {
+	if reqBody == nil {
+		return nil, nil, NewArgError("reqBody", "cannot be nil")
+	}
+
+	if err := validateOriginForResource(ctx, s, parent); err != nil {
+		return nil, nil, err
+	}
+
+	if c, ok := s.(uncheckedOriginCreator); ok {
+		return c.create(ctx, parent.ID, reqBody)
+	}
+
+	return s.Create(ctx, parent.ID, reqBody)
+}
+
+// CreateAliasForResource adds alias for the given DDoS resource through s after validating it against the
+// resource feature flags. The resource is used as is, so a cached copy saves a request.
+func CreateAliasForResource(ctx context.Context, s AliasesService, parent *Resource, reqBody *AliasCreateRequest) (*Alias, *Response, error) {
+	if reqBody == nil {
+		return nil, nil, NewArgError("reqBody", "cannot be nil")
+	}
+
+	if err := ValidateAliasForResource(parent, *reqBody); err != nil {
+		return nil, nil, err
+	}
+
+	if c, ok := s.(uncheckedAliasCreator); ok {
+		return c.create(ctx, parent.ID, reqBody)
+	}
+
+	return s.Create(ctx, parent.ID, reqBody)
+}
+
+// validateOriginForResource lists all origins of the parent resource and checks that another one can be added
+func validateOriginForResource(ctx context.Context, s OriginsService, parent *Resource) error {
+	if parent == nil {
+		return NewArgError("parent", "cannot be nil")
+	}
+
+	var existing []Origin
+	if !parent.MultipleOrigins {
+		var err error
+		existing, err = listAllOrigins(ctx, s, parent.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return ValidateOriginForResource(parent, existing)
+}
+
+// isWildcardDomain reports whether the domain name starts with a wildcard label
+func isWildcardDomain(name string) bool {
+	return strings.HasPrefix(strings.TrimSpace(name), "*.")
+}
@@ -0,0 +1,152 @@
+package edgecenterprotection_go
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type fakeOrigins struct {
+	OriginsService
+	existing  []Origin
+	listCalls int
+	created   []OriginCreateRequest
+}
+
+func (f *fakeOrigins) List(_ context.Context, _ int64, opts *OriginListOptions) ([]Origin, *Response, error) {
+	f.listCalls++
+	return pageOf(f.existing, opts.Limit, opts.Offset), nil, nil
+}
+
+func (f *fakeOrigins) Create(_ context.Context, _ int64, r *OriginCreateRequest) (*Origin, *Response, error) {
+	f.created = append(f.created, *r)
+	return &Origin{ID: int64(len(f.created)), IP: r.IP}, nil, nil
+}
+
+func TestValidateOriginForResource(t *testing.T) {
+	single := &Resource{ID: 1, Name: "example.com"}
+	multiple := &Resource{ID: 1, Name: "example.com", MultipleOrigins: true}
+	existing := []Origin{{ID: 5, IP: "192.0.2.1"}}
+
+	if err := ValidateOriginForResource(single, nil); err != nil {
+		t.Errorf("first origin: %v", err)
+	}
+	if err := ValidateOriginForResource(multiple, existing); err != nil {
+		t.Errorf("second origin with multiple origins: %v", err)
+	}
+
+	var argErr *ArgError
+	err := ValidateOriginForResource(single, existing)
+	if !errors.As(err, &argErr) || !strings.Contains(argErr.reason, "already has origin 192.0.2.1") {
+		t.Errorf("second origin without multiple origins = %v", err)
+	}
+
+	if err := ValidateOriginForResource(nil, nil); !errors.As(err, &argErr) || argErr.arg != "parent" {
+		t.Errorf("nil parent = %v, want parent ArgError", err)
+	}
+}
+
+func TestValidateAliasForResource(t *testing.T) {
+	tests := []struct {
+		name     string
+		wildcard bool
+		wantErr  bool
+	}{
+		{"www.example.com", false, false},
+		{"*.example.com", false, true},
+		{" *.example.com", false, true},
+		{"*.example.com", true, false},
+		{"www.*.example.com", false, false},
+	}
+
+	for _, tt := range tests {
+		parent := &Resource{ID: 1, Name: "example.com", WidlcardAliases: tt.wildcard}
+		err := ValidateAliasForResource(parent, AliasCreateRequest{Name: tt.name})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateAliasForResource(%q, wildcard %v) = %v, want error %v", tt.name, tt.wildcard, err, tt.wantErr)
+		}
+	}
+
+	if err := ValidateAliasForResource(nil, AliasCreateRequest{Name: "www.example.com"}); err == nil {
+		t.Error("expected error for nil parent")
+	}
+}
+
+func TestCreateOriginForResource(t *testing.T) {
+	ctx := context.Background()
+
+	// every page of origins is listed
+	f := &fakeOrigins{}
+	for i := range listPageSize + 1 {
+		f.existing = append(f.existing, Origin{ID: int64(i + 1), IP: fmt.Sprintf("192.0.2.%d", i)})
+	}
+	single := &Resource{ID: 1, Name: "example.com"}
+	if _, _, err := CreateOriginForResource(ctx, f, single, &OriginCreateRequest{IP: "198.51.100.1"}); err == nil {
+		t.Error("expected error for a second origin")
+	}
+	if f.listCalls != 2 || len(f.created) != 0 {
+		t.Errorf("got %d list calls and %d creates, want 2 and 0", f.listCalls, len(f.created))
+	}
+
+	// with multiple origins the existing origins are not listed
+	f = &fakeOrigins{existing: []Origin{{ID: 1, IP: "192.0.2.1"}}}
+	multiple := &Resource{ID: 1, Name: "example.com", MultipleOrigins: true}
+	origin, _, err := CreateOriginForResource(ctx, f, multiple, &OriginCreateRequest{IP: "198.51.100.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.listCalls != 0 || origin.IP != "198.51.100.1" {
+		t.Errorf("got %d list calls and origin %+v", f.listCalls, origin)
+	}
+
+	if _, _, err := CreateOriginForResource(ctx, f, nil, &OriginCreateRequest{IP: "198.51.100.1"}); err == nil {
+		t.Error("expected error for nil parent")
+	}
+	if _, _, err := CreateOriginForResource(ctx, f, multiple, nil); err == nil {
+		t.Error("expected error for nil request")
+	}
+}
+
+func TestWithParentValidation(t *testing.T) {
+	var requests []string
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v2/resources/1":
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": 1, "name": "example.com"})
+		case r.Method == http.MethodGet:
+			_, _ = w.Write([]byte(`[]`))
+		default:
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": 2, "alias_data": "www.example.com"})
+		}
+	}))
+	if err := WithParentValidation()(c); err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err := c.Aliases.Create(context.Background(), 1, &AliasCreateRequest{Name: "*.example.com"})
+	var argErr *ArgError
+	if !errors.As(err, &argErr) || argErr.arg != "Name" {
+		t.Errorf("wildcard alias = %v, want Name ArgError", err)
+	}
+
+	if _, _, err := c.Aliases.Create(context.Background(), 1, &AliasCreateRequest{Name: "www.example.com"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// a loaded resource saves fetching it
+	parent := &Resource{ID: 1, Name: "example.com"}
+	if _, _, err := CreateAliasForResource(context.Background(), c.Aliases, parent, &AliasCreateRequest{Name: "api.example.com"}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"GET /v2/resources/1", "GET /v2/resources/1", "POST /v2/resources/1/aliases", "POST /v2/resources/1/aliases"}
+	if strings.Join(requests, ", ") != strings.Join(want, ", ") {
+		t.Errorf("requests = %v, want %v", requests, want)
+	}
+}
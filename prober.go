@@ -0,0 +1,250 @@
+package edgecenterprotection_go
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultProbeTimeout     = 5 * time.Second
+	defaultProbeConcurrency = 8
+)
+
+// OriginProbeStage is the step of an origin probe
+type OriginProbeStage string
+
+const (
+	ProbeStageAddress OriginProbeStage = "address"
+	ProbeStageConnect OriginProbeStage = "connect"
+	ProbeStageTLS     OriginProbeStage = "tls"
+	ProbeStageHTTP    OriginProbeStage = "http"
+)
+
+// OriginProbeOptions configure ProbeOrigins
+type OriginProbeOptions struct {
+	// TLS makes the probe perform a TLS handshake after connecting
+	TLS bool
+
+	// Port is used for origins without a port, 443 with TLS and 80 without it by default
+	Port uint16
+
+	// ServerName is sent as SNI and as the Host header, the resource name by default
+	ServerName string
+
+	// InsecureSkipVerify disables verification of origin certificates, e.g. for self-signed certificates
+	InsecureSkipVerify bool
+
+	// RootCAs verify origin certificates, the system pool is used if nil
+	RootCAs *x509.CertPool
+
+	// HTTPPath enables the HTTP check, a GET request of the path is sent with the Host header.
+	// It must start with "/".
+	HTTPPath string
+
+	// ExpectStatus reports whether the HTTP status is healthy, any status below 500 is by default
+	ExpectStatus func(int) bool
+
+	// Timeout limits the whole probe of a single origin, 5s by default
+	Timeout time.Duration
+
+	// Concurrency is the number of origins probed at once, 8 by default
+	Concurrency int
+
+	// DialContext opens connections, net.Dialer is used by default
+	DialContext func(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+// OriginProbeResult is the outcome of probing a single origin. Durations of the stages that were not reached are zero.
+type OriginProbeResult struct {
+	Origin  Origin
+	Address string
+
+	Connect      time.Duration
+	TLSHandshake time.Duration
+	HTTP         time.Duration
+	StatusCode   int
+
+	// Stage is the failed stage, empty if the probe succeeded
+	Stage OriginProbeStage
+	Err   error
+}
+
+// OK reports whether every stage of the probe succeeded
+func (r OriginProbeResult) OK() bool {
+	return r.Err == nil
+}
+
+// Latency returns the total time of the probe stages
+func (r OriginProbeResult) Latency() time.Duration {
+	return r.Connect + r.TLSHandshake + r.HTTP
+}
+
+// ProbeOrigins checks from the local host that origins of DDoS resource with the given name are reachable:
+// each origin is connected to over TCP, then optionally a TLS handshake and an HTTP request are made.
+// Results are returned in the order of origins.
+func ProbeOrigins(ctx context.Context, name string, origins []Origin, opts *OriginProbeOptions) ([]OriginProbeResult, error) {
+	o := OriginProbeOptions{}
+	if opts != nil {
+		o = *opts
+	}
+
+	if o.HTTPPath != "" && !strings.HasPrefix(o.HTTPPath, "/") {
+		return nil, NewArgError("HTTPPath", fmt.Sprintf("%q must start with /", o.HTTPPath))
+	}
+
+	if o.ServerName == "" {
+		o.ServerName = name
+	}
+	if o.Port == 0 {
+		o.Port = 80
+		if o.TLS {
+			o.Port = 443
+		}
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = defaultProbeTimeout
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = defaultProbeConcurrency
+	}
+	if o.ExpectStatus == nil {
+		o.ExpectStatus = func(code int) bool { return code < http.StatusInternalServerError }
+	}
+	if o.DialContext == nil {
+		o.DialContext = (&net.Dialer{}).DialContext
+	}
+
+	results := make([]OriginProbeResult, len(origins))
+	sem := make(chan struct{}, o.Concurrency)
+
+	var wg sync.WaitGroup
+	for i, origin := range origins {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = probeOrigin(ctx, origin, &o)
+		}()
+	}
+	wg.Wait()
+
+	return results, nil
+}
+
+// ProbeResource lists the origins of DDoS resource from every page and probes them with the resource name as SNI and Host header
+func ProbeResource(ctx context.Context, c *Client, resourceID int64, opts *OriginProbeOptions) ([]OriginProbeResult, error) {
+	resource, _, err := c.Resources.Get(ctx, resourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	origins, err := listAllOrigins(ctx, c.Origins, resourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	return ProbeOrigins(ctx, resource.Name, origins, opts)
+}
+
+// probeOrigin runs the stages of the probe against a single origin
+func probeOrigin(ctx context.Context, origin Origin, o *OriginProbeOptions) OriginProbeResult {
+	result := OriginProbeResult{Origin: origin}
+
+	fail := func(stage OriginProbeStage, err error) OriginProbeResult {
+		result.Stage = stage
+		result.Err = fmt.Errorf("%s check failed: %w", stage, err)
+		return result
+	}
+
+	addr, err := parseOriginAddress(origin.IP, false)
+	if err != nil {
+		return fail(ProbeStageAddress, err)
+	}
+	result.Address = addr.HostPort(o.Port)
+
+	ctx, cancel := context.WithTimeout(ctx, o.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	conn, err := o.DialContext(ctx, "tcp", result.Address)
+	result.Connect = time.Since(start)
+	if err != nil {
+		return fail(ProbeStageConnect, err)
+	}
+	// conn is replaced by the TLS connection after the handshake, which must be closed instead
+	defer func() {
+		conn.Close()
+	}()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	if o.TLS {
+		tlsConn := tls.Client(conn, &tls.Config{
+			ServerName:         o.ServerName,
+			InsecureSkipVerify: o.InsecureSkipVerify,
+			RootCAs:            o.RootCAs,
+		})
+
+		conn = tlsConn
+
+		start = time.Now()
+		err = tlsConn.HandshakeContext(ctx)
+		result.TLSHandshake = time.Since(start)
+		if err != nil {
+			return fail(ProbeStageTLS, err)
+		}
+	}
+
+	if o.HTTPPath == "" {
+		return result
+	}
+
+	start = time.Now()
+	code, err := probeHTTP(conn, o.ServerName, o.HTTPPath)
+	result.HTTP = time.Since(start)
+	result.StatusCode = code
+	if err != nil {
+		return fail(ProbeStageHTTP, err)
+	}
+
+	if !o.ExpectStatus(code) {
+		return fail(ProbeStageHTTP, fmt.Errorf("unexpected status %d", code))
+	}
+
+	return result
+}
+
+// probeHTTP sends a GET request over the connection and returns the response status
+func probeHTTP(conn net.Conn, host, path string) (int, error) {
+	req, err := http.NewRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	req.Host = host
+	req.Close = true
+	req.Header.Set("User-Agent", userAgent)
+
+	if err := req.Write(conn); err != nil {
+		return 0, err
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+
+	return resp.StatusCode, nil
+}
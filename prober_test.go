@@ -0,0 +1,188 @@
+package edgecenterprotection_go
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func probeOne(t *testing.T, addr string, opts *OriginProbeOptions) OriginProbeResult {
+	t.Helper()
+
+	results, err := ProbeOrigins(context.Background(), "example.com", []Origin{{ID: 1, IP: addr}}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return results[0]
+}
+
+func TestProbeOriginsConnect(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	res := probeOne(t, ln.Addr().String(), nil)
+	if !res.OK() || res.Address != ln.Addr().String() || res.Connect <= 0 {
+		t.Errorf("probe = %+v, want a successful connect", res)
+	}
+}
+
+func TestProbeOriginsTLSAndHTTP(t *testing.T) {
+	var mu sync.Mutex
+	var sni, host string
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		host = r.Host
+		mu.Unlock()
+		if r.URL.Path == "/down" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	srv.TLS = &tls.Config{
+		GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			mu.Lock()
+			sni = hello.ServerName
+			mu.Unlock()
+			return nil, nil
+		},
+	}
+	srv.StartTLS()
+	defer srv.Close()
+
+	opts := &OriginProbeOptions{
+		TLS:      true,
+		RootCAs:  srv.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs,
+		HTTPPath: "/health",
+	}
+	addr := srv.Listener.Addr().String()
+
+	res := probeOne(t, addr, opts)
+	if !res.OK() || res.StatusCode != http.StatusOK || res.TLSHandshake <= 0 {
+		t.Fatalf("probe = %+v, want TLS and HTTP checks to pass", res)
+	}
+
+	mu.Lock()
+	if sni != "example.com" || host != "example.com" {
+		t.Errorf("SNI %q and Host %q, want the resource name", sni, host)
+	}
+	mu.Unlock()
+
+	opts.HTTPPath = "/down"
+	res = probeOne(t, addr, opts)
+	if res.OK() || res.Stage != ProbeStageHTTP || res.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("probe = %+v, want HTTP stage to fail with 503", res)
+	}
+
+	opts.RootCAs = nil
+	res = probeOne(t, addr, opts)
+	if res.OK() || res.Stage != ProbeStageTLS {
+		t.Errorf("probe with unknown CA = %+v, want TLS stage to fail", res)
+	}
+}
+
+func TestProbeOriginsFailures(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	refused := ln.Addr().String()
+	ln.Close()
+
+	res := probeOne(t, refused, nil)
+	if res.OK() || res.Stage != ProbeStageConnect {
+		t.Errorf("probe of a closed port = %+v, want connect stage to fail", res)
+	}
+
+	silent, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer silent.Close()
+
+	go func() {
+		var conns []net.Conn
+		for {
+			conn, err := silent.Accept()
+			if err != nil {
+				break
+			}
+			conns = append(conns, conn)
+		}
+		for _, conn := range conns {
+			conn.Close()
+		}
+	}()
+
+	res = probeOne(t, silent.Addr().String(), &OriginProbeOptions{HTTPPath: "/", Timeout: 100 * time.Millisecond})
+	var netErr net.Error
+	if res.OK() || res.Stage != ProbeStageHTTP || !errors.As(res.Err, &netErr) || !netErr.Timeout() {
+		t.Errorf("probe of a silent origin = %+v, want HTTP stage to time out", res)
+	}
+
+	res = probeOne(t, "not a host:80", nil)
+	if res.OK() || res.Stage != ProbeStageAddress {
+		t.Errorf("probe of an invalid address = %+v, want address stage to fail", res)
+	}
+}
+
+func TestProbeOriginsHTTPPath(t *testing.T) {
+	_, err := ProbeOrigins(context.Background(), "example.com", nil, &OriginProbeOptions{HTTPPath: "health"})
+	var argErr *ArgError
+	if !errors.As(err, &argErr) || !strings.Contains(err.Error(), "HTTPPath") {
+		t.Errorf("ProbeOrigins() with a relative path = %v, want HTTPPath ArgError", err)
+	}
+}
+
+type fakeResources struct {
+	ResourcesService
+	resource Resource
+}
+
+func (f *fakeResources) Get(context.Context, int64) (*Resource, *Response, error) {
+	r := f.resource
+	return &r, nil, nil
+}
+
+func TestProbeResourceAllPages(t *testing.T) {
+	origins := make([]Origin, listPageSize+20)
+	for i := range origins {
+		origins[i] = Origin{ID: int64(i + 1), IP: "not a host"}
+	}
+	fake := &fakeOrigins{existing: origins}
+	c := &Client{Resources: &fakeResources{resource: Resource{Name: "example.com"}}, Origins: fake}
+
+	results, err := ProbeResource(context.Background(), c, 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != len(origins) || fake.listCalls != 2 {
+		t.Fatalf("ProbeResource() probed %d origins in %d list calls, want %d in 2", len(results), fake.listCalls, len(origins))
+	}
+	for i, res := range results {
+		if res.Origin.ID != origins[i].ID || res.Stage != ProbeStageAddress {
+			t.Errorf("result %d = %+v, want origin %d to fail the address stage", i, res, origins[i].ID)
+		}
+	}
+}
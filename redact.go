@@ -0,0 +1,37 @@
+package edgecenterprotection_go
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// redacted replaces secret values in logs and dumps
+const redacted = "[REDACTED]"
+
+// secretHeaders are request and response headers carrying credentials
+var secretHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+// secretJSONFields matches string values of JSON fields holding private keys, also in truncated bodies
+var secretJSONFields = regexp.MustCompile(`("(?:service_ssl_key|alias_ssl_key)"\s*:\s*)"(?:[^"\\]|\\.)*(?:"|\\?$)`)
+
+// redactHeader returns a copy of the header with the values of secret headers replaced. Names are matched
+// case-insensitively, as headers set by direct map assignment are not canonicalized.
+func redactHeader(h http.Header) http.Header {
+	c := h.Clone()
+	for name := range c {
+		for _, secret := range secretHeaders {
+			if strings.EqualFold(name, secret) {
+				c[name] = []string{redacted}
+				break
+			}
+		}
+	}
+
+	return c
+}
+
+// redactJSONSecrets replaces private keys in a JSON body
+func redactJSONSecrets(body []byte) []byte {
+	return secretJSONFields.ReplaceAll(body, []byte(`$1"`+redacted+`"`))
+}
@@ -0,0 +1,291 @@
+package edgecenterprotection_go
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// HTTPS2HTTPMode defines whether HTTPS requests to DDoS resource are proxied to origins over plain HTTP
+type HTTPS2HTTPMode byte
+
+const (
+	HTTPS2HTTPDisabled HTTPS2HTTPMode = 0
+	HTTPS2HTTPEnabled  HTTPS2HTTPMode = 1
+)
+
+var https2httpModeFlag = flagType{name: "HTTPS2HTTPMode", values: []string{"disabled", "enabled"}}
+
+// IPHashMode defines whether origins of DDoS resource are selected by a hash of the client IP address
+type IPHashMode byte
+
+const (
+	IPHashDisabled IPHashMode = 0
+	IPHashEnabled  IPHashMode = 1
+)
+
+var ipHashModeFlag = flagType{name: "IPHashMode", values: []string{"disabled", "enabled"}}
+
+// GeoIPMode defines how the GeoIP list of DDoS resource is applied
+type GeoIPMode byte
+
+const (
+	// GeoIPModeOff ignores the GeoIP list
+	GeoIPModeOff GeoIPMode = 0
+
+	// GeoIPModeAllow allows requests only from countries in the GeoIP list
+	GeoIPModeAllow GeoIPMode = 1
+
+	// GeoIPModeDeny denies requests from countries in the GeoIP list
+	GeoIPModeDeny GeoIPMode = 2
+)
+
+var geoIPModeFlag = flagType{name: "GeoIPMode", values: []string{"off", "allow", "deny"}}
+
+// WWWRedirMode defines redirects between the domain of DDoS resource and its www subdomain
+type WWWRedirMode byte
+
+const (
+	// WWWRedirNone disables redirects
+	WWWRedirNone WWWRedirMode = 0
+
+	// WWWRedirToWWW redirects example.com to www.example.com
+	WWWRedirToWWW WWWRedirMode = 1
+
+	// WWWRedirFromWWW redirects www.example.com to example.com
+	WWWRedirFromWWW WWWRedirMode = 2
+)
+
+var wwwRedirModeFlag = flagType{name: "WWWRedirMode", values: []string{"none", "to-www", "from-www"}}
+
+// Valid reports whether the mode is known to the API
+func (m HTTPS2HTTPMode) Valid() bool {
+	return https2httpModeFlag.valid(byte(m))
+}
+
+// String returns the name of the mode
+func (m HTTPS2HTTPMode) String() string {
+	return https2httpModeFlag.format(byte(m))
+}
+
+// MarshalText returns the name of the mode
+func (m HTTPS2HTTPMode) MarshalText() ([]byte, error) {
+	return https2httpModeFlag.marshalText(byte(m))
+}
+
+// UnmarshalText parses the name or the numeric value of the mode
+func (m *HTTPS2HTTPMode) UnmarshalText(text []byte) error {
+	return unmarshalFlag(m, https2httpModeFlag.parse, string(text))
+}
+
+// MarshalJSON encodes the mode as the number expected by the API
+func (m HTTPS2HTTPMode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(byte(m))
+}
+
+// UnmarshalJSON decodes the mode from a number or a name
+func (m *HTTPS2HTTPMode) UnmarshalJSON(data []byte) error {
+	return unmarshalFlag(m, https2httpModeFlag.unmarshalJSON, data)
+}
+
+// Valid reports whether the mode is known to the API
+func (m IPHashMode) Valid() bool {
+	return ipHashModeFlag.valid(byte(m))
+}
+
+// String returns the name of the mode
+func (m IPHashMode) String() string {
+	return ipHashModeFlag.format(byte(m))
+}
+
+// MarshalText returns the name of the mode
+func (m IPHashMode) MarshalText() ([]byte, error) {
+	return ipHashModeFlag.marshalText(byte(m))
+}
+
+// UnmarshalText parses the name or the numeric value of the mode
+func (m *IPHashMode) UnmarshalText(text []byte) error {
+	return unmarshalFlag(m, ipHashModeFlag.parse, string(text))
+}
+
+// MarshalJSON encodes the mode as the number expected by the API
+func (m IPHashMode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(byte(m))
+}
+
+// UnmarshalJSON decodes the mode from a number or a name
+func (m *IPHashMode) UnmarshalJSON(data []byte) error {
+	return unmarshalFlag(m, ipHashModeFlag.unmarshalJSON, data)
+}
+
+// Valid reports whether the mode is known to the API
+func (m GeoIPMode) Valid() bool {
+	return geoIPModeFlag.valid(byte(m))
+}
+
+// String returns the name of the mode
+func (m GeoIPMode) String() string {
+	return geoIPModeFlag.format(byte(m))
+}
+
+// MarshalText returns the name of the mode
+func (m GeoIPMode) MarshalText() ([]byte, error) {
+	return geoIPModeFlag.marshalText(byte(m))
+}
+
+// UnmarshalText parses the name or the numeric value of the mode
+func (m *GeoIPMode) UnmarshalText(text []byte) error {
+	return unmarshalFlag(m, geoIPModeFlag.parse, string(text))
+}
+
+// MarshalJSON encodes the mode as the number expected by the API
+func (m GeoIPMode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(byte(m))
+}
+
+// UnmarshalJSON decodes the mode from a number or a name
+func (m *GeoIPMode) UnmarshalJSON(data []byte) error {
+	return unmarshalFlag(m, geoIPModeFlag.unmarshalJSON, data)
+}
+
+// Valid reports whether the mode is known to the API
+func (m WWWRedirMode) Valid() bool {
+	return wwwRedirModeFlag.valid(byte(m))
+}
+
+// String returns the name of the mode
+func (m WWWRedirMode) String() string {
+	return wwwRedirModeFlag.format(byte(m))
+}
+
+// MarshalText returns the name of the mode
+func (m WWWRedirMode) MarshalText() ([]byte, error) {
+	return wwwRedirModeFlag.marshalText(byte(m))
+}
+
+// UnmarshalText parses the name or the numeric value of the mode
+func (m *WWWRedirMode) UnmarshalText(text []byte) error {
+	return unmarshalFlag(m, wwwRedirModeFlag.parse, string(text))
+}
+
+// MarshalJSON encodes the mode as the number expected by the API
+func (m WWWRedirMode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(byte(m))
+}
+
+// UnmarshalJSON decodes the mode from a number or a name
+func (m *WWWRedirMode) UnmarshalJSON(data []byte) error {
+	return unmarshalFlag(m, wwwRedirModeFlag.unmarshalJSON, data)
+}
+
+// validateResourceFlags checks the byte flags shared by resource create and update requests
+func validateResourceFlags(https2http HTTPS2HTTPMode, ipHash IPHashMode, geoIPMode GeoIPMode, wwwRedir WWWRedirMode) error {
+	if !https2http.Valid() {
+		return NewArgError("HTTPS2HTTP", https2httpModeFlag.choices())
+	}
+
+	if !ipHash.Valid() {
+		return NewArgError("IPHash", ipHashModeFlag.choices())
+	}
+
+	if !geoIPMode.Valid() {
+		return NewArgError("GeoIPMode", geoIPModeFlag.choices())
+	}
+
+	if !wwwRedir.Valid() {
+		return NewArgError("WWWRedir", wwwRedirModeFlag.choices())
+	}
+
+	return nil
+}
+
+// flagType is the name table of a byte flag, the value of a flag is the index of its name
+type flagType struct {
+	name   string
+	values []string
+}
+
+// unmarshalFlag sets *dst to the flag decoded from src, leaving it unchanged on error
+func unmarshalFlag[T ~byte, S any](dst *T, decode func(S) (byte, error), src S) error {
+	v, err := decode(src)
+	if err != nil {
+		return err
+	}
+
+	*dst = T(v)
+
+	return nil
+}
+
+func (f flagType) valid(v byte) bool {
+	return int(v) < len(f.values)
+}
+
+func (f flagType) format(v byte) string {
+	if f.valid(v) {
+		return f.values[v]
+	}
+
+	return fmt.Sprintf("%s(%d)", f.name, v)
+}
+
+func (f flagType) marshalText(v byte) ([]byte, error) {
+	if !f.valid(v) {
+		return nil, NewArgError(f.name, f.choices())
+	}
+
+	return []byte(f.values[v]), nil
+}
+
+// parse parses a flag from its name or its numeric value
+func (f flagType) parse(text string) (byte, error) {
+	text = strings.TrimSpace(text)
+
+	for i, name := range f.values {
+		if strings.EqualFold(text, name) {
+			return byte(i), nil
+		}
+	}
+
+	if n, err := strconv.Atoi(text); err == nil && n >= 0 && n < len(f.values) {
+		return byte(n), nil
+	}
+
+	return 0, NewArgError(f.name, f.choices())
+}
+
+// unmarshalJSON decodes a flag from a JSON number, a name string or null
+func (f flagType) unmarshalJSON(data []byte) (byte, error) {
+	if string(data) == "null" {
+		return 0, nil
+	}
+
+	if len(data) > 0 && data[0] == '"' {
+		var text string
+		if err := json.Unmarshal(data, &text); err != nil {
+			return 0, err
+		}
+
+		return f.parse(text)
+	}
+
+	var v byte
+	if err := json.Unmarshal(data, &v); err != nil {
+		return 0, err
+	}
+
+	return v, nil
+}
+
+// choices describes the accepted values of the flag, e.g. "must be off (0), allow (1) or deny (2)"
+func (f flagType) choices() string {
+	choices := make([]string, len(f.values))
+	for i, name := range f.values {
+		choices[i] = fmt.Sprintf("%s (%d)", name, i)
+	}
+
+	last := len(choices) - 1
+
+	return "must be " + strings.Join(choices[:last], ", ") + " or " + choices[last]
+}
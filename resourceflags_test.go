@@ -0,0 +1,50 @@
+package edgecenterprotection_go
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestResourceFlagsJSON(t *testing.T) {
+	var r struct {
+		GeoIPMode GeoIPMode    `json:"geo"`
+		WWWRedir  WWWRedirMode `json:"www"`
+	}
+	if err := json.Unmarshal([]byte(`{"geo":"deny","www":1}`), &r); err != nil {
+		t.Fatal(err)
+	}
+	if r.GeoIPMode != GeoIPModeDeny || r.WWWRedir != WWWRedirToWWW {
+		t.Errorf("got %v %v", r.GeoIPMode, r.WWWRedir)
+	}
+
+	data, err := json.Marshal(r)
+	if err != nil || string(data) != `{"geo":2,"www":1}` {
+		t.Errorf("Marshal() = %s, %v", data, err)
+	}
+
+	mode := GeoIPModeAllow
+	if err := mode.UnmarshalText([]byte("sometimes")); err == nil || mode != GeoIPModeAllow {
+		t.Errorf("UnmarshalText() = %v, mode %v, want error and unchanged mode", err, mode)
+	}
+	if s := GeoIPMode(7).String(); s != "GeoIPMode(7)" {
+		t.Errorf("String() = %q", s)
+	}
+	if err := json.Unmarshal([]byte(`"to-WWW"`), &r.WWWRedir); err != nil || r.WWWRedir != WWWRedirToWWW {
+		t.Errorf("UnmarshalJSON() = %v, mode %v", err, r.WWWRedir)
+	}
+}
+
+func TestValidateResourceCreateTLSEnabled(t *testing.T) {
+	s := &ResourcesServiceOp{}
+
+	var argErr *ArgError
+	err := s.ValidateResourceCreate(ResourceCreateRequest{Name: "example.com", TLSEnabled: []string{"1.2", "2"}})
+	if !errors.As(err, &argErr) || argErr.arg != "TLSEnabled" {
+		t.Errorf("ValidateResourceCreate() = %v, want TLSEnabled ArgError", err)
+	}
+
+	if err := s.ValidateResourceCreate(ResourceCreateRequest{Name: "example.com", TLSEnabled: []string{"1.2", "1.3"}}); err != nil {
+		t.Errorf("ValidateResourceCreate() = %v", err)
+	}
+}
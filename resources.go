@@ -2,8 +2,10 @@ package edgecenterprotection_go
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 )
 
 const (
@@ -36,85 +38,117 @@ var _ ResourcesService = &ResourcesServiceOp{}
 
 // Resource represents an Edgecenter DDoS protection resource
 type Resource struct {
-	ID              int64    `json:"id"`
-	CreatedAt       string   `json:"created"`
-	UpdatedAt       string   `json:"updated"`
-	Name            string   `json:"name"`
-	ClientID        int      `json:"client"`
-	Active          bool     `json:"active"`
-	Enabled         bool     `json:"enabled"`
-	WAF             bool     `json:"is_waf_enabled"`
-	RedirectToHTTPS bool     `json:"is_redirect_to_https_enabled"`
-	Status          string   `json:"status"`
-	ServiceIP       string   `json:"service_ip"`
-	HTTPS2HTTP      byte     `json:"service_https2http"`
-	IPHash          byte     `json:"service_iphash"`
-	GeoIPMode       byte     `json:"service_geoip_mode"`
-	GeoIPList       string   `json:"service_geoip_list"`
-	WWWRedir        byte     `json:"service_wwwredir"`
-	MultipleOrigins bool     `json:"feature_multiple_origins"`
-	WidlcardAliases bool     `json:"feature_wildcard_aliases"`
-	SSLType         *string  `json:"ssl_type"`
-	SSLExpire       int      `json:"service_ssl_expire"`
-	SSLStatus       string   `json:"service_ssl_status"`
-	TLSEnabled      []string `json:"tls_enabled"`
-	WaitForLE       int      `json:"wait_for_le"`
+	ID              int64          `json:"id"`
+	CreatedAt       time.Time      `json:"created"`
+	UpdatedAt       time.Time      `json:"updated"`
+	Name            string         `json:"name"`
+	ClientID        int            `json:"client"`
+	Active          bool           `json:"active"`
+	Enabled         bool           `json:"enabled"`
+	WAF             bool           `json:"is_waf_enabled"`
+	RedirectToHTTPS bool           `json:"is_redirect_to_https_enabled"`
+	Status          string         `json:"status"`
+	ServiceIP       string         `json:"service_ip"`
+	HTTPS2HTTP      HTTPS2HTTPMode `json:"service_https2http"`
+	IPHash          IPHashMode     `json:"service_iphash"`
+	GeoIPMode       GeoIPMode      `json:"service_geoip_mode"`
+	GeoIPList       string         `json:"service_geoip_list"`
+	WWWRedir        WWWRedirMode   `json:"service_wwwredir"`
+	MultipleOrigins bool           `json:"feature_multiple_origins"`
+	WidlcardAliases bool           `json:"feature_wildcard_aliases"`
+	SSLType         *string        `json:"ssl_type"`
+	SSLExpire       int            `json:"service_ssl_expire"`
+	SSLStatus       string         `json:"service_ssl_status"`
+	TLSEnabled      []string       `json:"tls_enabled"`
+	WaitForLE       int            `json:"wait_for_le"`
+}
+
+// UnmarshalJSON decodes the resource, parsing timestamps in any of the formats returned by the API
+func (r *Resource) UnmarshalJSON(data []byte) error {
+	type resource Resource
+
+	aux := struct {
+		*resource
+		CreatedAt json.RawMessage `json:"created"`
+		UpdatedAt json.RawMessage `json:"updated"`
+	}{resource: (*resource)(r)}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	var err error
+	if r.CreatedAt, err = unmarshalAPITime(aux.CreatedAt); err != nil {
+		return err
+	}
+
+	if r.UpdatedAt, err = unmarshalAPITime(aux.UpdatedAt); err != nil {
+		return err
+	}
+
+	return nil
 }
 
 // ResourceCreateRequest represents a request to create a DDoS protection resource
 type ResourceCreateRequest struct {
-	Name            string   `json:"name"`
-	Active          bool     `json:"active"`
-	MultipleOrigins bool     `json:"feature_multiple_origins"`
-	WidlcardAliases bool     `json:"feature_wildcard_aliases"`
-	RedirectToHTTPS bool     `json:"is_redirect_to_https_enabled"`
-	HTTPS2HTTP      byte     `json:"service_https2http"`
-	IPHash          byte     `json:"service_iphash"`
-	GeoIPMode       byte     `json:"service_geoip_mode"`
-	GeoIPList       string   `json:"service_geoip_list"`
-	WWWRedir        byte     `json:"service_wwwredir"`
-	TLSEnabled      []string `json:"tls_enabled"`
-	SSLType         *string  `json:"ssl_type"`
-	SSLCert         *string  `json:"service_ssl_crt,omitempty"`
-	SSLKey          *string  `json:"service_ssl_key,omitempty"`
-	WAF             bool     `json:"is_waf_enabled"`
+	Name            string         `json:"name"`
+	Active          bool           `json:"active"`
+	MultipleOrigins bool           `json:"feature_multiple_origins"`
+	WidlcardAliases bool           `json:"feature_wildcard_aliases"`
+	RedirectToHTTPS bool           `json:"is_redirect_to_https_enabled"`
+	HTTPS2HTTP      HTTPS2HTTPMode `json:"service_https2http"`
+	IPHash          IPHashMode     `json:"service_iphash"`
+	GeoIPMode       GeoIPMode      `json:"service_geoip_mode"`
+	GeoIPList       string         `json:"service_geoip_list"`
+	WWWRedir        WWWRedirMode   `json:"service_wwwredir"`
+	TLSEnabled      []string       `json:"tls_enabled"`
+	SSLType         *string        `json:"ssl_type"`
+	SSLCert         *string        `json:"service_ssl_crt,omitempty"`
+	SSLKey          *string        `json:"service_ssl_key,omitempty"`
+	WAF             bool           `json:"is_waf_enabled"`
 }
 
-// ResourceUpdateRequest represents a request to update a DDoS protection resource
+// ResourceUpdateRequest represents a request to partially update a DDoS protection resource.
+// Only non-nil fields are sent, use PtrTo to set them. TLSEnabled and SSLType can also be sent as null
+// or empty, they are sent only if set with NewOptional or NullOptional.
 type ResourceUpdateRequest struct {
-	Active          bool     `json:"active"`
-	MultipleOrigins bool     `json:"feature_multiple_origins"`
-	WidlcardAliases bool     `json:"feature_wildcard_aliases"`
-	RedirectToHTTPS bool     `json:"is_redirect_to_https_enabled"`
-	HTTPS2HTTP      byte     `json:"service_https2http"`
-	IPHash          byte     `json:"service_iphash"`
-	GeoIPMode       byte     `json:"service_geoip_mode"`
-	GeoIPList       string   `json:"service_geoip_list"`
-	WWWRedir        byte     `json:"service_wwwredir"`
-	TLSEnabled      []string `json:"tls_enabled"`
-	SSLType         *string  `json:"ssl_type"`
-	SSLCert         *string  `json:"service_ssl_crt,omitempty"`
-	SSLKey          *string  `json:"service_ssl_key,omitempty"`
-	WAF             bool     `json:"is_waf_enabled"`
+	Active          *bool              `json:"active,omitempty"`
+	MultipleOrigins *bool              `json:"feature_multiple_origins,omitempty"`
+	WidlcardAliases *bool              `json:"feature_wildcard_aliases,omitempty"`
+	RedirectToHTTPS *bool              `json:"is_redirect_to_https_enabled,omitempty"`
+	HTTPS2HTTP      *HTTPS2HTTPMode    `json:"service_https2http,omitempty"`
+	IPHash          *IPHashMode        `json:"service_iphash,omitempty"`
+	GeoIPMode       *GeoIPMode         `json:"service_geoip_mode,omitempty"`
+	GeoIPList       *string            `json:"service_geoip_list,omitempty"`
+	WWWRedir        *WWWRedirMode      `json:"service_wwwredir,omitempty"`
+	TLSEnabled      Optional[[]string] `json:"tls_enabled,omitzero"`
+	SSLType         Optional[string]   `json:"ssl_type,omitzero"`
+	SSLCert         *string            `json:"service_ssl_crt,omitempty"`
+	SSLKey          *string            `json:"service_ssl_key,omitempty"`
+	WAF             *bool              `json:"is_waf_enabled,omitempty"`
 }
 
-// ResourceListOptions specifies the optional query parameters to List method
+// ResourceListOptions specifies the optional query parameters to List method.
+// Time ranges are sent in RFC 3339 format, zero times are omitted.
 type ResourceListOptions struct {
-	Limit           int    `url:"limit,omitempty" validate:"omitempty"`
-	Offset          int    `url:"offset,omitempty" validate:"omitempty"`
-	Ordering        string `url:"ordering,omitempty" validate:"omitempty"`
-	ClientID        int    `url:"client,omitempty" validate:"omitempty"`
-	Name            string `url:"name,omitempty" validate:"omitempty"`
-	Active          bool   `url:"active,omitempty" validate:"omitempty"`
-	MultipleOrigin  bool   `url:"feature_multiple_origins,omitempty" validate:"omitempty"`
-	WildcardAliases bool   `url:"feature_wildcard_aliases,omitempty" validate:"omitempty"`
-	ServiceIP       string `url:"service_ip,omitempty" validate:"omitempty"`
-	OriginIP        string `url:"origin_ip,omitempty" validate:"omitempty"`
-	Status          string `url:"status,omitempty" validate:"omitempty"`
-	CreatedGt       string `url:"created_gte,omitempty" validate:"omitempty"`
-	CleatedLt       string `url:"created_lte,omitempty" validate:"omitempty"`
-	UpdatedGt       string `url:"updated_gte,omitempty" validate:"omitempty"`
-	UpdatedLt       string `url:"updated_lte,omitempty" validate:"omitempty"`
+	Limit           int       `url:"limit,omitempty" validate:"omitempty"`
+	Offset          int       `url:"offset,omitempty" validate:"omitempty"`
+	Ordering        string    `url:"ordering,omitempty" validate:"omitempty"`
+	ClientID        int       `url:"client,omitempty" validate:"omitempty"`
+	Name            string    `url:"name,omitempty" validate:"omitempty"`
+	Active          bool      `url:"active,omitempty" validate:"omitempty"`
+	MultipleOrigin  bool      `url:"feature_multiple_origins,omitempty" validate:"omitempty"`
+	WildcardAliases bool      `url:"feature_wildcard_aliases,omitempty" validate:"omitempty"`
+	ServiceIP       string    `url:"service_ip,omitempty" validate:"omitempty"`
+	OriginIP        string    `url:"origin_ip,omitempty" validate:"omitempty"`
+	Status          string    `url:"status,omitempty" validate:"omitempty"`
+	CreatedGt       time.Time `url:"created_gte,omitempty" validate:"omitempty"`
+	CreatedLt       time.Time `url:"created_lte,omitempty" validate:"omitempty"`
+	UpdatedGt       time.Time `url:"updated_gte,omitempty" validate:"omitempty"`
+	UpdatedLt       time.Time `url:"updated_lte,omitempty" validate:"omitempty"`
+
+	// Deprecated: use CreatedLt. CleatedLt is sent as is and cannot be combined with CreatedLt.
+	CleatedLt string `url:"created_lte,omitempty" validate:"omitempty"`
 }
 
 // DnsCheck represents DNS data obtained from Edgecenter protection API for single resource
@@ -131,6 +165,10 @@ type resourcesRoot struct {
 
 // List get DDoS resources
 func (s *ResourcesServiceOp) List(ctx context.Context, opts *ResourceListOptions) ([]Resource, *Response, error) {
+	if opts != nil && opts.CleatedLt != "" && !opts.CreatedLt.IsZero() {
+		return nil, nil, NewArgError("CleatedLt", "cannot be set together with CreatedLt")
+	}
+
 	path, err := addOptions(resourcesBasePathV2, opts)
 	if err != nil {
 		return nil, nil, err
@@ -178,7 +216,13 @@ func (s *ResourcesServiceOp) Create(ctx context.Context, reqBody *ResourceCreate
 		return nil, nil, NewArgError("reqBody", "failed validation")
 	}
 
-	req, err := s.client.NewRequest(ctx, http.MethodPost, resourcesBasePathV2, reqBody)
+	body := *reqBody
+	var err error
+	if body.Name, err = normalizeDomainName("Name", body.Name, false); err != nil {
+		return nil, nil, err
+	}
+
+	req, err := s.client.NewRequest(ctx, http.MethodPost, resourcesBasePathV2, &body)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -207,6 +251,11 @@ func (s *ResourcesServiceOp) Delete(ctx context.Context, resourceID int64) (*Res
 
 // Update DDoS protection resource
 func (s *ResourcesServiceOp) Update(ctx context.Context, resourceID int64, reqBody *ResourceUpdateRequest) (*Resource, *Response, error) {
+	return s.update(ctx, resourceID, reqBody, "")
+}
+
+// update updates DDoS resource, sending If-Match with ifMatch unless it is empty
+func (s *ResourcesServiceOp) update(ctx context.Context, resourceID int64, reqBody *ResourceUpdateRequest, ifMatch string) (*Resource, *Response, error) {
 	if reqBody == nil {
 		return nil, nil, NewArgError("reqBody", "cannot be nil")
 	}
@@ -221,6 +270,7 @@ func (s *ResourcesServiceOp) Update(ctx context.Context, resourceID int64, reqBo
 	if err != nil {
 		return nil, nil, err
 	}
+	setIfMatch(req, ifMatch)
 
 	resource := new(Resource)
 	resp, err := s.client.Do(ctx, req, resource)
@@ -249,37 +299,46 @@ func (s *ResourcesServiceOp) GetDomainName(ctx context.Context, resourceID int64
 	return dnsAnswer, resp, err
 }
 
-// Check update request data matches restrictions
-func (s *ResourcesServiceOp) ValidateResourceUpdate(r ResourceUpdateRequest) error {
-	if r.HTTPS2HTTP != 0 && r.HTTPS2HTTP != 1 {
-		return NewArgError("HTTPS2HTTP", "must be 0 or 1")
-	}
-
-	if r.IPHash != 0 && r.IPHash != 1 {
-		return NewArgError("IPHash", "must be 0 or 1")
-	}
-
-	if r.GeoIPMode != 0 && r.GeoIPMode != 1 && r.GeoIPMode != 2 {
-		return NewArgError("GeoIPMode", "must be 0, 1 or 2")
+// ResourceUpdateRequestFromResource returns an update request with every updatable field set from the resource,
+// for read-modify-write updates. SSL certificate and key are not returned by the API and are left unset.
+func ResourceUpdateRequestFromResource(r *Resource) *ResourceUpdateRequest {
+	return &ResourceUpdateRequest{
+		Active:          PtrTo(r.Active),
+		MultipleOrigins: PtrTo(r.MultipleOrigins),
+		WidlcardAliases: PtrTo(r.WidlcardAliases),
+		RedirectToHTTPS: PtrTo(r.RedirectToHTTPS),
+		HTTPS2HTTP:      PtrTo(r.HTTPS2HTTP),
+		IPHash:          PtrTo(r.IPHash),
+		GeoIPMode:       PtrTo(r.GeoIPMode),
+		GeoIPList:       PtrTo(r.GeoIPList),
+		WWWRedir:        PtrTo(r.WWWRedir),
+		TLSEnabled:      NewOptional(append([]string{}, r.TLSEnabled...)),
+		SSLType:         OptionalFromPtr(r.SSLType),
+		WAF:             PtrTo(r.WAF),
 	}
+}
 
-	if len(r.GeoIPList) > 255 {
-		return NewArgError("GeoIPList", "length cannot exceed 255 symbols")
+// Check update request data matches restrictions
+func (s *ResourcesServiceOp) ValidateResourceUpdate(r ResourceUpdateRequest) error {
+	if err := validateResourceFlags(valueOf(r.HTTPS2HTTP), valueOf(r.IPHash), valueOf(r.GeoIPMode), valueOf(r.WWWRedir)); err != nil {
+		return err
 	}
 
-	if r.WWWRedir != 0 && r.WWWRedir != 1 && r.WWWRedir != 2 {
-		return NewArgError("WWWRedir", "must be 0 or 1")
+	if r.GeoIPList != nil {
+		if err := s.validateGeoIPList(*r.GeoIPList); err != nil {
+			return err
+		}
 	}
 
-	for _, tls := range r.TLSEnabled {
+	tlsEnabled, _ := r.TLSEnabled.Get()
+	for _, tls := range tlsEnabled {
 		if tls != "1" && tls != "1.1" && tls != "1.2" && tls != "1.3" {
 			return NewArgError("TLSEnabled", "must be 1, 1.2, 1.2 or 1.3")
 		}
 	}
 
-	ssltype := r.SSLType
-	if ssltype != nil {
-		if *ssltype != "" && *ssltype != "custom" && *ssltype != "le" {
+	if ssltype, ok := r.SSLType.Get(); ok {
+		if ssltype != "" && ssltype != "custom" && ssltype != "le" {
 			return NewArgError("SSLType", "must be custom or le")
 		}
 	}
@@ -289,24 +348,16 @@ func (s *ResourcesServiceOp) ValidateResourceUpdate(r ResourceUpdateRequest) err
 
 // Check create request data matches restrictions
 func (s *ResourcesServiceOp) ValidateResourceCreate(r ResourceCreateRequest) error {
-	if r.HTTPS2HTTP != 0 && r.HTTPS2HTTP != 1 {
-		return NewArgError("HTTPS2HTTP", "must be 0 or 1")
-	}
-
-	if r.IPHash != 0 && r.IPHash != 1 {
-		return NewArgError("IPHash", "must be 0 or 1")
-	}
-
-	if r.GeoIPMode != 0 && r.GeoIPMode != 1 && r.GeoIPMode != 2 {
-		return NewArgError("GeoIPMode", "must be 0, 1 or 2")
+	if _, err := normalizeDomainName("Name", r.Name, false); err != nil {
+		return err
 	}
 
-	if len(r.GeoIPList) > 255 {
-		return NewArgError("GeoIPList", "length cannot exceed 255 symbols")
+	if err := validateResourceFlags(r.HTTPS2HTTP, r.IPHash, r.GeoIPMode, r.WWWRedir); err != nil {
+		return err
 	}
 
-	if r.WWWRedir != 0 && r.WWWRedir != 1 && r.WWWRedir != 2 {
-		return NewArgError("WWWRedir", "must be 0 or 1")
+	if err := s.validateGeoIPList(r.GeoIPList); err != nil {
+		return err
 	}
 
 	if len(r.TLSEnabled) == 0 {
@@ -0,0 +1,136 @@
+package edgecenterprotection_go
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// requestIDHeaders are the response headers the API and its proxies put request IDs in
+var requestIDHeaders = []string{"X-Request-Id", "X-Correlation-Id", "X-Trace-Id"}
+
+// Rate is the rate limit state reported in the headers of an API response
+type Rate struct {
+	// Limit and Remaining are the number of requests allowed and left in the current window,
+	// both 0 if the response has no rate limit headers
+	Limit     int
+	Remaining int
+
+	// Reset is the time the current window ends, zero if unknown
+	Reset time.Time
+
+	// RetryAfter is the wait requested by the Retry-After header of 429 and 503 responses
+	RetryAfter time.Duration
+}
+
+// ServerTiming is a metric of the Server-Timing header of an API response
+type ServerTiming struct {
+	Name        string
+	Duration    time.Duration
+	Description string
+}
+
+// headerRequestID returns the request ID from the response headers
+func headerRequestID(h http.Header) string {
+	for _, name := range requestIDHeaders {
+		if id := h.Get(name); id != "" {
+			return id
+		}
+	}
+
+	return ""
+}
+
+// headerRate returns the rate limit state from the X-RateLimit-* or RateLimit-* and Retry-After response headers.
+// Reset is accepted both as a Unix time and as seconds from now.
+func headerRate(h http.Header, now time.Time) Rate {
+	var rate Rate
+
+	get := func(name string) string {
+		if v := h.Get("X-" + name); v != "" {
+			return v
+		}
+		return h.Get(name)
+	}
+
+	limit, limitErr := strconv.Atoi(get("RateLimit-Limit"))
+	remaining, remainingErr := strconv.Atoi(get("RateLimit-Remaining"))
+	if limitErr == nil && remainingErr == nil && limit > 0 {
+		rate.Limit, rate.Remaining = limit, max(remaining, 0)
+	}
+
+	if reset, err := strconv.ParseInt(get("RateLimit-Reset"), 10, 64); err == nil && reset > 0 {
+		// a delta would be over 30 years, so larger values are timestamps
+		if reset > 1e9 {
+			rate.Reset = time.Unix(reset, 0)
+		} else {
+			rate.Reset = now.Add(time.Duration(reset) * time.Second)
+		}
+	}
+
+	if v := h.Get("Retry-After"); v != "" {
+		if seconds, err := strconv.Atoi(v); err == nil && seconds > 0 {
+			rate.RetryAfter = time.Duration(seconds) * time.Second
+		} else if at, err := http.ParseTime(v); err == nil && at.After(now) {
+			rate.RetryAfter = at.Sub(now)
+		}
+	}
+
+	return rate
+}
+
+// headerServerTiming returns the metrics of the Server-Timing response headers, skipping malformed ones
+func headerServerTiming(h http.Header) []ServerTiming {
+	var timings []ServerTiming
+	for _, value := range h.Values("Server-Timing") {
+		for _, metric := range splitQuoted(value, ',') {
+			params := splitQuoted(metric, ';')
+
+			t := ServerTiming{Name: strings.TrimSpace(params[0])}
+			if t.Name == "" {
+				continue
+			}
+
+			for _, p := range params[1:] {
+				k, v, _ := strings.Cut(p, "=")
+				v = strings.TrimSpace(v)
+				if uq, err := strconv.Unquote(v); err == nil && strings.HasPrefix(v, `"`) {
+					v = uq
+				}
+
+				switch strings.ToLower(strings.TrimSpace(k)) {
+				case "dur":
+					if ms, err := strconv.ParseFloat(v, 64); err == nil {
+						t.Duration = time.Duration(ms * float64(time.Millisecond))
+					}
+				case "desc":
+					t.Description = v
+				}
+			}
+
+			timings = append(timings, t)
+		}
+	}
+
+	return timings
+}
+
+// splitQuoted splits s at sep outside of double quoted strings
+func splitQuoted(s string, sep byte) []string {
+	var parts []string
+	start, quoted := 0, false
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quoted:
+			i++
+		case s[i] == '"':
+			quoted = !quoted
+		case s[i] == sep && !quoted:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+
+	return append(parts, s[start:])
+}
@@ -0,0 +1,206 @@
+package edgecenterprotection_go
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHeaderRequestID(t *testing.T) {
+	h := http.Header{}
+	if got := headerRequestID(h); got != "" {
+		t.Errorf("headerRequestID() of no headers = %q", got)
+	}
+
+	h.Set("X-Trace-Id", "trace")
+	h.Set("X-Correlation-Id", "correlation")
+	if got := headerRequestID(h); got != "correlation" {
+		t.Errorf("headerRequestID() = %q, want correlation", got)
+	}
+
+	h.Set("x-request-id", "request")
+	if got := headerRequestID(h); got != "request" {
+		t.Errorf("headerRequestID() = %q, want request", got)
+	}
+}
+
+func TestHeaderRate(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		header map[string]string
+		want   Rate
+	}{
+		{"none", nil, Rate{}},
+		{
+			"prefixed with delta reset",
+			map[string]string{"X-RateLimit-Limit": "100", "X-RateLimit-Remaining": "7", "X-RateLimit-Reset": "30"},
+			Rate{Limit: 100, Remaining: 7, Reset: now.Add(30 * time.Second)},
+		},
+		{
+			"unprefixed with timestamp reset",
+			map[string]string{"RateLimit-Limit": "10", "RateLimit-Remaining": "0", "RateLimit-Reset": "1792324800"},
+			Rate{Limit: 10, Reset: time.Unix(1792324800, 0)},
+		},
+		{
+			"prefixed header wins",
+			map[string]string{"X-RateLimit-Limit": "100", "RateLimit-Limit": "10", "RateLimit-Remaining": "5"},
+			Rate{Limit: 100, Remaining: 5},
+		},
+		{
+			"negative remaining",
+			map[string]string{"X-RateLimit-Limit": "100", "X-RateLimit-Remaining": "-1"},
+			Rate{Limit: 100},
+		},
+		{
+			"limit without remaining",
+			map[string]string{"X-RateLimit-Limit": "100"},
+			Rate{},
+		},
+		{
+			"zero limit",
+			map[string]string{"X-RateLimit-Limit": "0", "X-RateLimit-Remaining": "0", "X-RateLimit-Reset": "0"},
+			Rate{},
+		},
+		{
+			"malformed",
+			map[string]string{"X-RateLimit-Limit": "many", "X-RateLimit-Remaining": "1", "X-RateLimit-Reset": "soon", "Retry-After": "later"},
+			Rate{},
+		},
+		{
+			"retry after seconds",
+			map[string]string{"Retry-After": "120"},
+			Rate{RetryAfter: 2 * time.Minute},
+		},
+		{
+			"retry after date",
+			map[string]string{"Retry-After": now.Add(90 * time.Second).Format(http.TimeFormat)},
+			Rate{RetryAfter: 90 * time.Second},
+		},
+		{
+			"retry after past date",
+			map[string]string{"Retry-After": now.Add(-time.Minute).Format(http.TimeFormat)},
+			Rate{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := http.Header{}
+			for k, v := range tt.header {
+				h.Set(k, v)
+			}
+
+			got := headerRate(h, now)
+			if got.Limit != tt.want.Limit || got.Remaining != tt.want.Remaining || !got.Reset.Equal(tt.want.Reset) || got.RetryAfter != tt.want.RetryAfter {
+				t.Errorf("headerRate() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHeaderServerTiming(t *testing.T) {
+	h := http.Header{}
+	h.Add("Server-Timing", `db;dur=53.2;desc="Database, primary; read", cache;desc=hit`)
+	h.Add("Server-Timing", `total;DUR=120, ;dur=1, edge;dur=fast;desc="say \"hi\""`)
+
+	want := []ServerTiming{
+		{Name: "db", Duration: 53200 * time.Microsecond, Description: "Database, primary; read"},
+		{Name: "cache", Description: "hit"},
+		{Name: "total", Duration: 120 * time.Millisecond},
+		{Name: "edge", Description: `say "hi"`},
+	}
+	if got := headerServerTiming(h); !slices.Equal(got, want) {
+		t.Errorf("headerServerTiming() = %+v, want %+v", got, want)
+	}
+
+	if got := headerServerTiming(http.Header{}); got != nil {
+		t.Errorf("headerServerTiming() of no headers = %+v", got)
+	}
+}
+
+func TestSplitQuoted(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"a,b", []string{"a", "b"}},
+		{`a="x,y",b`, []string{`a="x,y"`, "b"}},
+		{`a="x\",y",b`, []string{`a="x\",y"`, "b"}},
+		{"", []string{""}},
+		{"a,", []string{"a", ""}},
+	}
+
+	for _, tt := range tests {
+		if got := splitQuoted(tt.in, ','); !slices.Equal(got, tt.want) {
+			t.Errorf("splitQuoted(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestResponseMetadata(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "header-id")
+		w.Header().Set("X-RateLimit-Limit", "100")
+		w.Header().Set("X-RateLimit-Remaining", "99")
+		w.Header().Set("Server-Timing", "app;dur=12")
+
+		switch r.URL.Path {
+		case "/v2/resources/1":
+			_, _ = w.Write([]byte(`{"id": 1}`))
+		case "/v2/resources/2":
+			_, _ = w.Write([]byte(`<html>maintenance</html>`))
+		case "/v2/resources/3":
+			w.Header().Set("Retry-After", "5")
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"message": "slow down", "request_id": "body-id"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	c := newTestClient(t, handler)
+	ctx := context.Background()
+
+	check := func(name string, resp *Response, status int, requestID string) {
+		t.Helper()
+		if resp == nil || resp.StatusCode != status || resp.RequestID != requestID || resp.Rate.Limit != 100 || resp.Rate.Remaining != 99 ||
+			len(resp.ServerTiming) != 1 || resp.ServerTiming[0].Duration != 12*time.Millisecond {
+			t.Errorf("%s response = %+v, want status %d and request ID %s with rate and timing", name, resp, status, requestID)
+		}
+	}
+
+	_, resp, err := c.Resources.Get(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("success", resp, http.StatusOK, "header-id")
+
+	// a body that cannot be decoded keeps the metadata of the response
+	_, resp, err = c.Resources.Get(ctx, 2)
+	var respErr *ResponseError
+	if err == nil || errors.As(err, &respErr) {
+		t.Errorf("Get() of a non-JSON body = %v, want a decoding error", err)
+	}
+	check("undecodable", resp, http.StatusOK, "header-id")
+
+	// the request ID in the error body takes precedence
+	_, resp, err = c.Resources.Get(ctx, 3)
+	if !errors.As(err, &respErr) || respErr.RequestID != "body-id" || respErr.Rate.RetryAfter != 5*time.Second || len(respErr.ServerTiming) != 1 {
+		t.Errorf("Get() = %+v, want a ResponseError with the body request ID and rate", err)
+	}
+	if err != nil && !strings.Contains(err.Error(), "(request ID body-id)") {
+		t.Errorf("error = %q, want the request ID", err)
+	}
+	check("rate limited", resp, http.StatusTooManyRequests, "body-id")
+
+	_, resp, err = c.Resources.Get(ctx, 4)
+	if !errors.As(err, &respErr) || respErr.RequestID != "header-id" {
+		t.Errorf("Get() = %v, want a ResponseError with the header request ID", err)
+	}
+	check("not found", resp, http.StatusNotFound, "header-id")
+}
@@ -0,0 +1,269 @@
+package edgecenterprotection_go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+const defaultRolloutSteps = 4
+
+// RolloutGroupWeight is the total weight RolloutOrigins gives an origin set at full traffic. During a rollout the
+// weights of the From and To origins add up to about this value, so primary origins outside the rollout should
+// use weights on the same scale.
+const RolloutGroupWeight = 100
+
+// OriginRollout describes a gradual shift of traffic from one set of origins of DDoS resource to another
+type OriginRollout struct {
+	// From and To are the IDs of the origins traffic is moved from and to
+	From []int64
+	To   []int64
+
+	// Steps is the number of weight changes, 4 by default, e.g. 25%, 50%, 75% and 100% of traffic to To
+	Steps int
+
+	// Pause is the time to wait after each step before the health check
+	Pause time.Duration
+
+	// HealthCheck is called after each step with the current state of the To origins, an error rolls back
+	HealthCheck func(ctx context.Context, step int, origins []Origin) error
+
+	// OnStep is called after each step passed the health check
+	OnStep func(step int, origins []Origin)
+}
+
+// OriginRolloutResult describes the outcome of RolloutOrigins
+type OriginRolloutResult struct {
+	// Steps is the number of completed steps
+	Steps int
+
+	// Origins is the last known state of the From and To origins
+	Origins []Origin
+
+	// RolledBack is set if the origins were restored after a failure
+	RolledBack bool
+}
+
+// RolloutOrigins moves traffic of DDoS resource from the From origins to the To origins in steps. At every step
+// each set gets its share of RolloutGroupWeight split proportionally to the original weights of its origins; since
+// the API treats zero weight as unset, weights never go below 1. After the last step the From origins are switched
+// to backup mode with their original weights, so they keep serving as a fallback.
+// If an update, the health check or the context fails, every origin is restored to its original weight and mode.
+func RolloutOrigins(ctx context.Context, s OriginsService, resourceID int64, r OriginRollout) (*OriginRolloutResult, error) {
+	if len(r.From) == 0 || len(r.To) == 0 {
+		return nil, NewArgError("OriginRollout", "From and To must be non-empty")
+	}
+
+	if r.Steps <= 0 {
+		r.Steps = defaultRolloutSteps
+	}
+
+	origins, err := originsByID(ctx, s, resourceID, append(append([]int64{}, r.From...), r.To...))
+	if err != nil {
+		return nil, err
+	}
+
+	from, to := origins[:len(r.From)], origins[len(r.From):]
+	for _, f := range from {
+		for _, t := range to {
+			if f.ID == t.ID {
+				return nil, NewArgError("OriginRollout", fmt.Sprintf("origin %d is in both From and To", f.ID))
+			}
+		}
+	}
+
+	fromShares, toShares := rolloutShares(from), rolloutShares(to)
+	current := append([]Origin{}, origins...)
+	result := &OriginRolloutResult{Origins: current}
+
+	fail := func(err error) (*OriginRolloutResult, error) {
+		result.RolledBack = true
+		if rbErr := restoreOrigins(context.WithoutCancel(ctx), s, resourceID, origins); rbErr != nil {
+			return result, errors.Join(err, fmt.Errorf("rollback failed: %w", rbErr))
+		}
+		result.Origins = origins
+		return result, err
+	}
+
+	for step := 1; step <= r.Steps; step++ {
+		p := float64(step) / float64(r.Steps)
+
+		// grow the new set before shrinking the old one to keep capacity
+		for i := range to {
+			current[len(from)+i], err = setOriginWeight(ctx, s, resourceID, to[i], rolloutWeight(toShares[i]*p), OriginModePrimary)
+			if err != nil {
+				return fail(err)
+			}
+		}
+
+		for i := range from {
+			weight, mode := rolloutWeight(fromShares[i]*(1-p)), OriginModePrimary
+			if step == r.Steps {
+				weight, mode = max(from[i].Weight, 1), OriginModeBackup
+			}
+
+			current[i], err = setOriginWeight(ctx, s, resourceID, from[i], weight, mode)
+			if err != nil {
+				return fail(err)
+			}
+		}
+
+		if err := sleepCtx(ctx, r.Pause); err != nil {
+			return fail(err)
+		}
+
+		if r.HealthCheck != nil {
+			if err := r.HealthCheck(ctx, step, current[len(from):]); err != nil {
+				return fail(fmt.Errorf("health check after step %d: %w", step, err))
+			}
+		}
+
+		result.Steps = step
+		if r.OnStep != nil {
+			r.OnStep(step, current)
+		}
+	}
+
+	return result, nil
+}
+
+// FlipOrigins swaps primary and backup origins of DDoS resource, e.g. to switch between blue and green deployments.
+// Backup origins are promoted before primary ones are demoted, so the resource always has a primary origin.
+// If any update fails, every origin is restored to its original mode.
+func FlipOrigins(ctx context.Context, s OriginsService, resourceID int64) ([]Origin, error) {
+	origins, err := listAllOrigins(ctx, s, resourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	var primaries, backups []Origin
+	for _, o := range origins {
+		if o.Mode == OriginModeBackup {
+			backups = append(backups, o)
+		} else {
+			primaries = append(primaries, o)
+		}
+	}
+
+	if len(backups) == 0 {
+		return nil, NewArgError("resourceID", fmt.Sprintf("resource %d has no backup origins to switch to", resourceID))
+	}
+
+	flipped := make([]Origin, 0, len(origins))
+	for _, group := range []struct {
+		origins []Origin
+		mode    OriginMode
+	}{{backups, OriginModePrimary}, {primaries, OriginModeBackup}} {
+		for _, o := range group.origins {
+			updated, err := setOriginWeight(ctx, s, resourceID, o, o.Weight, group.mode)
+			if err != nil {
+				if rbErr := restoreOrigins(context.WithoutCancel(ctx), s, resourceID, origins); rbErr != nil {
+					return nil, errors.Join(err, fmt.Errorf("rollback failed: %w", rbErr))
+				}
+				return nil, err
+			}
+			flipped = append(flipped, updated)
+		}
+	}
+
+	return flipped, nil
+}
+
+// ProbeHealthCheck returns a rollout health check probing the origins with ProbeOrigins, failing if any is unhealthy
+func ProbeHealthCheck(name string, opts *OriginProbeOptions) func(context.Context, int, []Origin) error {
+	return func(ctx context.Context, _ int, origins []Origin) error {
+		results, err := ProbeOrigins(ctx, name, origins, opts)
+		if err != nil {
+			return err
+		}
+
+		var errs []error
+		for _, res := range results {
+			if !res.OK() {
+				errs = append(errs, fmt.Errorf("origin %d (%s): %w", res.Origin.ID, res.Origin.IP, res.Err))
+			}
+		}
+
+		return errors.Join(errs...)
+	}
+}
+
+// originsByID lists the origins of DDoS resource from every page and returns the ones with the given IDs in the
+// same order
+func originsByID(ctx context.Context, s OriginsService, resourceID int64, ids []int64) ([]Origin, error) {
+	all, err := listAllOrigins(ctx, s, resourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int64]Origin, len(all))
+	for _, o := range all {
+		byID[o.ID] = o
+	}
+
+	origins := make([]Origin, len(ids))
+	for i, id := range ids {
+		o, ok := byID[id]
+		if !ok {
+			return nil, NewArgError("OriginRollout", fmt.Sprintf("origin %d does not belong to resource %d", id, resourceID))
+		}
+		origins[i] = o
+	}
+
+	return origins, nil
+}
+
+// rolloutShares splits the group weight between origins proportionally to their weights, counting zero as 1
+func rolloutShares(origins []Origin) []float64 {
+	total := 0
+	for _, o := range origins {
+		total += max(o.Weight, 1)
+	}
+
+	shares := make([]float64, len(origins))
+	for i, o := range origins {
+		shares[i] = float64(RolloutGroupWeight*max(o.Weight, 1)) / float64(total)
+	}
+
+	return shares
+}
+
+// rolloutWeight rounds the weight to an integer the API accepts
+func rolloutWeight(w float64) int {
+	return min(max(int(math.Round(w)), 1), RolloutGroupWeight)
+}
+
+// setOriginWeight updates the weight and the mode of the origin, keeping its other settings
+func setOriginWeight(ctx context.Context, s OriginsService, resourceID int64, o Origin, weight int, mode OriginMode) (Origin, error) {
+	req := OriginCreateRequestFromOrigin(&o)
+	req.Weight = weight
+	req.Mode = mode
+
+	updated, _, err := s.Update(ctx, resourceID, o.ID, req)
+	if err != nil {
+		return o, fmt.Errorf("updating origin %d: %w", o.ID, err)
+	}
+
+	return *updated, nil
+}
+
+// restoreOrigins writes back the original settings of the origins, continuing past failures. Unset modes and
+// weights are written as primary and 1, as omitting them would keep the values set during the rollout.
+func restoreOrigins(ctx context.Context, s OriginsService, resourceID int64, origins []Origin) error {
+	var errs []error
+	for _, o := range origins {
+		req := OriginCreateRequestFromOrigin(&o)
+		req.Weight = max(req.Weight, 1)
+		if req.Mode == "" {
+			req.Mode = OriginModePrimary
+		}
+
+		if _, _, err := s.Update(ctx, resourceID, o.ID, req); err != nil {
+			errs = append(errs, fmt.Errorf("restoring origin %d: %w", o.ID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
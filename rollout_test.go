@@ -0,0 +1,280 @@
+package edgecenterprotection_go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// rolloutOrigins applies updates to the listed origins, failing the updates fail reports
+type rolloutOrigins struct {
+	*fakeOrigins
+	updates int
+	fail    func(n int, id int64) bool
+}
+
+func (f *rolloutOrigins) Update(_ context.Context, _ int64, id int64, r *OriginCreateRequest) (*Origin, *Response, error) {
+	f.updates++
+	if f.fail != nil && f.fail(f.updates, id) {
+		return nil, nil, fmt.Errorf("update %d failed", f.updates)
+	}
+
+	for i := range f.existing {
+		if f.existing[i].ID == id {
+			o := &f.existing[i]
+			o.IP, o.Mode, o.Weight, o.MaxFails, o.FailTimeout, o.Comment = r.IP, r.Mode, r.Weight, r.MaxFails, r.FailTimeout, r.Comment
+			updated := *o
+			return &updated, nil, nil
+		}
+	}
+
+	return nil, nil, fmt.Errorf("origin %d not found", id)
+}
+
+// newRolloutOrigins returns origins 1 and 2 serving traffic and origins 3 and 4 on standby, listed after a full
+// page of other origins
+func newRolloutOrigins() *rolloutOrigins {
+	var origins []Origin
+	for i := range listPageSize {
+		origins = append(origins, Origin{ID: int64(1000 + i), IP: "192.0.2.1", Mode: OriginModePrimary, Weight: 50})
+	}
+	origins = append(origins,
+		Origin{ID: 1, IP: "192.0.2.10", Mode: OriginModePrimary, Weight: 10, MaxFails: 3, Comment: "blue"},
+		Origin{ID: 2, IP: "192.0.2.11", Mode: OriginModePrimary, Weight: 30},
+		Origin{ID: 3, IP: "192.0.2.20", Mode: OriginModeBackup, Comment: "green"},
+		Origin{ID: 4, IP: "192.0.2.21", Mode: OriginModeBackup},
+	)
+
+	return &rolloutOrigins{fakeOrigins: &fakeOrigins{existing: origins}}
+}
+
+func (f *rolloutOrigins) byID(id int64) Origin {
+	for _, o := range f.existing {
+		if o.ID == id {
+			return o
+		}
+	}
+
+	return Origin{}
+}
+
+// rolloutState formats the weight and the mode of the origins, e.g. "1:10 2:30b"
+func rolloutState(origins []Origin) string {
+	parts := make([]string, len(origins))
+	for i, o := range origins {
+		parts[i] = fmt.Sprintf("%d:%d", o.ID, o.Weight)
+		if o.Mode == OriginModeBackup {
+			parts[i] += "b"
+		}
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func TestRolloutOriginsSteps(t *testing.T) {
+	fake := newRolloutOrigins()
+
+	var steps, checked []string
+	result, err := RolloutOrigins(context.Background(), fake, 1, OriginRollout{
+		From: []int64{1, 2},
+		To:   []int64{3, 4},
+		HealthCheck: func(_ context.Context, step int, origins []Origin) error {
+			checked = append(checked, fmt.Sprintf("%d %s", step, rolloutState(origins)))
+			return nil
+		},
+		OnStep: func(step int, origins []Origin) {
+			steps = append(steps, rolloutState(origins))
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the To set shares the group weight evenly as both weights are unset, the From set keeps its 1:3 ratio
+	want := []string{
+		"1:19 2:56 3:13 4:13",
+		"1:13 2:38 3:25 4:25",
+		"1:6 2:19 3:38 4:38",
+		"1:10b 2:30b 3:50 4:50",
+	}
+	if !slices.Equal(steps, want) {
+		t.Errorf("steps = %q, want %q", steps, want)
+	}
+	if !slices.Equal(checked, []string{"1 3:13 4:13", "2 3:25 4:25", "3 3:38 4:38", "4 3:50 4:50"}) {
+		t.Errorf("health checks = %q", checked)
+	}
+
+	if result.Steps != 4 || result.RolledBack || rolloutState(result.Origins) != want[3] {
+		t.Errorf("result = %+v", result)
+	}
+
+	// settings other than the weight and the mode are kept
+	if o := fake.byID(1); o.MaxFails != 3 || o.Comment != "blue" || o.IP != "192.0.2.10" {
+		t.Errorf("origin 1 = %+v", o)
+	}
+	if o := fake.byID(1000); o.Weight != 50 {
+		t.Errorf("origin outside the rollout = %+v", o)
+	}
+}
+
+func TestRolloutOriginsRollback(t *testing.T) {
+	original := newRolloutOrigins().existing[listPageSize:]
+	restored := "1:10 2:30 3:1b 4:1b"
+
+	tests := []struct {
+		name        string
+		fail        func(n int, id int64) bool
+		healthCheck func(context.Context, int, []Origin) error
+		wantSteps   int
+		wantErr     string
+	}{
+		{
+			name: "update fails partway",
+			// the 7th update shrinks origin 1 in the second step
+			fail:      func(n int, _ int64) bool { return n == 7 },
+			wantSteps: 1,
+			wantErr:   "updating origin 1: update 7 failed",
+		},
+		{
+			name:      "first update fails",
+			fail:      func(n int, _ int64) bool { return n == 1 },
+			wantSteps: 0,
+			wantErr:   "updating origin 3",
+		},
+		{
+			name: "health check fails",
+			healthCheck: func(_ context.Context, step int, _ []Origin) error {
+				if step == 3 {
+					return errors.New("unhealthy")
+				}
+				return nil
+			},
+			wantSteps: 2,
+			wantErr:   "health check after step 3: unhealthy",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newRolloutOrigins()
+			fake.fail = tt.fail
+
+			result, err := RolloutOrigins(context.Background(), fake, 1, OriginRollout{From: []int64{1, 2}, To: []int64{3, 4}, HealthCheck: tt.healthCheck})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("RolloutOrigins() = %v, want %q", err, tt.wantErr)
+			}
+
+			if !result.RolledBack || result.Steps != tt.wantSteps || !slices.Equal(result.Origins, original) {
+				t.Errorf("result = %+v, want rollback after %d steps", result, tt.wantSteps)
+			}
+
+			// unset weights are written as 1 since an omitted weight would keep the rollout value
+			state := rolloutState([]Origin{fake.byID(1), fake.byID(2), fake.byID(3), fake.byID(4)})
+			if state != restored {
+				t.Errorf("origins after rollback = %q, want %q", state, restored)
+			}
+		})
+	}
+
+	// a failed rollback still restores the other origins and reports both errors
+	fake := newRolloutOrigins()
+	fake.fail = func(n int, id int64) bool { return n == 3 || (n > 3 && id == 2) }
+
+	result, err := RolloutOrigins(context.Background(), fake, 1, OriginRollout{From: []int64{1, 2}, To: []int64{3, 4}})
+	if err == nil || !strings.Contains(err.Error(), "updating origin 1") || !strings.Contains(err.Error(), "rollback failed: restoring origin 2") {
+		t.Errorf("RolloutOrigins() = %v, want update and rollback errors", err)
+	}
+	if !result.RolledBack {
+		t.Errorf("result = %+v, want RolledBack", result)
+	}
+	if state := rolloutState([]Origin{fake.byID(1), fake.byID(3), fake.byID(4)}); state != "1:10 3:1b 4:1b" {
+		t.Errorf("origins after a failed rollback = %q", state)
+	}
+}
+
+func TestRolloutOriginsArgs(t *testing.T) {
+	var argErr *ArgError
+
+	tests := []struct {
+		name     string
+		from, to []int64
+		want     string
+	}{
+		{"empty From", nil, []int64{3}, "must be non-empty"},
+		{"unknown origin", []int64{1}, []int64{99}, "origin 99 does not belong to resource 1"},
+		{"origin in both sets", []int64{1, 3}, []int64{3}, "origin 3 is in both From and To"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newRolloutOrigins()
+			_, err := RolloutOrigins(context.Background(), fake, 1, OriginRollout{From: tt.from, To: tt.to})
+			if !errors.As(err, &argErr) || !strings.Contains(argErr.reason, tt.want) {
+				t.Errorf("RolloutOrigins() = %v, want %q", err, tt.want)
+			}
+			if fake.updates != 0 {
+				t.Errorf("RolloutOrigins() made %d updates", fake.updates)
+			}
+		})
+	}
+}
+
+func TestOriginsByIDAllPages(t *testing.T) {
+	fake := newRolloutOrigins()
+
+	origins, err := originsByID(context.Background(), fake, 1, []int64{4, 1000, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := []int64{origins[0].ID, origins[1].ID, origins[2].ID}
+	if !slices.Equal(got, []int64{4, 1000, 1}) || fake.listCalls != 2 {
+		t.Errorf("originsByID() = %v in %d list calls", got, fake.listCalls)
+	}
+}
+
+func TestFlipOrigins(t *testing.T) {
+	fake := newRolloutOrigins()
+	fake.existing = fake.existing[listPageSize:]
+	fake.existing[2].Weight = 5
+
+	var order []int64
+	fake.fail = func(_ int, id int64) bool {
+		order = append(order, id)
+		return false
+	}
+
+	flipped, err := FlipOrigins(context.Background(), fake, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// backups are promoted first so the resource always has a primary origin
+	if !slices.Equal(order, []int64{3, 4, 1, 2}) {
+		t.Errorf("update order = %v", order)
+	}
+	if got := rolloutState(flipped); got != "3:5 4:0 1:10b 2:30b" {
+		t.Errorf("FlipOrigins() = %q", got)
+	}
+
+	fake = newRolloutOrigins()
+	fake.existing = fake.existing[listPageSize : listPageSize+2]
+	var argErr *ArgError
+	if _, err := FlipOrigins(context.Background(), fake, 1); !errors.As(err, &argErr) {
+		t.Errorf("FlipOrigins() without backups = %v, want ArgError", err)
+	}
+
+	// a failed update restores the original modes, writing unset weights as 1
+	fake = newRolloutOrigins()
+	fake.existing = fake.existing[listPageSize:]
+	fake.fail = func(n int, _ int64) bool { return n == 3 }
+	if _, err := FlipOrigins(context.Background(), fake, 1); err == nil {
+		t.Fatal("FlipOrigins() = nil, want the update error")
+	}
+	if got := rolloutState(fake.existing); got != "1:10 2:30 3:1b 4:1b" {
+		t.Errorf("origins after a failed flip = %q", got)
+	}
+}
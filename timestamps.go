@@ -0,0 +1,59 @@
+package edgecenterprotection_go
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// apiTimeLayouts lists the timestamp formats accepted from the API. Timestamps without a zone are UTC.
+var apiTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+}
+
+// parseAPITime parses a timestamp in any of the formats returned by the API. An empty string is the zero time.
+func parseAPITime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	for _, layout := range apiTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("unsupported timestamp format %q", s)
+}
+
+// unmarshalAPITime decodes a timestamp from a JSON string, a number of Unix seconds or null
+func unmarshalAPITime(data json.RawMessage) (time.Time, error) {
+	if len(data) == 0 || string(data) == "null" {
+		return time.Time{}, nil
+	}
+
+	if data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return time.Time{}, err
+		}
+
+		return parseAPITime(s)
+	}
+
+	var seconds float64
+	if err := json.Unmarshal(data, &seconds); err != nil {
+		return time.Time{}, fmt.Errorf("unsupported timestamp %s", data)
+	}
+
+	whole, frac := math.Modf(seconds)
+
+	return time.Unix(int64(whole), int64(math.Round(frac*float64(time.Second)))).UTC(), nil
+}
@@ -0,0 +1,151 @@
+package edgecenterprotection_go
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseAPITime(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"", time.Time{}},
+		{"2024-03-10T12:30:45Z", time.Date(2024, 3, 10, 12, 30, 45, 0, time.UTC)},
+		{"2024-03-10T12:30:45.123456+03:00", time.Date(2024, 3, 10, 9, 30, 45, 123456000, time.UTC)},
+		{"2024-03-10T12:30:45.5", time.Date(2024, 3, 10, 12, 30, 45, 500000000, time.UTC)},
+		{"2024-03-10 12:30:45+00:00", time.Date(2024, 3, 10, 12, 30, 45, 0, time.UTC)},
+		{" 2024-03-10 12:30:45 ", time.Date(2024, 3, 10, 12, 30, 45, 0, time.UTC)},
+		{"2024-03-10", time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		got, err := parseAPITime(tt.in)
+		if err != nil {
+			t.Errorf("parseAPITime(%q): %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseAPITime(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"10/03/2024", "2024-03-10T25:00:00Z", "yesterday"} {
+		if _, err := parseAPITime(in); err == nil {
+			t.Errorf("parseAPITime(%q) succeeded, want error", in)
+		}
+	}
+}
+
+func TestUnmarshalAPITime(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{`null`, time.Time{}},
+		{`""`, time.Time{}},
+		{`"2024-03-10T12:30:45Z"`, time.Date(2024, 3, 10, 12, 30, 45, 0, time.UTC)},
+		{`1710073845`, time.Date(2024, 3, 10, 12, 30, 45, 0, time.UTC)},
+		{`1710073845.25`, time.Date(2024, 3, 10, 12, 30, 45, 250000000, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		got, err := unmarshalAPITime(json.RawMessage(tt.in))
+		if err != nil {
+			t.Errorf("unmarshalAPITime(%s): %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("unmarshalAPITime(%s) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{`true`, `"soon"`, `{}`} {
+		if _, err := unmarshalAPITime(json.RawMessage(in)); err == nil {
+			t.Errorf("unmarshalAPITime(%s) succeeded, want error", in)
+		}
+	}
+}
+
+func TestResourceUnmarshalJSON(t *testing.T) {
+	var r Resource
+	err := json.Unmarshal([]byte(`{"id":7,"name":"example.com","created":"2024-03-10 12:30:45","updated":1710073846,"is_waf_enabled":true}`), &r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r.ID != 7 || r.Name != "example.com" || !r.WAF {
+		t.Errorf("fields not decoded: %+v", r)
+	}
+	if want := time.Date(2024, 3, 10, 12, 30, 45, 0, time.UTC); !r.CreatedAt.Equal(want) {
+		t.Errorf("CreatedAt = %v, want %v", r.CreatedAt, want)
+	}
+	if want := time.Date(2024, 3, 10, 12, 30, 46, 0, time.UTC); !r.UpdatedAt.Equal(want) {
+		t.Errorf("UpdatedAt = %v, want %v", r.UpdatedAt, want)
+	}
+
+	if err := json.Unmarshal([]byte(`{"id":7,"created":"not a time"}`), &r); err == nil {
+		t.Error("expected error for invalid created")
+	}
+}
+
+func TestAliasUnmarshalJSON(t *testing.T) {
+	var a Alias
+	err := json.Unmarshal([]byte(`{"id":3,"alias_data":"www.example.com","alias_created":"2024-03-10T12:30:45.000001Z","alias_updated":null,"alias_ssl_type":"le"}`), &a)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if a.ID != 3 || a.Name != "www.example.com" || a.SSLType == nil || *a.SSLType != "le" {
+		t.Errorf("fields not decoded: %+v", a)
+	}
+	if want := time.Date(2024, 3, 10, 12, 30, 45, 1000, time.UTC); !a.Created.Equal(want) {
+		t.Errorf("Created = %v, want %v", a.Created, want)
+	}
+	if !a.Updated.IsZero() {
+		t.Errorf("Updated = %v, want zero", a.Updated)
+	}
+
+	if err := json.Unmarshal([]byte(`{"alias_updated":"03/10/2024"}`), &a); err == nil {
+		t.Error("expected error for invalid alias_updated")
+	}
+}
+
+func TestResourceListOptionsQuery(t *testing.T) {
+	var query string
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		_, _ = w.Write([]byte(`{"count":0,"results":[]}`))
+	}))
+
+	msk := time.FixedZone("MSK", 3*3600)
+	opts := &ResourceListOptions{
+		Limit:     10,
+		CreatedGt: time.Date(2024, 3, 10, 12, 30, 45, 0, time.UTC),
+		UpdatedLt: time.Date(2024, 3, 11, 0, 0, 0, 0, msk),
+	}
+	if _, _, err := c.Resources.List(context.Background(), opts); err != nil {
+		t.Fatal(err)
+	}
+	if want := "created_gte=2024-03-10T12%3A30%3A45Z&limit=10&updated_lte=2024-03-11T00%3A00%3A00%2B03%3A00"; query != want {
+		t.Errorf("query = %s, want %s", query, want)
+	}
+
+	// the deprecated field is still sent as given
+	if _, _, err := c.Resources.List(context.Background(), &ResourceListOptions{CleatedLt: "2024-03-10"}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "created_lte=2024-03-10"; query != want {
+		t.Errorf("query = %s, want %s", query, want)
+	}
+
+	opts = &ResourceListOptions{CleatedLt: "2024-03-10", CreatedLt: time.Now()}
+	var argErr *ArgError
+	if _, _, err := c.Resources.List(context.Background(), opts); !errors.As(err, &argErr) || argErr.arg != "CleatedLt" {
+		t.Errorf("List() with CleatedLt and CreatedLt = %v, want CleatedLt ArgError", err)
+	}
+}
@@ -0,0 +1,239 @@
+package edgecenterprotection_go
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// traceParentHeader is the W3C Trace Context header propagating the span of a request
+const traceParentHeader = "traceparent"
+
+// Span attributes set by the client
+const (
+	SpanAttrService    = "edgecenter_protection.service"
+	SpanAttrOperation  = "edgecenter_protection.operation"
+	SpanAttrResourceID = "edgecenter_protection.resource_id"
+	SpanAttrAttempts   = "edgecenter_protection.attempts"
+	SpanAttrMethod     = "http.request.method"
+	SpanAttrEndpoint   = "url.template"
+	SpanAttrStatusCode = "http.response.status_code"
+)
+
+// Tracer starts spans around API requests, see WithTracer. It can be implemented on top of OpenTelemetry.
+type Tracer interface {
+	// Start starts a span named after the operation, e.g. Origins.Update, as a child of the span in ctx
+	Start(ctx context.Context, operation string) (context.Context, Span)
+}
+
+// Span is a span of an API request started by Tracer
+type Span interface {
+	// TraceParent returns the W3C traceparent header sent with the request, or "" to send none.
+	// FormatTraceParent builds it from the trace and span IDs. Values ParseTraceParent rejects are not sent.
+	TraceParent() string
+
+	SetAttribute(key string, value any)
+
+	// End finishes the span, err is the error of the request if it failed
+	End(err error)
+}
+
+// WithTracer is a client option starting a span around every API request and propagating it with
+// the traceparent header. Spans are tagged with the service, the operation, the resource ID and the number of attempts.
+func WithTracer(tracer Tracer) ClientOpt {
+	return func(c *Client) error {
+		if tracer == nil {
+			return NewArgError("tracer", "cannot be nil")
+		}
+
+		c.tracer = tracer
+
+		return nil
+	}
+}
+
+// FormatTraceParent returns the W3C traceparent header of the span, or "" if either ID is all zeros
+func FormatTraceParent(traceID [16]byte, spanID [8]byte, sampled bool) string {
+	if traceID == [16]byte{} || spanID == [8]byte{} {
+		return ""
+	}
+
+	flags := "00"
+	if sampled {
+		flags = "01"
+	}
+
+	return "00-" + hex.EncodeToString(traceID[:]) + "-" + hex.EncodeToString(spanID[:]) + "-" + flags
+}
+
+// TraceParent is a parsed W3C traceparent header
+type TraceParent struct {
+	Version byte
+	TraceID [16]byte
+	SpanID  [8]byte
+	Flags   byte
+}
+
+// Sampled reports whether the sampled flag is set
+func (tp TraceParent) Sampled() bool {
+	return tp.Flags&1 == 1
+}
+
+// String returns the header in the version 00 format
+func (tp TraceParent) String() string {
+	return "00-" + hex.EncodeToString(tp.TraceID[:]) + "-" + hex.EncodeToString(tp.SpanID[:]) + "-" +
+		hex.EncodeToString([]byte{tp.Flags})
+}
+
+// ParseTraceParent parses a W3C traceparent header. The fields must be lowercase hex and the IDs must not be
+// all zeros. Headers of later versions may carry more fields after the flags, version 00 headers may not.
+func ParseTraceParent(s string) (TraceParent, error) {
+	var tp TraceParent
+
+	// version, trace ID, span ID and flags separated by dashes
+	const size = 2 + 1 + 32 + 1 + 16 + 1 + 2
+	if len(s) < size || s[2] != '-' || s[35] != '-' || s[52] != '-' {
+		return tp, NewArgError("traceparent", fmt.Sprintf("%q is not in the version-traceid-spanid-flags format", s))
+	}
+
+	fields := []struct {
+		name string
+		hex  string
+		dst  []byte
+	}{
+		{"version", s[0:2], []byte{0}},
+		{"trace ID", s[3:35], tp.TraceID[:]},
+		{"span ID", s[36:52], tp.SpanID[:]},
+		{"flags", s[53:55], []byte{0}},
+	}
+	for _, f := range fields {
+		if strings.ToLower(f.hex) != f.hex {
+			return tp, NewArgError("traceparent", fmt.Sprintf("%s %q must be lowercase hex", f.name, f.hex))
+		}
+		if _, err := hex.Decode(f.dst, []byte(f.hex)); err != nil {
+			return tp, NewArgError("traceparent", fmt.Sprintf("%s %q is not hex", f.name, f.hex))
+		}
+	}
+	tp.Version, tp.Flags = fields[0].dst[0], fields[3].dst[0]
+
+	switch {
+	case tp.Version == 0xff:
+		return tp, NewArgError("traceparent", "version ff is invalid")
+	case tp.Version == 0 && len(s) != size:
+		return tp, NewArgError("traceparent", fmt.Sprintf("%q has data after the flags", s))
+	case len(s) > size && s[size] != '-':
+		return tp, NewArgError("traceparent", fmt.Sprintf("%q has no dash after the flags", s))
+	case tp.TraceID == [16]byte{}:
+		return tp, NewArgError("traceparent", "trace ID cannot be all zeros")
+	case tp.SpanID == [8]byte{}:
+		return tp, NewArgError("traceparent", "span ID cannot be all zeros")
+	}
+
+	return tp, nil
+}
+
+// serviceNames maps path segments to the names of the services of Client
+var serviceNames = map[string]string{
+	"resources":  "Resources",
+	"aliases":    "Aliases",
+	"origins":    "Origins",
+	"headers":    "Headers",
+	"blacklists": "Blacklists",
+	"whitelists": "Whitelists",
+}
+
+// requestOperation describes the client method an API request was sent by
+type requestOperation struct {
+	service    string
+	name       string
+	endpoint   string
+	resourceID int64
+}
+
+// String returns the operation name, e.g. Origins.Update
+func (o requestOperation) String() string {
+	return o.service + "." + o.name
+}
+
+// operationFromRequest derives the service and the method sending the request from its method and path
+func operationFromRequest(basePath string, req *http.Request) requestOperation {
+	op := requestOperation{endpoint: metricsEndpoint(basePath, req.URL.Path)}
+
+	switch op.endpoint {
+	case webprotectionBasePathV1:
+		op.service, op.name = "Services", "GetWebProtectionService"
+		return op
+	case infrastructureprotectionBasePathV1:
+		op.service, op.name = "Services", "GetInfrastructureProtectionService"
+		return op
+	}
+
+	segments := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	for i, s := range segments {
+		if s == "resources" && i+1 < len(segments) {
+			op.resourceID, _ = strconv.ParseInt(segments[i+1], 10, 64)
+		}
+	}
+
+	templated := strings.Split(strings.Trim(op.endpoint, "/"), "/")
+	last := templated[len(templated)-1]
+	for i := len(templated) - 1; i >= 0 && op.service == ""; i-- {
+		op.service = serviceNames[templated[i]]
+	}
+
+	switch {
+	case op.service == "":
+		op.service, op.name = "Client", req.Method
+	case last == resourcesDnsCheck:
+		op.name = "GetDomainName"
+	case req.Method == http.MethodGet && last == "{id}":
+		op.name = "Get"
+	case req.Method == http.MethodGet:
+		op.name = "List"
+	case req.Method == http.MethodPost:
+		op.name = "Create"
+	case req.Method == http.MethodPatch || req.Method == http.MethodPut:
+		op.name = "Update"
+	case req.Method == http.MethodDelete:
+		op.name = "Delete"
+	default:
+		op.name = req.Method
+	}
+
+	return op
+}
+
+// startSpan starts the span of the request and sets its traceparent header
+func (c *Client) startSpan(ctx context.Context, req *http.Request) (context.Context, Span) {
+	op := operationFromRequest(c.BaseURL.Path, req)
+
+	ctx, span := c.tracer.Start(ctx, op.String())
+	span.SetAttribute(SpanAttrService, op.service)
+	span.SetAttribute(SpanAttrOperation, op.String())
+	span.SetAttribute(SpanAttrMethod, req.Method)
+	span.SetAttribute(SpanAttrEndpoint, op.endpoint)
+	if op.resourceID != 0 {
+		span.SetAttribute(SpanAttrResourceID, op.resourceID)
+	}
+
+	if tp := span.TraceParent(); tp != "" {
+		if _, err := ParseTraceParent(tp); err == nil {
+			req.Header.Set(traceParentHeader, tp)
+		}
+	}
+
+	return ctx, span
+}
+
+// endSpan tags the span with the outcome of the request and ends it
+func endSpan(span Span, resp *Response, err error, attempts int) {
+	span.SetAttribute(SpanAttrAttempts, attempts)
+	if status := apiStatusCode(resp, err); status != 0 {
+		span.SetAttribute(SpanAttrStatusCode, status)
+	}
+
+	span.End(err)
+}
@@ -0,0 +1,227 @@
+package edgecenterprotection_go
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+)
+
+// fakeTracer records the spans it starts, each sending traceParent
+type fakeTracer struct {
+	mu          sync.Mutex
+	traceParent string
+	spans       []*fakeSpan
+}
+
+type fakeSpan struct {
+	operation   string
+	traceParent string
+	attrs       map[string]any
+	ended       bool
+	err         error
+}
+
+func (f *fakeTracer) Start(ctx context.Context, operation string) (context.Context, Span) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	span := &fakeSpan{operation: operation, traceParent: f.traceParent, attrs: make(map[string]any)}
+	f.spans = append(f.spans, span)
+
+	return ctx, span
+}
+
+func (s *fakeSpan) TraceParent() string {
+	return s.traceParent
+}
+
+func (s *fakeSpan) SetAttribute(key string, value any) {
+	s.attrs[key] = value
+}
+
+func (s *fakeSpan) End(err error) {
+	s.ended, s.err = true, err
+}
+
+func TestFormatTraceParent(t *testing.T) {
+	traceID := [16]byte{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36}
+	spanID := [8]byte{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7}
+
+	want := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	if got := FormatTraceParent(traceID, spanID, true); got != want {
+		t.Errorf("FormatTraceParent() = %q, want %q", got, want)
+	}
+	if got := FormatTraceParent(traceID, spanID, false); got != want[:53]+"00" {
+		t.Errorf("FormatTraceParent() unsampled = %q", got)
+	}
+	if FormatTraceParent([16]byte{}, spanID, true) != "" || FormatTraceParent(traceID, [8]byte{}, true) != "" {
+		t.Error("FormatTraceParent() with a zero ID is not empty")
+	}
+
+	// generated headers parse back to the same IDs
+	tp, err := ParseTraceParent(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tp.TraceID != traceID || tp.SpanID != spanID || !tp.Sampled() || tp.Version != 0 || tp.String() != want {
+		t.Errorf("ParseTraceParent() = %+v", tp)
+	}
+}
+
+func TestParseTraceParent(t *testing.T) {
+	const valid = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	tests := []struct {
+		name    string
+		in      string
+		wantErr bool
+	}{
+		{"valid", valid, false},
+		{"unsampled with other flags", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-02", false},
+		{"later version", "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false},
+		{"later version with more fields", "cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-what-the-future-holds", false},
+
+		{"empty", "", true},
+		{"short trace ID", "00-4bf92f3577b34da6a3ce929d0e0e473-00f067aa0ba902b7-01", true},
+		{"long span ID", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7a-01", true},
+		{"missing flags", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7", true},
+		{"wrong separator", "00_4bf92f3577b34da6a3ce929d0e0e4736_00f067aa0ba902b7_01", true},
+		{"uppercase", "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", true},
+		{"not hex", "00-4bf92f3577b34da6a3ce929d0e0e473g-00f067aa0ba902b7-01", true},
+		{"signed hex", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-+1", true},
+		{"zero trace ID", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", true},
+		{"zero span ID", "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", true},
+		{"version ff", "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true},
+		{"version 00 with more fields", valid + "-extra", true},
+		{"trailing space", valid + " ", true},
+		{"later version without dash", "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01x", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseTraceParent(tt.in)
+			if !tt.wantErr {
+				if err != nil {
+					t.Errorf("ParseTraceParent() = %v", err)
+				}
+				return
+			}
+
+			var argErr *ArgError
+			if !errors.As(err, &argErr) {
+				t.Errorf("ParseTraceParent() = %v, want ArgError", err)
+			}
+		})
+	}
+}
+
+func TestWithTracer(t *testing.T) {
+	var received []string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Header.Get("traceparent"))
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	})
+
+	c := newTestClient(t, handler)
+	tracer := &fakeTracer{traceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}
+	if err := WithTracer(tracer)(c); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if _, _, err := c.Origins.Get(ctx, 12, 34); err != nil {
+		t.Fatal(err)
+	}
+	_, err := c.Origins.Delete(ctx, 12, 34)
+	if err == nil {
+		t.Fatal("Delete() = nil, want the 404 error")
+	}
+
+	if len(tracer.spans) != 2 {
+		t.Fatalf("started %d spans, want 2", len(tracer.spans))
+	}
+
+	get := tracer.spans[0]
+	want := map[string]any{
+		SpanAttrService:    "Origins",
+		SpanAttrOperation:  "Origins.Get",
+		SpanAttrMethod:     http.MethodGet,
+		SpanAttrEndpoint:   "/v2/resources/{id}/origins/{id}",
+		SpanAttrResourceID: int64(12),
+		SpanAttrAttempts:   1,
+		SpanAttrStatusCode: http.StatusOK,
+	}
+	if get.operation != "Origins.Get" || !get.ended || get.err != nil || len(get.attrs) != len(want) {
+		t.Errorf("span = %+v", get)
+	}
+	for k, v := range want {
+		if get.attrs[k] != v {
+			t.Errorf("attribute %s = %v, want %v", k, get.attrs[k], v)
+		}
+	}
+
+	if del := tracer.spans[1]; del.operation != "Origins.Delete" || del.err != err || del.attrs[SpanAttrStatusCode] != http.StatusNotFound {
+		t.Errorf("span = %+v, want the 404 error", del)
+	}
+
+	if received[0] != tracer.traceParent || received[1] != tracer.traceParent {
+		t.Errorf("received traceparent %q, want %q", received, tracer.traceParent)
+	}
+
+	// spans without a valid traceparent send none
+	received = nil
+	for _, tp := range []string{"", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", "garbage"} {
+		tracer.traceParent = tp
+		if _, _, err := c.Resources.Get(ctx, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, tp := range received {
+		if tp != "" {
+			t.Errorf("request %d sent traceparent %q", i, tp)
+		}
+	}
+
+	var argErr *ArgError
+	if _, err := New(nil, WithTracer(nil)); !errors.As(err, &argErr) {
+		t.Errorf("WithTracer(nil) = %v, want ArgError", err)
+	}
+}
+
+func TestOperationFromRequest(t *testing.T) {
+	tests := []struct {
+		method, path string
+		want         requestOperation
+	}{
+		{http.MethodGet, "/v2/resources", requestOperation{service: "Resources", name: "List", endpoint: "/v2/resources"}},
+		{http.MethodPost, "/v2/resources", requestOperation{service: "Resources", name: "Create", endpoint: "/v2/resources"}},
+		{http.MethodGet, "/v2/resources/7", requestOperation{service: "Resources", name: "Get", endpoint: "/v2/resources/{id}", resourceID: 7}},
+		{http.MethodGet, "/v2/resources/7/" + resourcesDnsCheck, requestOperation{service: "Resources", name: "GetDomainName", endpoint: "/v2/resources/{id}/" + resourcesDnsCheck, resourceID: 7}},
+		{http.MethodGet, "/v2/resources/7/aliases", requestOperation{service: "Aliases", name: "List", endpoint: "/v2/resources/{id}/aliases", resourceID: 7}},
+		{http.MethodPatch, "/v2/resources/7/headers/9", requestOperation{service: "Headers", name: "Update", endpoint: "/v2/resources/{id}/headers/{id}", resourceID: 7}},
+		{http.MethodDelete, "/v2/resources/7/blacklists/9", requestOperation{service: "Blacklists", name: "Delete", endpoint: "/v2/resources/{id}/blacklists/{id}", resourceID: 7}},
+		{http.MethodGet, "/v2/other", requestOperation{service: "Client", name: http.MethodGet, endpoint: "/v2/other"}},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		if got := operationFromRequest("", req); got != tt.want {
+			t.Errorf("operationFromRequest(%s %s) = %+v, want %+v", tt.method, tt.path, got, tt.want)
+		}
+	}
+
+	// the service endpoints are matched after the base path is removed
+	u, _ := url.Parse("https://api.example.com/protection" + webprotectionBasePathV1)
+	got := operationFromRequest("/protection", &http.Request{Method: http.MethodGet, URL: u})
+	if got.String() != "Services.GetWebProtectionService" {
+		t.Errorf("operationFromRequest() = %s", got)
+	}
+}
@@ -95,6 +95,12 @@ func (s *WhitelistsServiceOp) Create(ctx context.Context, resourceID int64, reqB
 		return nil, nil, NewArgError("reqBody", "cannot be nil")
 	}
 
+	if s.client.listConflictCheck {
+		if err := checkWhitelistConflict(ctx, s.client, resourceID, reqBody.IP); err != nil {
+			return nil, nil, err
+		}
+	}
+
 	req, err := s.client.NewRequest(ctx, http.MethodPost, path, reqBody)
 	if err != nil {
 		return nil, nil, err
@@ -128,6 +134,12 @@ func (s *WhitelistsServiceOp) Update(ctx context.Context, resourceID int64, whit
 		return nil, nil, NewArgError("reqBody", "cannot be nil")
 	}
 
+	if s.client.listConflictCheck {
+		if err := checkWhitelistConflict(ctx, s.client, resourceID, reqBody.IP); err != nil {
+			return nil, nil, err
+		}
+	}
+
 	path := fmt.Sprintf("%s/%d/%s/%d", resourcesBasePathV2, resourceID, whitelistsPathV2, whitelistID)
 
 	req, err := s.client.NewRequest(ctx, http.MethodPatch, path, reqBody)
@@ -143,3 +155,30 @@ func (s *WhitelistsServiceOp) Update(ctx context.Context, resourceID int64, whit
 
 	return whitelist, resp, err
 }
+
+// CreateAggregatedWhitelists adds whitelists for DDoS resource through s, merging adjacent and overlapping addresses into the minimal set of prefixes.
+// The aggregation result reports the extra address space covered when opts.MaxEntries is set.
+func CreateAggregatedWhitelists(ctx context.Context, s WhitelistsService, resourceID int64, ips []string, opts *AggregateOptions) ([]Whitelist, *AggregateResult, *Response, error) {
+	var whitelists []Whitelist
+
+	result, resp, err := createAggregated(ips, opts, func(ip string) (*Response, error) {
+		whitelist, resp, err := s.Create(ctx, resourceID, &WhitelistCreateRequest{IP: ip})
+		if err != nil {
+			return resp, err
+		}
+
+		whitelists = append(whitelists, *whitelist)
+
+		return resp, nil
+	})
+
+	return whitelists, result, resp, err
+}
+
+// listAllWhitelists returns the whitelists of DDoS resource from every page
+func listAllWhitelists(ctx context.Context, s WhitelistsService, resourceID int64) ([]Whitelist, error) {
+	return listAll(ctx, func(ctx context.Context, limit, offset int) ([]Whitelist, error) {
+		whitelists, _, err := s.List(ctx, resourceID, &WhitelistListOptions{Limit: limit, Offset: offset})
+		return whitelists, err
+	})
+}
@@ -0,0 +1,542 @@
+package edgecenterprotection_go
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/netip"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// maxCNAMEChain limits following CNAME records within a zone
+const maxCNAMEChain = 8
+
+// ZoneRecord is a resource record of a zone file. Names are absolute, lowercase and without the trailing dot.
+type ZoneRecord struct {
+	Name  string
+	TTL   uint32
+	Class string
+	Type  string
+	Data  []string
+	Line  int
+}
+
+// String returns the record in zone file format
+func (r ZoneRecord) String() string {
+	return fmt.Sprintf("%s.\t%d\t%s\t%s\t%s", r.Name, r.TTL, r.Class, r.Type, strings.Join(r.Data, " "))
+}
+
+// ZoneRecordChange is a DNS change needed to put a name behind DDoS protection
+type ZoneRecordChange struct {
+	// Record is the current record, its Type is empty if a record has to be added
+	Record ZoneRecord
+
+	// Want is the record that should replace it, e.g. "A 203.0.113.1", empty if it should be removed
+	Want string
+
+	Reason string
+}
+
+// ZoneAliasResult describes the aliases found in a zone for DDoS resource
+type ZoneAliasResult struct {
+	// Aliases are the proposed aliases, names already registered for the resource are left out
+	Aliases []AliasCreateRequest
+
+	// Created are the aliases created by ImportZoneAliases
+	Created []Alias
+
+	// Changes are the records that still have to be changed for the resource and its aliases to be protected
+	Changes []ZoneRecordChange
+
+	// Candidates are names pointing only at addresses the resource is reachable at without protection
+	Candidates []ZoneAliasCandidate
+
+	Warnings []string
+}
+
+// ZoneAliasCandidate is a name sharing an address with the origins of DDoS resource or its unprotected records,
+// e.g. a mail or FTP host on the same server. It is not proposed as an alias, as it may not serve the site.
+type ZoneAliasCandidate struct {
+	Name string
+
+	// Addresses are the addresses of the resource the name points at
+	Addresses []netip.Addr
+
+	// Line is the line of the first record of the name
+	Line int
+}
+
+// ParseZoneFile parses a zone file in BIND format. The $ORIGIN and $TTL directives, parentheses, "@",
+// relative names, blank owners and omitted TTL and class are supported; $INCLUDE and $GENERATE are not.
+// The origin is used until the zone sets its own and may be empty if the zone uses only absolute names.
+func ParseZoneFile(r io.Reader, origin string) ([]ZoneRecord, error) {
+	origin = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(origin), "."))
+
+	lines, err := zoneLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		records    []ZoneRecord
+		owner      string
+		defaultTTL uint32
+		hasTTL     bool
+	)
+
+	for _, l := range lines {
+		tokens := l.tokens
+
+		if strings.HasPrefix(tokens[0], "$") {
+			switch strings.ToUpper(tokens[0]) {
+			case "$ORIGIN":
+				if len(tokens) < 2 {
+					return nil, fmt.Errorf("line %d: $ORIGIN without a name", l.line)
+				}
+				if origin, err = zoneName(tokens[1], origin); err != nil {
+					return nil, fmt.Errorf("line %d: %w", l.line, err)
+				}
+			case "$TTL":
+				if len(tokens) < 2 {
+					return nil, fmt.Errorf("line %d: $TTL without a value", l.line)
+				}
+				if defaultTTL, err = parseZoneTTL(tokens[1]); err != nil {
+					return nil, fmt.Errorf("line %d: %w", l.line, err)
+				}
+				hasTTL = true
+			default:
+				return nil, fmt.Errorf("line %d: %s is not supported", l.line, tokens[0])
+			}
+			continue
+		}
+
+		if !l.blankOwner {
+			if owner, err = zoneName(tokens[0], origin); err != nil {
+				return nil, fmt.Errorf("line %d: %w", l.line, err)
+			}
+			tokens = tokens[1:]
+		} else if owner == "" {
+			return nil, fmt.Errorf("line %d: record without an owner name", l.line)
+		}
+
+		rec := ZoneRecord{Name: owner, TTL: defaultTTL, Class: "IN", Line: l.line}
+		ttlSet := false
+		for i := 0; i < 2 && len(tokens) > 0; i++ {
+			if c := strings.ToUpper(tokens[0]); c == "IN" || c == "CH" || c == "HS" || c == "CS" {
+				rec.Class = c
+				tokens = tokens[1:]
+			} else if ttl, err := parseZoneTTL(tokens[0]); err == nil {
+				rec.TTL, ttlSet = ttl, true
+				tokens = tokens[1:]
+			}
+		}
+
+		if len(tokens) == 0 {
+			return nil, fmt.Errorf("line %d: record without a type", l.line)
+		}
+
+		rec.Type = strings.ToUpper(tokens[0])
+		rec.Data = tokens[1:]
+
+		if !ttlSet && !hasTTL && len(records) > 0 {
+			rec.TTL = records[len(records)-1].TTL
+		}
+
+		if err := resolveZoneData(&rec, origin); err != nil {
+			return nil, fmt.Errorf("line %d: %w", l.line, err)
+		}
+
+		records = append(records, rec)
+	}
+
+	return records, nil
+}
+
+// ProposeZoneAliases finds names in the zone that belong to DDoS resource: names with a CNAME chain to the resource
+// name and names with A or AAAA records pointing at its service IP. Names not yet pointing at the service IP are
+// reported in Changes, as is the resource name itself. Names pointing only at its origins or at the addresses the
+// resource name has in the zone are reported as Candidates. Existing aliases are not proposed again.
+func ProposeZoneAliases(records []ZoneRecord, resource *Resource, origins []Origin, existing []Alias) *ZoneAliasResult {
+	result := &ZoneAliasResult{}
+
+	name, err := NormalizeDomainName(resource.Name)
+	if err != nil {
+		result.Warnings = append(result.Warnings, err.Error())
+		return result
+	}
+	serviceIP, _ := netip.ParseAddr(resource.ServiceIP)
+
+	byName := make(map[string][]ZoneRecord)
+	var names []string
+	for _, rec := range records {
+		n := strings.ToLower(rec.Name)
+		if _, ok := byName[n]; !ok {
+			names = append(names, n)
+		}
+		byName[n] = append(byName[n], rec)
+	}
+
+	// addresses the resource is reachable at without protection
+	direct := make(map[netip.Addr]bool)
+	for _, o := range origins {
+		if addr, err := parseOriginAddress(o.IP, false); err == nil && addr.IsIP() {
+			direct[addr.IP] = true
+		}
+	}
+	for _, rec := range byName[name] {
+		if ip, ok := zoneRecordAddr(rec); ok && ip != serviceIP {
+			direct[ip] = true
+		}
+	}
+
+	registered := map[string]bool{name: true}
+	for _, a := range existing {
+		if n, err := NormalizeDomainName(a.Name); err == nil {
+			registered[n] = true
+		}
+	}
+
+	seen := make(map[string]bool)
+	addChanges := func(changes []ZoneRecordChange) {
+		for _, ch := range changes {
+			key := fmt.Sprintf("%s/%d/%s", ch.Record.Name, ch.Record.Line, ch.Want)
+			if !seen[key] {
+				seen[key] = true
+				result.Changes = append(result.Changes, ch)
+			}
+		}
+	}
+
+	addChanges(zoneAddressChanges(byName[name], serviceIP, name))
+
+	for _, n := range names {
+		if n == name {
+			continue
+		}
+
+		recs := byName[n]
+		target, viaCNAME := followZoneCNAME(byName, n)
+
+		belongs := viaCNAME && target == name
+		var directAddrs []netip.Addr
+		if !belongs {
+			for _, rec := range byName[target] {
+				ip, ok := zoneRecordAddr(rec)
+				switch {
+				case !ok:
+				case ip == serviceIP:
+					belongs = true
+				case direct[ip]:
+					directAddrs = append(directAddrs, ip)
+				}
+			}
+		}
+		if !belongs {
+			if len(directAddrs) > 0 && !registered[n] {
+				result.Candidates = append(result.Candidates, ZoneAliasCandidate{Name: n, Addresses: directAddrs, Line: recs[0].Line})
+			}
+			continue
+		}
+
+		alias, err := NormalizeDomainName(n)
+		if err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("line %d: %s", recs[0].Line, err))
+			continue
+		}
+
+		if isWildcardDomain(alias) && !resource.WidlcardAliases {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("line %d: wildcard alias %s requires wildcard aliases to be enabled for the resource", recs[0].Line, alias))
+		}
+
+		if !registered[alias] {
+			registered[alias] = true
+			result.Aliases = append(result.Aliases, AliasCreateRequest{Name: alias})
+		}
+
+		if !viaCNAME || target != name {
+			addChanges(zoneAddressChanges(byName[target], serviceIP, name))
+		}
+	}
+
+	return result
+}
+
+// ImportZoneAliases proposes aliases for DDoS resource from the zone records and creates them unless dryRun is set.
+// Every page of origins and aliases of the resource is taken into account.
+func ImportZoneAliases(ctx context.Context, c *Client, resourceID int64, records []ZoneRecord, dryRun bool) (*ZoneAliasResult, error) {
+	resource, _, err := c.Resources.Get(ctx, resourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	origins, err := listAllOrigins(ctx, c.Origins, resourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := listAllAliases(ctx, c.Aliases, resourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := ProposeZoneAliases(records, resource, origins, existing)
+	if dryRun {
+		return result, nil
+	}
+
+	for i := range result.Aliases {
+		alias, _, err := CreateAliasForResource(ctx, c.Aliases, resource, &result.Aliases[i])
+		if err != nil {
+			return result, fmt.Errorf("creating alias %s: %w", result.Aliases[i].Name, err)
+		}
+		result.Created = append(result.Created, *alias)
+	}
+
+	return result, nil
+}
+
+// zoneAddressChanges reports address records of a name that do not point at the service IP
+func zoneAddressChanges(records []ZoneRecord, serviceIP netip.Addr, resourceName string) []ZoneRecordChange {
+	var changes []ZoneRecordChange
+	var hasServiceIP bool
+
+	for _, rec := range records {
+		ip, ok := zoneRecordAddr(rec)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case ip == serviceIP:
+			hasServiceIP = true
+		case serviceIP.IsValid() && ip.Is4() == serviceIP.Is4():
+			changes = append(changes, ZoneRecordChange{Record: rec, Want: rec.Type + " " + serviceIP.String(),
+				Reason: "points past DDoS protection of " + resourceName})
+		default:
+			changes = append(changes, ZoneRecordChange{Record: rec,
+				Reason: "bypasses DDoS protection of " + resourceName + ", the service IP has no such address family"})
+		}
+	}
+
+	if !hasServiceIP && len(changes) == 0 && len(records) > 0 && serviceIP.IsValid() {
+		rec := records[0]
+		if rec.Type != "CNAME" {
+			changes = append(changes, ZoneRecordChange{Record: ZoneRecord{Name: rec.Name, TTL: rec.TTL, Class: rec.Class},
+				Want: zoneAddressType(serviceIP) + " " + serviceIP.String(), Reason: "has no record pointing at the service IP"})
+		}
+	}
+
+	return changes
+}
+
+// followZoneCNAME follows CNAME records from name within the zone, returning the final name and whether any was followed
+func followZoneCNAME(byName map[string][]ZoneRecord, name string) (string, bool) {
+	followed := false
+	for i := 0; i < maxCNAMEChain; i++ {
+		next := ""
+		for _, rec := range byName[name] {
+			if rec.Type == "CNAME" && len(rec.Data) == 1 {
+				next = rec.Data[0]
+			}
+		}
+		if next == "" {
+			break
+		}
+		name, followed = next, true
+	}
+
+	return name, followed
+}
+
+// zoneRecordAddr returns the address of an A or AAAA record
+func zoneRecordAddr(rec ZoneRecord) (netip.Addr, bool) {
+	if (rec.Type != "A" && rec.Type != "AAAA") || len(rec.Data) != 1 {
+		return netip.Addr{}, false
+	}
+
+	ip, err := netip.ParseAddr(rec.Data[0])
+	if err != nil {
+		return netip.Addr{}, false
+	}
+
+	return ip.Unmap(), true
+}
+
+func zoneAddressType(ip netip.Addr) string {
+	if ip.Is4() {
+		return "A"
+	}
+
+	return "AAAA"
+}
+
+// zoneLine is a logical line of a zone file with parentheses joined and comments removed
+type zoneLine struct {
+	tokens     []string
+	blankOwner bool
+	line       int
+}
+
+// zoneLines splits a zone file into logical lines
+func zoneLines(r io.Reader) ([]zoneLine, error) {
+	var lines []zoneLine
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var cur *zoneLine
+	depth, lineNo := 0, 0
+	for scanner.Scan() {
+		lineNo++
+		text := scanner.Text()
+
+		if cur == nil {
+			cur = &zoneLine{line: lineNo, blankOwner: text != "" && unicode.IsSpace(rune(text[0]))}
+		}
+
+		inQuote := false
+		var tok strings.Builder
+		flush := func() {
+			if tok.Len() > 0 {
+				cur.tokens = append(cur.tokens, tok.String())
+				tok.Reset()
+			}
+		}
+
+	scan:
+		for i := 0; i < len(text); i++ {
+			c := text[i]
+			switch {
+			case inQuote && c == '\\' && i+1 < len(text):
+				tok.WriteByte(c)
+				tok.WriteByte(text[i+1])
+				i++
+			case c == '"':
+				tok.WriteByte(c)
+				inQuote = !inQuote
+			case inQuote:
+				tok.WriteByte(c)
+			case c == ';':
+				break scan
+			case c == '(':
+				flush()
+				depth++
+			case c == ')':
+				flush()
+				if depth == 0 {
+					return nil, fmt.Errorf("line %d: unbalanced parentheses", lineNo)
+				}
+				depth--
+			case c == ' ' || c == '\t' || c == '\r':
+				flush()
+			default:
+				tok.WriteByte(c)
+			}
+		}
+		if inQuote {
+			return nil, fmt.Errorf("line %d: unterminated quoted string", lineNo)
+		}
+		flush()
+
+		if depth == 0 {
+			if len(cur.tokens) > 0 {
+				lines = append(lines, *cur)
+			}
+			cur = nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if depth > 0 {
+		return nil, fmt.Errorf("line %d: unbalanced parentheses", cur.line)
+	}
+
+	return lines, nil
+}
+
+// zoneName makes a name absolute, lowercase and without the trailing dot
+func zoneName(name, origin string) (string, error) {
+	switch {
+	case name == "@":
+		if origin == "" {
+			return "", fmt.Errorf("@ used without an origin")
+		}
+		return origin, nil
+	case strings.HasSuffix(name, "."):
+		return strings.ToLower(strings.TrimSuffix(name, ".")), nil
+	case origin == "":
+		return "", fmt.Errorf("relative name %s used without an origin", name)
+	default:
+		return strings.ToLower(name) + "." + origin, nil
+	}
+}
+
+// resolveZoneData makes the names in the record data absolute
+func resolveZoneData(rec *ZoneRecord, origin string) error {
+	var indexes []int
+	switch rec.Type {
+	case "CNAME", "NS", "PTR", "DNAME":
+		indexes = []int{0}
+	case "MX":
+		indexes = []int{1}
+	case "SRV":
+		indexes = []int{3}
+	case "SOA":
+		indexes = []int{0, 1}
+	default:
+		return nil
+	}
+
+	if len(rec.Data) <= indexes[len(indexes)-1] {
+		return fmt.Errorf("%s record has too few fields", rec.Type)
+	}
+
+	for _, idx := range indexes {
+		name, err := zoneName(rec.Data[idx], origin)
+		if err != nil {
+			return err
+		}
+		rec.Data[idx] = name
+	}
+
+	return nil
+}
+
+// parseZoneTTL parses a TTL in seconds or with BIND units, e.g. "3600" or "1h30m"
+func parseZoneTTL(s string) (uint32, error) {
+	if n, err := strconv.ParseUint(s, 10, 32); err == nil {
+		return uint32(n), nil
+	}
+
+	units := map[byte]uint64{'s': 1, 'm': 60, 'h': 3600, 'd': 86400, 'w': 604800}
+
+	var total uint64
+	num := ""
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= '0' && c <= '9' {
+			num += string(c)
+			continue
+		}
+
+		unit, ok := units[byte(unicode.ToLower(rune(c)))]
+		if !ok || num == "" {
+			return 0, fmt.Errorf("invalid TTL %q", s)
+		}
+		n, _ := strconv.ParseUint(num, 10, 32)
+		total += n * unit
+		num = ""
+	}
+
+	if num != "" || total == 0 && s != "0" || total > 1<<32-1 {
+		return 0, fmt.Errorf("invalid TTL %q", s)
+	}
+
+	return uint32(total), nil
+}
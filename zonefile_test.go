@@ -0,0 +1,202 @@
+package edgecenterprotection_go
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseZoneFile(t *testing.T) {
+	zone := `$ORIGIN example.com.
+$TTL 1h
+@	IN	SOA	ns1 hostmaster (
+		2024010101 ; serial
+		3600       ; refresh
+		900 604800 300 )
+	IN	NS	ns1
+www	300	CNAME	@
+api		A	203.0.113.10
+	IN	AAAA	2001:db8::10 ; same owner as api
+mail.example.com.	MX	10 mx.example.net.
+txt	TXT	"v=spf1 ; not a comment" "(" 
+$ORIGIN sub.example.com.
+dev	A	203.0.113.20
+`
+
+	records, err := ParseZoneFile(strings.NewReader(zone), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"example.com.\t3600\tIN\tSOA\tns1.example.com hostmaster.example.com 2024010101 3600 900 604800 300",
+		"example.com.\t3600\tIN\tNS\tns1.example.com",
+		"www.example.com.\t300\tIN\tCNAME\texample.com",
+		"api.example.com.\t3600\tIN\tA\t203.0.113.10",
+		"api.example.com.\t3600\tIN\tAAAA\t2001:db8::10",
+		"mail.example.com.\t3600\tIN\tMX\t10 mx.example.net",
+		`txt.example.com.	3600	IN	TXT	"v=spf1 ; not a comment" "("`,
+		"dev.sub.example.com.\t3600\tIN\tA\t203.0.113.20",
+	}
+
+	var got []string
+	for _, rec := range records {
+		got = append(got, rec.String())
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("records:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	if records[0].Line != 3 || records[2].Line != 8 {
+		t.Errorf("lines = %d, %d, want 3 and 8", records[0].Line, records[2].Line)
+	}
+}
+
+func TestParseZoneFileTTLWithoutDirective(t *testing.T) {
+	records, err := ParseZoneFile(strings.NewReader("a 600 A 192.0.2.1\nb A 192.0.2.2\n"), "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if records[1].TTL != 600 || records[1].Name != "b.example.com" {
+		t.Errorf("second record = %+v, want the TTL of the previous record", records[1])
+	}
+}
+
+func TestParseZoneFileErrors(t *testing.T) {
+	tests := []struct {
+		name, zone, origin string
+	}{
+		{name: "relative name without origin", zone: "www A 192.0.2.1"},
+		{name: "@ without origin", zone: "@ A 192.0.2.1"},
+		{name: "unbalanced parentheses", zone: "@ SOA ns1 hostmaster ( 1 2 3 4 5", origin: "example.com"},
+		{name: "closing parenthesis", zone: "@ A 192.0.2.1 )", origin: "example.com"},
+		{name: "include", zone: "$INCLUDE other.zone", origin: "example.com"},
+		{name: "invalid TTL", zone: "$TTL forever", origin: "example.com"},
+		{name: "blank owner first", zone: "  A 192.0.2.1", origin: "example.com"},
+		{name: "unterminated quote", zone: `txt TXT "open`, origin: "example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if records, err := ParseZoneFile(strings.NewReader(tt.zone), tt.origin); err == nil {
+				t.Errorf("ParseZoneFile() = %v, want error", records)
+			}
+		})
+	}
+}
+
+func TestProposeZoneAliases(t *testing.T) {
+	zone := `$ORIGIN example.com.
+$TTL 300
+@	A	198.51.100.1
+www	CNAME	@
+shop	A	203.0.113.1
+blog	A	198.51.100.7
+mail	A	198.51.100.1
+ftp	A	192.0.2.10
+old	A	203.0.113.1
+other	A	192.0.2.99
+`
+	records, err := ParseZoneFile(strings.NewReader(zone), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resource := &Resource{Name: "example.com", ServiceIP: "203.0.113.1"}
+	origins := []Origin{{IP: "192.0.2.10:8080"}}
+	existing := []Alias{{Name: "old.example.com"}}
+
+	result := ProposeZoneAliases(records, resource, origins, existing)
+
+	var aliases []string
+	for _, a := range result.Aliases {
+		aliases = append(aliases, a.Name)
+	}
+	if want := []string{"www.example.com", "shop.example.com"}; !slices.Equal(aliases, want) {
+		t.Errorf("aliases = %v, want %v", aliases, want)
+	}
+
+	want := []ZoneAliasCandidate{
+		{Name: "mail.example.com", Addresses: []netip.Addr{netip.MustParseAddr("198.51.100.1")}, Line: 7},
+		{Name: "ftp.example.com", Addresses: []netip.Addr{netip.MustParseAddr("192.0.2.10")}, Line: 8},
+	}
+	if len(result.Candidates) != len(want) {
+		t.Fatalf("candidates = %+v, want %+v", result.Candidates, want)
+	}
+	for i, c := range result.Candidates {
+		if c.Name != want[i].Name || c.Line != want[i].Line || !slices.Equal(c.Addresses, want[i].Addresses) {
+			t.Errorf("candidate %d = %+v, want %+v", i, c, want[i])
+		}
+	}
+
+	if len(result.Changes) != 1 || result.Changes[0].Record.Name != "example.com" || result.Changes[0].Want != "A 203.0.113.1" {
+		t.Errorf("changes = %+v, want only the resource name moved to the service IP", result.Changes)
+	}
+}
+
+type fakeAliases struct {
+	AliasesService
+	existing  []Alias
+	listCalls int
+	created   []AliasCreateRequest
+}
+
+func (f *fakeAliases) List(_ context.Context, _ int64, opts *AliasListOptions) ([]Alias, *Response, error) {
+	f.listCalls++
+	return pageOf(f.existing, opts.Limit, opts.Offset), nil, nil
+}
+
+func (f *fakeAliases) Create(_ context.Context, _ int64, r *AliasCreateRequest) (*Alias, *Response, error) {
+	f.created = append(f.created, *r)
+	return &Alias{ID: int64(len(f.created)), Name: r.Name}, nil, nil
+}
+
+func TestImportZoneAliasesAllPages(t *testing.T) {
+	zone := `$ORIGIN example.com.
+@	300	A	203.0.113.1
+www	300	CNAME	@
+old	300	A	203.0.113.1
+ftp	300	A	192.0.2.10
+`
+	records, err := ParseZoneFile(strings.NewReader(zone), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the matching origin and alias are on the second page
+	origins := &fakeOrigins{}
+	aliases := &fakeAliases{}
+	for i := range listPageSize {
+		origins.existing = append(origins.existing, Origin{ID: int64(i + 1), IP: fmt.Sprintf("198.51.100.%d", i+1)})
+		aliases.existing = append(aliases.existing, Alias{ID: int64(i + 1), Name: fmt.Sprintf("a%d.example.com", i)})
+	}
+	origins.existing = append(origins.existing, Origin{IP: "192.0.2.10"})
+	aliases.existing = append(aliases.existing, Alias{Name: "old.example.com"})
+
+	c := &Client{Resources: &fakeResources{resource: Resource{ID: 1, Name: "example.com", ServiceIP: "203.0.113.1"}}, Origins: origins, Aliases: aliases}
+
+	result, err := ImportZoneAliases(context.Background(), c, 1, records, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Aliases) != 1 || result.Aliases[0].Name != "www.example.com" || len(aliases.created) != 0 {
+		t.Errorf("dry run = %+v, created %v, want only www.example.com proposed", result.Aliases, aliases.created)
+	}
+	if len(result.Candidates) != 1 || result.Candidates[0].Name != "ftp.example.com" {
+		t.Errorf("candidates = %+v, want ftp.example.com", result.Candidates)
+	}
+	if origins.listCalls != 2 || aliases.listCalls != 2 {
+		t.Errorf("list calls = %d origins, %d aliases, want 2 each", origins.listCalls, aliases.listCalls)
+	}
+
+	result, err = ImportZoneAliases(context.Background(), c, 1, records, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Created) != 1 || len(aliases.created) != 1 || aliases.created[0].Name != "www.example.com" {
+		t.Errorf("created = %+v, want www.example.com", result.Created)
+	}
+}